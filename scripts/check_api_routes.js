@@ -91,6 +91,7 @@ function normalizeRoute(path) {
 
 function normalizeTemplate(path) {
   return path
+    .replace(/\$\{(\w+)\}/g, ':$1')
     .replace(/\$\{[^}]+\}/g, ':id')
     .replace(/`/g, '')
 }
@@ -0,0 +1,106 @@
+// Package activity 在内存中记录评论者（按 Member.Mid）在所有监控目标下的近期评论，
+// 供刷屏规则判断同一账号在短时间内的评论数、重复内容和涉及的视频数。
+package activity
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/rules"
+)
+
+// Horizon 是活动记录的保留时长，与刷屏规则允许的最长窗口一致。
+const Horizon = rules.MaxFloodWindow
+
+// MaxPerUser 是每个评论者最多保留的评论数，超出时丢弃最早的评论。
+const MaxPerUser = 500
+
+// sweepInterval 控制清理全部评论者过期记录的频率。
+const sweepInterval = 10 * time.Minute
+
+// Tracker 按评论者保存滑动窗口内的评论，可在多个任务的 goroutine 间共享。
+type Tracker struct {
+	mu        sync.Mutex
+	users     map[int64][]rules.ActivityComment
+	lastSweep time.Time
+}
+
+// NewTracker 创建空的活动记录。
+func NewTracker() *Tracker {
+	return &Tracker{users: map[int64][]rules.ActivityComment{}}
+}
+
+// Record 记录 mid 的一条评论，返回发布时间与它相差不超过 within 的全部评论（按时间排序，包含本条）。
+// 记录只保留发布时间在 now 之前 Horizon 内的评论；同一条评论（按 RPID）只记录一次，mid 为 0 时不记录。
+func (t *Tracker) Record(mid int64, comment rules.ActivityComment, within time.Duration, now time.Time) []rules.ActivityComment {
+	if mid == 0 {
+		return []rules.ActivityComment{comment}
+	}
+	since := now.Add(-Horizon)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweep(since, now)
+
+	history := prune(t.users[mid], since)
+	known := false
+	for _, item := range history {
+		if item.RPID == comment.RPID {
+			known = true
+			break
+		}
+	}
+	if !known && !comment.At.Before(since) {
+		history = append(history, comment)
+		sort.SliceStable(history, func(i, j int) bool { return history[i].At.Before(history[j].At) })
+		if len(history) > MaxPerUser {
+			history = history[len(history)-MaxPerUser:]
+		}
+	}
+	if len(history) == 0 {
+		delete(t.users, mid)
+	} else {
+		t.users[mid] = history
+	}
+
+	related := make([]rules.ActivityComment, 0, len(history)+1)
+	found := false
+	for _, item := range history {
+		if item.At.Sub(comment.At).Abs() <= within {
+			related = append(related, item)
+			found = found || item.RPID == comment.RPID
+		}
+	}
+	if !found {
+		related = append(related, comment)
+	}
+	return related
+}
+
+// Users 返回当前有活动记录的评论者数。
+func (t *Tracker) Users() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.users)
+}
+
+// sweep 定期移除全部评论者的过期评论，避免不再发言的账号一直占用内存。
+func (t *Tracker) sweep(since, now time.Time) {
+	if now.Sub(t.lastSweep) < sweepInterval {
+		return
+	}
+	t.lastSweep = now
+	for mid, history := range t.users {
+		if kept := prune(history, since); len(kept) > 0 {
+			t.users[mid] = kept
+		} else {
+			delete(t.users, mid)
+		}
+	}
+}
+
+func prune(history []rules.ActivityComment, since time.Time) []rules.ActivityComment {
+	idx := sort.Search(len(history), func(i int) bool { return !history[i].At.Before(since) })
+	return history[idx:]
+}
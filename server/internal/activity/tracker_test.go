@@ -0,0 +1,45 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/rules"
+)
+
+func TestTrackerRecord(t *testing.T) {
+	tracker := NewTracker()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	comment := func(rpid int64, ago time.Duration) rules.ActivityComment {
+		return rules.ActivityComment{RPID: rpid, Message: "刷屏", At: now.Add(-ago)}
+	}
+
+	tracker.Record(7, comment(1, 20*time.Minute), time.Hour, now)
+	tracker.Record(7, comment(2, 5*time.Minute), time.Hour, now)
+	// 重复扫描到同一条评论不会重复记录
+	tracker.Record(7, comment(2, 5*time.Minute), time.Hour, now)
+	tracker.Record(8, comment(3, time.Minute), time.Hour, now)
+
+	history := tracker.Record(7, comment(4, 0), 10*time.Minute, now)
+	if len(history) != 2 || history[0].RPID != 2 || history[1].RPID != 4 {
+		t.Fatalf("history = %+v", history)
+	}
+	if history := tracker.Record(7, comment(4, 0), time.Hour, now); len(history) != 3 {
+		t.Fatalf("history within 1h = %+v", history)
+	}
+
+	// 超出保留时长的评论不记录，mid 为 0 不记录
+	if history := tracker.Record(9, comment(5, Horizon+time.Minute), Horizon, now); len(history) != 1 {
+		t.Fatalf("expired history = %+v", history)
+	}
+	tracker.Record(0, comment(6, 0), time.Hour, now)
+	if tracker.Users() != 2 {
+		t.Fatalf("Users = %d", tracker.Users())
+	}
+
+	later := now.Add(Horizon + sweepInterval)
+	tracker.Record(8, rules.ActivityComment{RPID: 7, At: later}, time.Hour, later)
+	if tracker.Users() != 1 {
+		t.Fatalf("Users after sweep = %d", tracker.Users())
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"math/rand"
 	"net/http"
@@ -17,6 +18,10 @@ import (
 	"time"
 
 	"github.com/imroc/req/v3"
+	"github.com/spiritlhl/goban/internal/logging"
+	"github.com/spiritlhl/goban/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // min 辅助函数
@@ -95,7 +100,8 @@ func NewBiliClient(cookies string, uid int64) *BiliClient {
 	client := req.C().
 		SetTimeout(30 * time.Second).
 		EnableKeepAlives().
-		ImpersonateChrome()
+		ImpersonateChrome().
+		WrapRoundTripFunc(traceRoundTrip)
 
 	if cookies != "" {
 		client.SetCommonHeader("Cookie", cookies)
@@ -115,7 +121,8 @@ func NewBiliClientWithProxy(cookies string, uid int64, proxyURL string) *BiliCli
 	client := req.C().
 		SetTimeout(30 * time.Second).
 		EnableKeepAlives().
-		ImpersonateChrome()
+		ImpersonateChrome().
+		WrapRoundTripFunc(traceRoundTrip)
 
 	if cookies != "" {
 		client.SetCommonHeader("Cookie", cookies)
@@ -141,6 +148,38 @@ func (c *BiliClient) SetRetryPolicy(maxRetries, retryInterval int) {
 	c.RetryInterval = retryInterval
 }
 
+// traceRoundTrip 为每次实际发出的B站请求（包括重试中的每一次）创建客户端 span，
+// 父 span 来自调用方通过 SetContext 传入的 ctx。
+func traceRoundTrip(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		name := "bili " + r.Method
+		attrs := []attribute.KeyValue{attribute.String("http.request.method", r.Method)}
+		if r.URL != nil {
+			name += " " + r.URL.Path
+			attrs = append(attrs,
+				attribute.String("server.address", r.URL.Host),
+				attribute.String("url.path", r.URL.Path),
+			)
+		}
+		ctx, span := telemetry.Tracer().Start(r.Context(), name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+		r.SetContext(ctx)
+
+		resp, err := rt.RoundTrip(r)
+		if resp != nil && resp.Response != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if err == nil && resp.StatusCode >= http.StatusBadRequest {
+				telemetry.RecordError(span, fmt.Errorf("HTTP %d", resp.StatusCode))
+			}
+		}
+		telemetry.RecordError(span, err)
+		return resp, err
+	}
+}
+
 // retryWithBackoff 使用指数退避策略重试函数
 func (c *BiliClient) retryWithBackoff(ctx context.Context, operation func() error) error {
 	if ctx == nil {
@@ -169,7 +208,13 @@ func (c *BiliClient) retryWithBackoff(ctx context.Context, operation func() erro
 			if errors.As(lastErr, &rateErr) && rateErr.RetryAfter > 0 {
 				backoffTime = rateErr.RetryAfter
 			}
-			log.Printf("[重试] 第 %d 次尝试失败，%v 后重试: %v", attempt+1, backoffTime, lastErr)
+			logging.For("bili").WarnContext(ctx, "请求失败，准备重试",
+				logging.KeyAttempt, attempt+1, "backoff", backoffTime, slog.Any(logging.KeyError, lastErr))
+			trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+				attribute.Int("attempt", attempt+1),
+				attribute.String("backoff", backoffTime.String()),
+				attribute.String("error", lastErr.Error()),
+			))
 			timer := time.NewTimer(backoffTime)
 			select {
 			case <-timer.C:
@@ -315,7 +360,8 @@ func GetNavInfoContext(ctx context.Context, cookies string) (*NavResponse, error
 	var nav NavResponse
 	client := req.C().
 		SetTimeout(30 * time.Second).
-		ImpersonateChrome()
+		ImpersonateChrome().
+		WrapRoundTripFunc(traceRoundTrip)
 	resp, err := client.R().
 		SetContext(ctx).
 		SetHeader("Cookie", cookies).
@@ -448,11 +494,80 @@ type CommentInfo struct {
 	Content struct {
 		Message string `json:"message"`
 	} `json:"content"`
-	Member struct {
-		Uname string `json:"uname"`
-		Mid   int64  `json:"mid"`
-	} `json:"member"`
-	CTime int64 `json:"ctime"`
+	Member CommentMember `json:"member"`
+	CTime  int64         `json:"ctime"`
+	// UpAction 是 UP 主对这条评论的互动：点赞和回复
+	UpAction struct {
+		Like  bool `json:"like"`
+		Reply bool `json:"reply"`
+	} `json:"up_action"`
+}
+
+// CommentMember 评论者资料，字段与评论接口返回的 member 对象一致
+type CommentMember struct {
+	Uname     string `json:"uname"`
+	Mid       int64  `json:"mid"`
+	Sex       string `json:"sex"` // 男、女、保密
+	LevelInfo struct {
+		CurrentLevel int `json:"current_level"`
+	} `json:"level_info"`
+	Vip struct {
+		VipType   int `json:"vipType"`   // 0=无，1=月度大会员，2=年度及以上大会员
+		VipStatus int `json:"vipStatus"` // 1=有效
+	} `json:"vip"`
+	Pendant struct {
+		PID  int64  `json:"pid"`
+		Name string `json:"name"`
+	} `json:"pendant"`
+	FansDetail *FansDetail `json:"fans_detail"` // 未佩戴粉丝勋章时为 null
+}
+
+// FansDetail 评论者在当前UP主下佩戴的粉丝勋章
+type FansDetail struct {
+	UID        int64  `json:"uid"`
+	MedalID    int64  `json:"medal_id"`
+	MedalName  string `json:"medal_name"`
+	Level      int    `json:"level"`
+	GuardLevel int    `json:"guard_level"`
+}
+
+// UnmarshalJSON 兼容 member.mid 为字符串的返回格式（评论接口实际返回字符串）。
+func (m *CommentMember) UnmarshalJSON(data []byte) error {
+	type plain CommentMember
+	var raw struct {
+		plain
+		Mid json.Number `json:"mid"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = CommentMember(raw.plain)
+	if raw.Mid != "" {
+		mid, err := raw.Mid.Int64()
+		if err != nil {
+			return fmt.Errorf("评论者UID格式错误: %w", err)
+		}
+		m.Mid = mid
+	}
+	return nil
+}
+
+// Level 返回评论者账号等级（0-6）。
+func (m CommentMember) Level() int {
+	return m.LevelInfo.CurrentLevel
+}
+
+// IsVip 表示评论者当前是否为有效大会员。
+func (m CommentMember) IsVip() bool {
+	return m.Vip.VipStatus == 1 && m.Vip.VipType > 0
+}
+
+// FansMedalLevel 返回佩戴的粉丝勋章等级，未佩戴时为 0。
+func (m CommentMember) FansMedalLevel() int {
+	if m.FansDetail == nil {
+		return 0
+	}
+	return m.FansDetail.Level
 }
 
 // GetVideoComments 获取视频评论（带分页和重试）
@@ -592,6 +707,48 @@ func (c *BiliClient) ReportCommentContext(ctx context.Context, oid, rpid int64,
 	})
 }
 
+// CommentExists 查询评论是否仍然存在（带重试），用于举报后的复查
+func (c *BiliClient) CommentExists(oid, rpid int64) (bool, error) {
+	return c.CommentExistsContext(context.Background(), oid, rpid)
+}
+
+func (c *BiliClient) CommentExistsContext(ctx context.Context, oid, rpid int64) (bool, error) {
+	exists := false
+	err := c.retryWithBackoff(ctx, func() error {
+		apiURL := fmt.Sprintf("https://api.bilibili.com/x/v2/reply/detail?type=1&oid=%d&root=%d&ps=1", oid, rpid)
+
+		var resp struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		r, err := c.ReqClient.R().
+			SetContext(ctx).
+			SetSuccessResult(&resp).
+			Get(apiURL)
+
+		if err != nil {
+			return fmt.Errorf("查询评论失败: %w", err)
+		}
+
+		if !r.IsSuccessState() {
+			return responseStatusError("查询评论失败", r)
+		}
+
+		switch resp.Code {
+		case 0:
+			exists = true
+			return nil
+		// code=12022 表示评论已被删除，12002 表示评论区已关闭
+		case 12022, 12002:
+			exists = false
+			return nil
+		default:
+			return apiCodeError("查询评论失败", resp.Message, resp.Code)
+		}
+	})
+	return exists, err
+}
+
 // GetUPInfo 获取UP主信息（带重试）
 func (c *BiliClient) GetUPInfo(mid int64) (string, error) {
 	return c.GetUPInfoContext(context.Background(), mid)
@@ -710,9 +867,8 @@ func GenerateTVQRCode() (*QRCodeResponse, error) {
 	params = signParams(params)
 	apiURL := "https://passport.bilibili.com/x/passport-tv-login/qrcode/auth_code"
 
-	log.Printf("[TV_QR] 请求URL: %s", apiURL)
-	log.Printf("[TV_QR] 请求参数: appkey=%s, local_id=%s, ts=%s",
-		params["appkey"], params["local_id"], params["ts"])
+	logger := logging.For("bili_login")
+	logger.Debug("请求TV端二维码", "url", apiURL, "appkey", params["appkey"])
 
 	var qrResp QRCodeResponse
 	client := req.C().
@@ -738,7 +894,7 @@ func GenerateTVQRCode() (*QRCodeResponse, error) {
 		return nil, fmt.Errorf("生成TV端二维码失败 code=%d msg=%s", qrResp.Code, qrResp.Message)
 	}
 
-	log.Println("[TV_QR] 生成成功")
+	logger.Debug("TV端二维码生成成功")
 
 	return &qrResp, nil
 }
@@ -755,7 +911,7 @@ func PollTVQRCodeStatus(authCode string) (*QRCodePollResponse, error) {
 	params = signParams(params)
 	apiURL := "https://passport.bilibili.com/x/passport-tv-login/qrcode/poll"
 
-	log.Println("[TV_POLL] 轮询二维码状态")
+	logger := logging.For("bili_login").With("client", "tv")
 
 	var pollResp QRCodePollResponse
 	client := req.C().
@@ -778,29 +934,28 @@ func PollTVQRCodeStatus(authCode string) (*QRCodePollResponse, error) {
 		return nil, fmt.Errorf("解析轮询响应失败: %w", err)
 	}
 
-	log.Printf("[TV_POLL] 原始响应 - code=%d, message=%s, data.code=%d",
-		pollResp.Code, pollResp.Message, pollResp.Data.Code)
+	logger.Debug("二维码轮询响应", "code", pollResp.Code, "message", pollResp.Message, "data_code", pollResp.Data.Code)
 
 	// TV端的状态码在顶层code字段
 	if pollResp.Code == 0 {
 		// 登录成功
 		pollResp.Data.Code = 0
-		log.Printf("[TV_POLL] 登录成功")
+		logger.Info("二维码登录成功")
 	} else {
 		// 将顶层code映射到data.code以保持统一接口
 		pollResp.Data.Code = pollResp.Code
 		switch pollResp.Code {
 		case 86038:
-			log.Printf("[TV_POLL] 二维码已过期")
+			logger.Debug("二维码已过期")
 		case 86090:
-			log.Printf("[TV_POLL] 已扫码，等待确认")
+			logger.Debug("已扫码，等待确认")
 		case 86101, 86039:
 			// 86039: 二维码尚未确认 (未扫码)
 			// 86101: 未扫码
 			pollResp.Data.Code = 86101
-			log.Printf("[TV_POLL] 等待扫码")
+			logger.Debug("等待扫码")
 		default:
-			log.Printf("[TV_POLL] 未知状态 code=%d", pollResp.Code)
+			logger.Warn("二维码轮询返回未知状态", "code", pollResp.Code)
 		}
 	}
 
@@ -838,33 +993,34 @@ func PollWebQRCodeStatus(oauthKey string) (*QRCodePollResponse, error) {
 	// data.code=-4: 未扫码
 	// data.code=-5: 已扫码待确认
 	// data.code=-2: 二维码已过期
-	log.Printf("[WEB_POLL] 原始响应 - status: %v, data.code: %d, data.message: %s",
-		pollResp.Status, pollResp.Data.Code, pollResp.Data.Message)
+	logger := logging.For("bili_login").With("client", "web")
+	logger.Debug("二维码轮询响应", "status", pollResp.Status, "data_code", pollResp.Data.Code, "message", pollResp.Data.Message)
 
 	// 优先判断 status 字段
 	if pollResp.Status {
 		// 登录成功
 		pollResp.Data.Code = 0
-		log.Printf("[WEB_POLL] 登录成功 - url=%s", pollResp.Data.URL)
+		// 回调 URL 的查询参数里带有 Cookie，不写入日志。
+		logger.Info("二维码登录成功")
 	} else {
 		// 根据 data.code 字段判断状态
 		switch pollResp.Data.Code {
 		case -4:
 			// 二维码未失效，等待扫码
 			pollResp.Data.Code = 86101
-			log.Printf("[WEB_POLL] 等待扫码")
+			logger.Debug("等待扫码")
 		case -5:
 			// 已扫码，等待确认
 			pollResp.Data.Code = 86090
-			log.Printf("[WEB_POLL] 已扫码，等待确认")
+			logger.Debug("已扫码，等待确认")
 		case -2:
 			// 二维码已失效
 			pollResp.Data.Code = 86038
-			log.Printf("[WEB_POLL] 二维码已过期")
+			logger.Debug("二维码已过期")
 		default:
 			// 其他未知状态，默认为等待扫码
 			pollResp.Data.Code = 86101
-			log.Printf("[WEB_POLL] 未知状态 code=%d，默认等待扫码", pollResp.Data.Code)
+			logger.Warn("二维码轮询返回未知状态，默认等待扫码", "code", pollResp.Data.Code)
 		}
 	}
 
@@ -873,31 +1029,31 @@ func PollWebQRCodeStatus(oauthKey string) (*QRCodePollResponse, error) {
 
 // ExtractCookiesFromWebPollResponse 从Web端轮询响应中提取Cookie
 func ExtractCookiesFromWebPollResponse(pollResp *QRCodePollResponse, client *req.Client) string {
+	logger := logging.For("bili_login").With("client", "web")
 	if pollResp == nil {
-		log.Printf("[WEB_COOKIE] 登录未完成，跳过Cookie提取 - 响应为空")
+		logger.Debug("登录未完成，跳过Cookie提取", "reason", "empty_response")
 		return ""
 	}
 	if pollResp.Data.Code != 0 {
-		log.Printf("[WEB_COOKIE] 登录未完成，跳过Cookie提取 - code=%d", pollResp.Data.Code)
+		logger.Debug("登录未完成，跳过Cookie提取", "code", pollResp.Data.Code)
 		return ""
 	}
 
 	if pollResp.Data.URL == "" {
-		log.Printf("[WEB_COOKIE] 错误：URL为空")
+		logger.Error("登录回调URL为空")
 		return ""
 	}
 
 	// Web端登录成功后，URL中包含Cookie参数
-	log.Printf("[WEB_COOKIE] 解析登录URL: %s", pollResp.Data.URL[:min(100, len(pollResp.Data.URL))])
 
 	parsedURL, err := url.Parse(pollResp.Data.URL)
 	if err != nil {
-		log.Printf("[WEB_COOKIE] URL解析失败: %v", err)
+		logger.Error("登录回调URL解析失败", slog.Any(logging.KeyError, err))
 		return ""
 	}
 	params := parsedURL.Query()
 	if len(params) == 0 {
-		log.Printf("[WEB_COOKIE] URL没有查询参数")
+		logger.Error("登录回调URL没有查询参数")
 		return ""
 	}
 
@@ -908,8 +1064,8 @@ func ExtractCookiesFromWebPollResponse(pollResp *QRCodePollResponse, client *req
 	sid := params.Get("sid")
 
 	if dedeUserID == "" || sessdata == "" || biliJct == "" {
-		log.Printf("[WEB_COOKIE] 关键字段缺失 - DedeUserID: %v, SESSDATA: %v, bili_jct: %v",
-			dedeUserID != "", sessdata != "", biliJct != "")
+		logger.Error("Cookie关键字段缺失",
+			"has_dede_user_id", dedeUserID != "", "has_sessdata", sessdata != "", "has_bili_jct", biliJct != "")
 		return ""
 	}
 
@@ -927,26 +1083,26 @@ func ExtractCookiesFromWebPollResponse(pollResp *QRCodePollResponse, client *req
 	}
 
 	result := strings.Join(cookieStrs, "; ")
-	log.Printf("[WEB_COOKIE] 提取成功 - DedeUserID: %s, SESSDATA长度: %d, bili_jct长度: %d",
-		dedeUserID, len(sessdata), len(biliJct))
+	logger.Info("Cookie提取成功", "uid", dedeUserID)
 
 	return result
 }
 
 // ExtractCookiesFromTVPollResponse 从TV端轮询响应中提取Cookie
 func ExtractCookiesFromTVPollResponse(pollResp *QRCodePollResponse) string {
+	logger := logging.For("bili_login").With("client", "tv")
 	if pollResp == nil {
-		log.Printf("[TV_COOKIE] 登录未完成，跳过Cookie提取 - 响应为空")
+		logger.Debug("登录未完成，跳过Cookie提取", "reason", "empty_response")
 		return ""
 	}
 	if pollResp.Data.Code != 0 {
-		log.Printf("[TV_COOKIE] 登录未完成，跳过Cookie提取 - code=%d", pollResp.Data.Code)
+		logger.Debug("登录未完成，跳过Cookie提取", "code", pollResp.Data.Code)
 		return ""
 	}
 
 	// TV端登录成功后，从 cookie_info.cookies 数组中提取
 	if len(pollResp.Data.CookieInfo.Cookies) == 0 {
-		log.Printf("[TV_COOKIE] 错误：cookie_info.cookies为空")
+		logger.Error("cookie_info.cookies为空")
 		return ""
 	}
 
@@ -956,8 +1112,8 @@ func ExtractCookiesFromTVPollResponse(pollResp *QRCodePollResponse) string {
 		cookieMap[cookie.Name] = cookie.Value
 	}
 	if cookieMap["DedeUserID"] == "" || cookieMap["SESSDATA"] == "" || cookieMap["bili_jct"] == "" {
-		log.Printf("[TV_COOKIE] 关键字段缺失 - DedeUserID: %v, SESSDATA: %v, bili_jct: %v",
-			cookieMap["DedeUserID"] != "", cookieMap["SESSDATA"] != "", cookieMap["bili_jct"] != "")
+		logger.Error("Cookie关键字段缺失",
+			"has_dede_user_id", cookieMap["DedeUserID"] != "", "has_sessdata", cookieMap["SESSDATA"] != "", "has_bili_jct", cookieMap["bili_jct"] != "")
 		return ""
 	}
 
@@ -976,8 +1132,7 @@ func ExtractCookiesFromTVPollResponse(pollResp *QRCodePollResponse) string {
 	}
 
 	result := strings.Join(cookieStrs, "; ")
-	log.Printf("[TV_COOKIE] 提取成功 - DedeUserID: %s, SESSDATA长度: %d",
-		cookieMap["DedeUserID"], len(cookieMap["SESSDATA"]))
+	logger.Info("Cookie提取成功", "uid", cookieMap["DedeUserID"])
 
 	return result
 }
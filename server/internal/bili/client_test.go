@@ -2,9 +2,19 @@ package bili
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/spiritlhl/goban/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestAPICodeErrorClassifiesRiskControl(t *testing.T) {
@@ -129,3 +139,64 @@ func TestExtractCookiesFromTVPollResponseRequiresCoreCookies(t *testing.T) {
 		t.Fatalf("expected missing SESSDATA to produce empty cookie string, got %q", got)
 	}
 }
+
+func TestTraceRoundTripRecordsClientSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, parent := telemetry.StartSpan(context.Background(), "monitor.video")
+	client := req.C().WrapRoundTripFunc(traceRoundTrip)
+	if _, err := client.R().SetContext(ctx).Get(server.URL + "/x/v2/reply"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	parent.End()
+
+	var found bool
+	for _, span := range exporter.GetSpans() {
+		if span.Name != "bili GET /x/v2/reply" {
+			continue
+		}
+		found = true
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Fatal("request span should be a child of the caller span")
+		}
+		status := attribute.NewSet(span.Attributes...)
+		if value, ok := status.Value("http.response.status_code"); !ok || value.AsInt64() != http.StatusTooManyRequests {
+			t.Fatalf("expected status code attribute, got %v", span.Attributes)
+		}
+	}
+	if !found {
+		t.Fatalf("expected bili request span, got %d spans", len(exporter.GetSpans()))
+	}
+}
+
+func TestCommentMemberDecodesReplyPayload(t *testing.T) {
+	payload := `{"code":0,"data":{"replies":[{"rpid":1,"mid":42,"content":{"message":"hi"},
+		"member":{"mid":"42","uname":"alice","sex":"女","level_info":{"current_level":2},
+		"vip":{"vipType":2,"vipStatus":1},"pendant":{"pid":7,"name":"挂件"},
+		"fans_detail":{"uid":42,"medal_name":"牌子","level":12}},"up_action":{"like":true,"reply":false}},
+		{"rpid":2,"member":{"mid":43,"uname":"bob","level_info":{"current_level":6},"fans_detail":null}}]}}`
+	var resp CommentListResponse
+	if err := json.Unmarshal([]byte(payload), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	alice := resp.Data.Replies[0].Member
+	if alice.Mid != 42 || alice.Level() != 2 || !alice.IsVip() || alice.FansMedalLevel() != 12 || alice.Pendant.PID != 7 || alice.Sex != "女" {
+		t.Fatalf("unexpected member: %#v", alice)
+	}
+	if action := resp.Data.Replies[0].UpAction; !action.Like || action.Reply {
+		t.Fatalf("unexpected up_action: %#v", action)
+	}
+	bob := resp.Data.Replies[1].Member
+	if bob.Mid != 43 || bob.Level() != 6 || bob.IsVip() || bob.FansDetail != nil {
+		t.Fatalf("unexpected member: %#v", bob)
+	}
+}
@@ -0,0 +1,202 @@
+// Package classifier 实现按字符 n-gram 的朴素贝叶斯文本分类器，用举报历史和审核结果训练，
+// 为评论给出垃圾评论概率，任务可把它作为一条计分规则使用。
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/spiritlhl/goban/internal/rules"
+)
+
+// 样本标签。
+const (
+	LabelHam  = "ham"  // 正常评论
+	LabelSpam = "spam" // 垃圾评论
+)
+
+const (
+	ham  = 0
+	spam = 1
+)
+
+// 训练参数的取值范围和默认值。
+const (
+	DefaultNGram       = 2
+	MaxNGram           = 3
+	MinSamplesPerClass = 10
+	// MinFeatureCount 是特征在全部训练样本中至少出现的次数，只出现一次的 n-gram 多为噪声。
+	MinFeatureCount = 2
+	// MaxFeatures 限制模型的特征数，按出现次数保留最常见的特征，避免模型过大。
+	MaxFeatures = 50000
+)
+
+// MatchType 是分类器合成规则的匹配类型，写入举报记录的 match_type。
+const MatchType = "classifier"
+
+// RuleName 是分类器合成规则的名称。
+const RuleName = "文本分类器"
+
+// Sample 是一条带标签的训练或评估样本。
+type Sample struct {
+	Text   string `json:"text"`
+	Label  string `json:"label"`
+	Source string `json:"source"`
+}
+
+// Model 是二分类的多项式朴素贝叶斯模型，每条文本的特征只计一次（二值化），对短评论更稳定。
+// 数组下标 0 为正常评论，1 为垃圾评论。
+type Model struct {
+	NGram    int               `json:"ngram"`
+	Docs     [2]int            `json:"docs"`
+	Totals   [2]int            `json:"totals"`
+	Features map[string][2]int `json:"features"`
+}
+
+// Train 用样本训练模型，两类样本都少于 MinSamplesPerClass 条时返回错误。
+func Train(samples []Sample, ngram int) (*Model, error) {
+	if ngram < 1 || ngram > MaxNGram {
+		return nil, fmt.Errorf("n-gram 长度需在 1-%d 之间", MaxNGram)
+	}
+	model := &Model{NGram: ngram, Features: map[string][2]int{}}
+	for _, sample := range samples {
+		class := classOf(sample.Label)
+		features := Features(sample.Text, ngram)
+		if class < 0 || len(features) == 0 {
+			continue
+		}
+		model.Docs[class]++
+		for _, feature := range features {
+			counts := model.Features[feature]
+			counts[class]++
+			model.Features[feature] = counts
+		}
+	}
+	if model.Docs[spam] < MinSamplesPerClass || model.Docs[ham] < MinSamplesPerClass {
+		return nil, fmt.Errorf("训练样本不足：垃圾评论和正常评论各需要至少 %d 条，当前 %d / %d", MinSamplesPerClass, model.Docs[spam], model.Docs[ham])
+	}
+	model.prune()
+	return model, nil
+}
+
+// prune 移除出现次数过少的特征，并只保留最常见的 MaxFeatures 个。
+func (m *Model) prune() {
+	type featureCount struct {
+		feature string
+		count   int
+	}
+	kept := make([]featureCount, 0, len(m.Features))
+	for feature, counts := range m.Features {
+		if total := counts[ham] + counts[spam]; total >= MinFeatureCount {
+			kept = append(kept, featureCount{feature, total})
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		if kept[i].count != kept[j].count {
+			return kept[i].count > kept[j].count
+		}
+		return kept[i].feature < kept[j].feature
+	})
+	if len(kept) > MaxFeatures {
+		kept = kept[:MaxFeatures]
+	}
+	features := make(map[string][2]int, len(kept))
+	m.Totals = [2]int{}
+	for _, item := range kept {
+		counts := m.Features[item.feature]
+		features[item.feature] = counts
+		m.Totals[ham] += counts[ham]
+		m.Totals[spam] += counts[spam]
+	}
+	m.Features = features
+}
+
+// Probability 返回文本是垃圾评论的概率。文本规范化后没有可用特征时返回 false。
+func (m *Model) Probability(text string) (float64, bool) {
+	features := Features(text, m.NGram)
+	if len(features) == 0 || m.Docs[ham] == 0 || m.Docs[spam] == 0 {
+		return 0, false
+	}
+	vocabulary := float64(len(m.Features))
+	scores := [2]float64{
+		math.Log(float64(m.Docs[ham]) / float64(m.Docs[ham]+m.Docs[spam])),
+		math.Log(float64(m.Docs[spam]) / float64(m.Docs[ham]+m.Docs[spam])),
+	}
+	known := false
+	for _, feature := range features {
+		counts, ok := m.Features[feature]
+		if !ok {
+			continue
+		}
+		known = true
+		for class := range scores {
+			// 拉普拉斯平滑
+			scores[class] += math.Log((float64(counts[class]) + 1) / (float64(m.Totals[class]) + vocabulary))
+		}
+	}
+	if !known {
+		return 0, false
+	}
+	return 1 / (1 + math.Exp(scores[ham]-scores[spam])), true
+}
+
+// Match 把达到阈值的分类结果转为合成规则的命中，得分与默认权重的关键字规则相同。
+func Match(probability float64) rules.MatchResult {
+	return rules.MatchResult{
+		RuleName:  RuleName,
+		MatchType: MatchType,
+		Matched:   fmt.Sprintf("垃圾评论概率 %.0f%%", probability*100),
+		Score:     rules.DefaultWeight,
+	}
+}
+
+// Features 返回文本去重后的字符 n-gram（长度 1 到 ngram），文本先按 rules.Canonicalize 规范化。
+func Features(text string, ngram int) []string {
+	runes := []rune(rules.Canonicalize(text))
+	seen := map[string]bool{}
+	features := make([]string, 0, len(runes)*ngram)
+	for n := 1; n <= ngram; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			feature := string(runes[i : i+n])
+			if !seen[feature] {
+				seen[feature] = true
+				features = append(features, feature)
+			}
+		}
+	}
+	return features
+}
+
+// Encode 把模型序列化为 JSON，存入数据库。
+func Encode(m *Model) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Decode 解析 Encode 的结果。
+func Decode(data string) (*Model, error) {
+	var m Model
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, fmt.Errorf("模型数据无效: %w", err)
+	}
+	if m.NGram < 1 || m.NGram > MaxNGram || m.Features == nil {
+		return nil, fmt.Errorf("模型数据无效")
+	}
+	return &m, nil
+}
+
+func classOf(label string) int {
+	switch label {
+	case LabelSpam:
+		return spam
+	case LabelHam:
+		return ham
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,235 @@
+package classifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/watchlist"
+)
+
+var (
+	spamTemplates = []string{"加我微信领%s福利，名额有限", "点我主页看%s免费资源", "私信我低价出%s，全网最便宜", "兼职刷单日结%s元，加群详聊"}
+	hamTemplates  = []string{"这期讲%s讲得真清楚，学到了", "up主的%s剪辑越来越好了", "%s这段笑死我了哈哈", "期待下一期关于%s的视频"}
+	topics        = []string{"游戏", "电影", "音乐", "编程", "数学", "历史", "美食", "旅行", "摄影", "健身"}
+)
+
+func corpus() []Sample {
+	var samples []Sample
+	for _, topic := range topics {
+		for _, template := range spamTemplates {
+			samples = append(samples, Sample{Text: fmt.Sprintf(template, topic), Label: LabelSpam})
+		}
+		for _, template := range hamTemplates {
+			samples = append(samples, Sample{Text: fmt.Sprintf(template, topic), Label: LabelHam})
+		}
+	}
+	return samples
+}
+
+func TestTrainAndProbability(t *testing.T) {
+	model, err := Train(corpus(), DefaultNGram)
+	if err != nil {
+		t.Fatalf("Train failed: %v", err)
+	}
+	if p, ok := model.Probability("加我微信领动漫福利"); !ok || p < 0.9 {
+		t.Fatalf("expected spam probability > 0.9, got %v (ok=%v)", p, ok)
+	}
+	if p, ok := model.Probability("这期讲动漫讲得真清楚"); !ok || p > 0.1 {
+		t.Fatalf("expected spam probability < 0.1, got %v (ok=%v)", p, ok)
+	}
+	if _, ok := model.Probability("！！！"); ok {
+		t.Fatal("expected text without features to be skipped")
+	}
+
+	data, err := Encode(model)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	a, _ := model.Probability("私信我低价出手办")
+	b, _ := decoded.Probability("私信我低价出手办")
+	if a != b {
+		t.Fatalf("expected decoded model to score identically, got %v and %v", a, b)
+	}
+}
+
+func TestTrainRequiresBothClasses(t *testing.T) {
+	samples := corpus()[:8]
+	if _, err := Train(samples, DefaultNGram); err == nil {
+		t.Fatal("expected error for too few samples")
+	}
+	if _, err := Train(corpus(), MaxNGram+1); err == nil {
+		t.Fatal("expected error for invalid n-gram")
+	}
+}
+
+func TestSplitIsDeterministic(t *testing.T) {
+	samples := corpus()
+	train, test := Split(samples, 30)
+	if len(train)+len(test) != len(samples) || len(test) == 0 || len(train) == 0 {
+		t.Fatalf("unexpected split %d/%d", len(train), len(test))
+	}
+	_, again := Split(samples, 30)
+	if len(again) != len(test) {
+		t.Fatalf("expected the same holdout, got %d and %d", len(again), len(test))
+	}
+	for i := range test {
+		if test[i].Text != again[i].Text {
+			t.Fatalf("holdout differs at %d", i)
+		}
+	}
+	// 全角和大小写差异不影响划分
+	if InHoldout("ＡＢＣ测试", 30) != InHoldout("abc测试", 30) {
+		t.Fatal("expected canonical text to decide the split")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	model, err := Train(corpus(), DefaultNGram)
+	if err != nil {
+		t.Fatalf("Train failed: %v", err)
+	}
+	samples := []Sample{
+		{Text: "加我微信领动漫福利", Label: LabelSpam},
+		{Text: "点我主页看动漫免费资源", Label: LabelSpam},
+		{Text: "这期讲动漫讲得真清楚", Label: LabelHam},
+		{Text: "！！！", Label: LabelHam},
+	}
+	metrics, curve := Evaluate(model, samples, DefaultThreshold)
+	if metrics.Samples != 4 || metrics.Skipped != 1 {
+		t.Fatalf("unexpected sample counts %+v", metrics)
+	}
+	if metrics.TruePositives != 2 || metrics.TrueNegatives != 1 || metrics.Precision != 1 || metrics.Recall != 1 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+	if len(curve) != len(CurveThresholds) {
+		t.Fatalf("expected %d curve points, got %d", len(CurveThresholds), len(curve))
+	}
+	if err := ValidateThreshold(0.3); err == nil {
+		t.Fatal("expected threshold below the minimum to be rejected")
+	}
+	if err := ValidateThreshold(0); err != nil {
+		t.Fatalf("expected 0 to disable the classifier: %v", err)
+	}
+}
+
+func TestRetrainFromReportHistory(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	var keptText string
+	for i, sample := range corpus() {
+		if sample.Label == LabelSpam {
+			db.Create(&models.ReportRecord{CommentID: int64(i + 1), CommentContent: sample.Text, Success: true})
+		} else {
+			db.Create(&models.ClassifierSample{Content: sample.Text, Label: LabelHam})
+			keptText = sample.Text
+		}
+	}
+	// 举报失败的记录不参与训练，复查仍在的评论和人工标注的文本以审核结论为准
+	db.Create(&models.ReportRecord{CommentID: 1001, CommentContent: "失败的举报不算样本", Success: false})
+	db.Create(&models.ReportRecord{CommentID: 1002, CommentContent: keptText, Success: true, RemovalStatus: "kept"})
+
+	dataset, err := LoadDataset(db)
+	if err != nil {
+		t.Fatalf("LoadDataset failed: %v", err)
+	}
+	if dataset.Spam != 40 || dataset.Ham != 40 {
+		t.Fatalf("unexpected dataset %+v", dataset)
+	}
+	if dataset.Sources[SourceManual] != 40 || dataset.Sources[SourceReport] != 40 {
+		t.Fatalf("unexpected sources %+v", dataset.Sources)
+	}
+
+	cache := NewCache()
+	if model, err := cache.Active(db); err != nil || model != nil {
+		t.Fatalf("expected no active model, got %v (%v)", model, err)
+	}
+	result, err := Retrain(db, TrainOptions{NGram: DefaultNGram, Holdout: DefaultHoldout, Activate: true, CreatedBy: "admin"})
+	if err != nil {
+		t.Fatalf("Retrain failed: %v", err)
+	}
+	if !result.Model.Active || result.Model.TrainSamples+result.Model.TestSamples != 80 {
+		t.Fatalf("unexpected model %+v", result.Model)
+	}
+	model, err := cache.Active(db)
+	if err != nil || model == nil {
+		t.Fatalf("expected active model, got %v (%v)", model, err)
+	}
+	if p, ok := model.Probability("兼职刷单日结动漫元"); !ok || p < 0.5 {
+		t.Fatalf("expected spam probability >= 0.5, got %v", p)
+	}
+
+	second, err := Retrain(db, TrainOptions{NGram: 1, Holdout: DefaultHoldout, Activate: true})
+	if err != nil {
+		t.Fatalf("second Retrain failed: %v", err)
+	}
+	var active []models.ClassifierModel
+	db.Where("active = ?", true).Find(&active)
+	if len(active) != 1 || active[0].ID != second.Model.ID {
+		t.Fatalf("expected only the newest model to be active, got %+v", active)
+	}
+	if err := Activate(db, 9999); err == nil {
+		t.Fatal("expected activating a missing model to fail")
+	}
+}
+
+func TestLoadDatasetSkipsContentFreeReports(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	reports := []models.ReportRecord{
+		// 只由分类器或重点关注“总是举报”促成的举报不作为样本
+		{CommentID: 1, CommentContent: "分类器自己举报的评论", Success: true, MatchType: MatchType,
+			MatchedRules: `[{"rule_id":0,"rule_name":"文本分类器","match_type":"classifier","score":1}]`},
+		{CommentID: 2, CommentContent: "重点关注用户的普通评论", Success: true, MatchType: watchlist.MatchType,
+			MatchedRules: `[{"rule_id":0,"rule_name":"重点关注用户","match_type":"watchlist","score":1}]`},
+		{CommentID: 3, CommentContent: "两个合成规则一起命中", Success: true, MatchType: MatchType,
+			MatchedRules: `[{"rule_id":0,"rule_name":"文本分类器","score":1},{"rule_id":0,"rule_name":"重点关注用户","score":1}]`},
+		{CommentID: 4, CommentContent: "没有得分明细的旧记录", Success: true, MatchType: watchlist.MatchType},
+		// 刷屏规则只看评论者的活动，只由它促成的举报同样不作为样本
+		{CommentID: 7, CommentContent: "刷屏账号的随口一句", Success: true, MatchType: rules.MatchTypeFlood,
+			MatchedRules: `[{"rule_id":8,"rule_name":"十分钟五条","match_type":"flood","score":1}]`},
+		{CommentID: 8, CommentContent: "刷屏加分类器", Success: true, MatchType: rules.MatchTypeFlood,
+			MatchedRules: `[{"rule_id":8,"rule_name":"十分钟五条","match_type":"flood","score":1},{"rule_id":0,"rule_name":"文本分类器","match_type":"classifier","score":1}]`},
+		// 有关键字规则参与的举报照常使用
+		{CommentID: 5, CommentContent: "加我微信领福利", Success: true, MatchType: "plain",
+			MatchedRules: `[{"rule_id":3,"rule_name":"广告","match_type":"plain","score":1},{"rule_id":0,"rule_name":"文本分类器","match_type":"classifier","score":1}]`},
+		{CommentID: 6, CommentContent: "点我主页看资源", Success: true, MatchType: "plain"},
+	}
+	if err := db.Create(&reports).Error; err != nil {
+		t.Fatalf("create reports: %v", err)
+	}
+
+	dataset, err := LoadDataset(db)
+	if err != nil {
+		t.Fatalf("LoadDataset failed: %v", err)
+	}
+	if dataset.Spam != 2 || dataset.Ham != 0 {
+		t.Fatalf("unexpected dataset %+v", dataset)
+	}
+	for _, sample := range dataset.Samples {
+		if sample.Text != "加我微信领福利" && sample.Text != "点我主页看资源" {
+			t.Fatalf("unexpected sample %q", sample.Text)
+		}
+	}
+}
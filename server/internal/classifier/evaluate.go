@@ -0,0 +1,127 @@
+package classifier
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/spiritlhl/goban/internal/rules"
+)
+
+// 留出集比例的取值范围和默认值（百分比）。
+const (
+	DefaultHoldout = 20
+	MinHoldout     = 5
+	MaxHoldout     = 50
+)
+
+// DefaultThreshold 是评估时默认的判定阈值。
+const DefaultThreshold = 0.5
+
+// CurveThresholds 是评估时额外计算指标的一组阈值，便于为任务挑选合适的概率阈值。
+var CurveThresholds = []float64{0.5, 0.6, 0.7, 0.8, 0.85, 0.9, 0.95, 0.99}
+
+// Split 按规范化文本的哈希把样本分为训练集和留出集，同一文本总是落在同一侧，
+// 因此重新训练后仍可在同一批留出样本上比较模型。
+func Split(samples []Sample, holdout int) (train, test []Sample) {
+	for _, sample := range samples {
+		if InHoldout(sample.Text, holdout) {
+			test = append(test, sample)
+		} else {
+			train = append(train, sample)
+		}
+	}
+	return train, test
+}
+
+// InHoldout 报告文本是否属于比例为 holdout% 的留出集。
+func InHoldout(text string, holdout int) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(rules.Canonicalize(text)))
+	return int(h.Sum32()%100) < holdout
+}
+
+// Metrics 是模型在一组样本上按某个阈值判定的结果，垃圾评论为正类。
+type Metrics struct {
+	Threshold      float64 `json:"threshold"`
+	Samples        int     `json:"samples"`
+	Skipped        int     `json:"skipped"` // 没有可用特征、无法判定的样本数
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	TrueNegatives  int     `json:"true_negatives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+	Accuracy       float64 `json:"accuracy"`
+}
+
+// Evaluate 计算模型在样本上按 threshold 判定的精确率和召回率，并给出 CurveThresholds 各阈值下的结果。
+func Evaluate(m *Model, samples []Sample, threshold float64) (Metrics, []Metrics) {
+	type scored struct {
+		probability float64
+		class       int
+	}
+	items := make([]scored, 0, len(samples))
+	skipped := 0
+	for _, sample := range samples {
+		class := classOf(sample.Label)
+		if class < 0 {
+			continue
+		}
+		probability, ok := m.Probability(sample.Text)
+		if !ok {
+			skipped++
+			continue
+		}
+		items = append(items, scored{probability, class})
+	}
+	measure := func(threshold float64) Metrics {
+		metrics := Metrics{Threshold: threshold, Samples: len(items) + skipped, Skipped: skipped}
+		for _, item := range items {
+			predicted := item.probability >= threshold
+			switch {
+			case predicted && item.class == spam:
+				metrics.TruePositives++
+			case predicted:
+				metrics.FalsePositives++
+			case item.class == spam:
+				metrics.FalseNegatives++
+			default:
+				metrics.TrueNegatives++
+			}
+		}
+		metrics.Precision = ratio(metrics.TruePositives, metrics.TruePositives+metrics.FalsePositives)
+		metrics.Recall = ratio(metrics.TruePositives, metrics.TruePositives+metrics.FalseNegatives)
+		if metrics.Precision+metrics.Recall > 0 {
+			metrics.F1 = 2 * metrics.Precision * metrics.Recall / (metrics.Precision + metrics.Recall)
+		}
+		metrics.Accuracy = ratio(metrics.TruePositives+metrics.TrueNegatives, len(items))
+		return metrics
+	}
+	curve := make([]Metrics, 0, len(CurveThresholds))
+	for _, value := range CurveThresholds {
+		curve = append(curve, measure(value))
+	}
+	return measure(threshold), curve
+}
+
+func ratio(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// 任务分类器阈值的取值范围，0 表示不使用分类器。
+const (
+	MinTaskThreshold = 0.5
+	MaxTaskThreshold = 0.99
+)
+
+// ValidateThreshold 校验任务的分类器阈值。
+func ValidateThreshold(threshold float64) error {
+	if threshold == 0 || (threshold >= MinTaskThreshold && threshold <= MaxTaskThreshold) {
+		return nil
+	}
+	return fmt.Errorf("分类器阈值需为 0（不使用）或 %.2f-%.2f 之间", MinTaskThreshold, MaxTaskThreshold)
+}
@@ -0,0 +1,274 @@
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/spiritlhl/goban/internal/copypasta"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/watchlist"
+	"gorm.io/gorm"
+)
+
+// 训练样本的来源。
+const (
+	SourceReport    = "report"    // 举报成功、尚未复查的评论，视为垃圾评论
+	SourceRemoved   = "removed"   // 举报后复查确认已删除的评论
+	SourceKept      = "kept"      // 举报后复查发现仍在的评论，视为正常评论
+	SourceDismissed = "dismissed" // 被驳回的近似重复簇中的评论
+	SourceManual    = "manual"    // 审核人员标注的样本
+)
+
+// MaxReportSamples 是每次训练最多读取的举报记录数，按时间倒序取最近的记录。
+const MaxReportSamples = 100000
+
+// contentFreeRules 是不看评论内容的合成规则，按匹配类型和名称对应。分类器自身的命中会让训练学习自己的输出，
+// 重点关注“总是举报”不论内容举报，只由它们促成的举报不能作为垃圾评论样本。
+// 刷屏规则只看评论者的近期活动，同样不看内容，但它是普通规则，没有固定名称，只按匹配类型判断，见 isContentFreeType。
+var contentFreeRules = map[string]string{
+	MatchType:           RuleName,
+	watchlist.MatchType: watchlist.RuleName,
+}
+
+// sourcePriority 决定同一文本有多个标签时以哪个为准：人工标注优先，其次是复查和驳回等审核结论。
+var sourcePriority = map[string]int{
+	SourceReport:    0,
+	SourceRemoved:   1,
+	SourceKept:      2,
+	SourceDismissed: 2,
+	SourceManual:    3,
+}
+
+// Dataset 是从数据库汇总的全部样本及各来源的数量。
+type Dataset struct {
+	Samples []Sample       `json:"-"`
+	Spam    int            `json:"spam"`
+	Ham     int            `json:"ham"`
+	Sources map[string]int `json:"sources"`
+}
+
+// LoadDataset 从举报记录、驳回的评论簇和人工标注汇总训练样本，规范化后相同的文本只保留一条。
+func LoadDataset(db *gorm.DB) (Dataset, error) {
+	byText := map[string]Sample{}
+	var order []string
+	add := func(text, label, source string) {
+		key := rules.Canonicalize(text)
+		if key == "" {
+			return
+		}
+		existing, ok := byText[key]
+		if !ok {
+			order = append(order, key)
+		} else if sourcePriority[source] < sourcePriority[existing.Source] {
+			return
+		}
+		byText[key] = Sample{Text: text, Label: label, Source: source}
+	}
+
+	var reports []models.ReportRecord
+	if err := db.Select("comment_content", "removal_status", "match_type", "matched_rules").Where("success = ?", true).
+		Order("id DESC").Limit(MaxReportSamples).Find(&reports).Error; err != nil {
+		return Dataset{}, err
+	}
+	for _, report := range reports {
+		if contentFreeReport(report) {
+			continue
+		}
+		// removal_status 的取值见 monitor.RemovalRemoved / monitor.RemovalKept
+		switch report.RemovalStatus {
+		case "kept":
+			add(report.CommentContent, LabelHam, SourceKept)
+		case "removed":
+			add(report.CommentContent, LabelSpam, SourceRemoved)
+		default:
+			add(report.CommentContent, LabelSpam, SourceReport)
+		}
+	}
+
+	var dismissed []string
+	if err := db.Model(&models.ClusterComment{}).
+		Joins("JOIN comment_clusters ON comment_clusters.id = cluster_comments.cluster_id").
+		Where("comment_clusters.status = ?", copypasta.StatusDismissed).
+		Pluck("cluster_comments.content", &dismissed).Error; err != nil {
+		return Dataset{}, err
+	}
+	for _, text := range dismissed {
+		add(text, LabelHam, SourceDismissed)
+	}
+
+	var labelled []models.ClassifierSample
+	if err := db.Order("id ASC").Find(&labelled).Error; err != nil {
+		return Dataset{}, err
+	}
+	for _, sample := range labelled {
+		add(sample.Content, sample.Label, SourceManual)
+	}
+
+	dataset := Dataset{Samples: make([]Sample, 0, len(order)), Sources: map[string]int{}}
+	for _, key := range order {
+		sample := byText[key]
+		dataset.Samples = append(dataset.Samples, sample)
+		dataset.Sources[sample.Source]++
+		if sample.Label == LabelSpam {
+			dataset.Spam++
+		} else {
+			dataset.Ham++
+		}
+	}
+	return dataset, nil
+}
+
+// contentFreeReport 判断举报是否只由不看内容的合成规则促成。
+// 得分明细中没有匹配类型的旧记录按规则 ID 为 0 且名称相同判断，没有明细的按主规则的匹配类型判断。
+func contentFreeReport(report models.ReportRecord) bool {
+	var contributions []rules.Contribution
+	if report.MatchedRules == "" || json.Unmarshal([]byte(report.MatchedRules), &contributions) != nil || len(contributions) == 0 {
+		return isContentFreeType(report.MatchType)
+	}
+	for _, item := range contributions {
+		if item.MatchType != "" {
+			if !isContentFreeType(item.MatchType) {
+				return false
+			}
+			continue
+		}
+		if item.RuleID != 0 || !isContentFreeName(item.RuleName) {
+			return false
+		}
+	}
+	return true
+}
+
+func isContentFreeType(matchType string) bool {
+	if matchType == rules.MatchTypeFlood {
+		return true
+	}
+	_, ok := contentFreeRules[matchType]
+	return ok
+}
+
+func isContentFreeName(name string) bool {
+	for _, ruleName := range contentFreeRules {
+		if ruleName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Activate 把模型设为监控使用的模型，其余模型取消使用。
+func Activate(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ClassifierModel{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.ClassifierModel{}).Where("id = ?", id).Update("active", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("模型不存在")
+		}
+		return nil
+	})
+}
+
+// Cache 缓存正在使用的模型，只有使用的模型变化时才重新从数据库解析，可在多个任务间共享。
+type Cache struct {
+	mu    sync.Mutex
+	id    uint
+	model *Model
+}
+
+// NewCache 创建空缓存。
+func NewCache() *Cache {
+	return &Cache{}
+}
+
+// Active 返回正在使用的模型，没有时返回 nil。
+func (c *Cache) Active(db *gorm.DB) (*Model, error) {
+	var ids []uint
+	if err := db.Model(&models.ClassifierModel{}).Where("active = ?", true).Order("id DESC").Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(ids) == 0 {
+		c.id, c.model = 0, nil
+		return nil, nil
+	}
+	if ids[0] == c.id && c.model != nil {
+		return c.model, nil
+	}
+	var row models.ClassifierModel
+	if err := db.First(&row, ids[0]).Error; err != nil {
+		return nil, err
+	}
+	model, err := Decode(row.Data)
+	if err != nil {
+		return nil, err
+	}
+	c.id, c.model = row.ID, model
+	return model, nil
+}
+
+// TrainOptions 是一次重新训练的参数。
+type TrainOptions struct {
+	NGram     int
+	Holdout   int // 留出集比例（百分比）
+	Activate  bool
+	CreatedBy string
+}
+
+// TrainResult 是重新训练的结果：保存的模型记录、留出集上的指标和各阈值下的指标。
+type TrainResult struct {
+	Model   models.ClassifierModel `json:"model"`
+	Metrics Metrics                `json:"metrics"`
+	Curve   []Metrics              `json:"curve"`
+	Dataset Dataset                `json:"dataset"`
+}
+
+// Retrain 用数据库中的全部样本重新训练：按 Holdout 留出一部分样本评估，其余用于训练，模型和指标保存为新记录。
+func Retrain(db *gorm.DB, opts TrainOptions) (TrainResult, error) {
+	dataset, err := LoadDataset(db)
+	if err != nil {
+		return TrainResult{}, err
+	}
+	trainSet, testSet := Split(dataset.Samples, opts.Holdout)
+	model, err := Train(trainSet, opts.NGram)
+	if err != nil {
+		return TrainResult{}, err
+	}
+	metrics, curve := Evaluate(model, testSet, DefaultThreshold)
+	data, err := Encode(model)
+	if err != nil {
+		return TrainResult{}, err
+	}
+	row := models.ClassifierModel{
+		NGram:        model.NGram,
+		TrainSamples: model.Docs[ham] + model.Docs[spam],
+		TestSamples:  len(testSet),
+		SpamSamples:  model.Docs[spam],
+		HamSamples:   model.Docs[ham],
+		Features:     len(model.Features),
+		Holdout:      opts.Holdout,
+		Precision:    metrics.Precision,
+		Recall:       metrics.Recall,
+		F1:           metrics.F1,
+		Accuracy:     metrics.Accuracy,
+		CreatedBy:    opts.CreatedBy,
+		Data:         data,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		return TrainResult{}, err
+	}
+	if opts.Activate {
+		if err := Activate(db, row.ID); err != nil {
+			return TrainResult{}, err
+		}
+		row.Active = true
+	}
+	return TrainResult{Model: row, Metrics: metrics, Curve: curve, Dataset: dataset}, nil
+}
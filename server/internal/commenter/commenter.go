@@ -0,0 +1,219 @@
+// Package commenter 汇总 goban 对某个评论者（按 UID）掌握的信息：举报记录、近似重复检测保存的评论，
+// 以及白名单和重点关注状态，并按举报次数给出评论者排行。
+package commenter
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/whitelist"
+	"gorm.io/gorm"
+)
+
+// RecentReports 是评论者档案附带的最近举报记录条数，完整记录通过举报记录接口按 comment_user_id 查询。
+const RecentReports = 10
+
+// RuleHit 是评论者被举报的评论命中某条规则的次数；没有规则 ID 的合成规则按名称区分。
+type RuleHit struct {
+	RuleID   uint   `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Count    int64  `json:"count"`
+}
+
+// TargetHit 是评论者在某个 UP 主视频下被举报的次数。
+type TargetHit struct {
+	TargetUID      int64     `json:"target_uid"`
+	TargetUname    string    `json:"target_uname"`
+	Reports        int64     `json:"reports"`
+	Succeeded      int64     `json:"succeeded"`
+	LastReportedAt time.Time `json:"last_reported_at"`
+}
+
+// Profile 是评论者档案。FirstSeen 和 LastSeen 取举报时间和近似重复检测记录的评论发布时间。
+type Profile struct {
+	UID          int64       `json:"uid"`
+	Unames       []string    `json:"unames"` // 出现过的用户名，最近使用的在前
+	Reports      int64       `json:"reports"`
+	Succeeded    int64       `json:"succeeded"`
+	Removed      int64       `json:"removed"` // 复查时评论已被删除
+	Kept         int64       `json:"kept"`    // 复查时评论仍在
+	SuccessRatio float64     `json:"success_ratio"`
+	FirstSeen    *time.Time  `json:"first_seen"`
+	LastSeen     *time.Time  `json:"last_seen"`
+	Rules        []RuleHit   `json:"rules"`
+	Targets      []TargetHit `json:"targets"`
+	// ScannedComments 是近似重复检测保存的该评论者评论数，Clusters 是这些评论所属的评论簇数
+	ScannedComments int64                  `json:"scanned_comments"`
+	Clusters        int64                  `json:"clusters"`
+	Whitelisted     bool                   `json:"whitelisted"` // 有启用且未过期的白名单指向该评论者，不区分适用范围
+	Whitelist       []models.WhitelistUser `json:"whitelist"`
+	Watchlist       *models.WatchlistUser  `json:"watchlist"`
+	RecentReports   []models.ReportRecord  `json:"recent_reports"`
+}
+
+// Load 汇总评论者档案，now 用于判断白名单是否过期。
+func Load(db *gorm.DB, uid int64, now time.Time) (Profile, error) {
+	profile := Profile{
+		UID:           uid,
+		Unames:        []string{},
+		Rules:         []RuleHit{},
+		Targets:       []TargetHit{},
+		Whitelist:     []models.WhitelistUser{},
+		RecentReports: []models.ReportRecord{},
+	}
+	var records []models.ReportRecord
+	if err := db.Where("comment_user_id = ?", uid).Order("created_at DESC").Order("id DESC").Find(&records).Error; err != nil {
+		return profile, err
+	}
+	var scanned []models.ClusterComment
+	if err := db.Select("cluster_id", "comment_user", "seen_at").Where("comment_user_id = ?", uid).Order("seen_at DESC").Find(&scanned).Error; err != nil {
+		return profile, err
+	}
+
+	seenUnames := map[string]bool{}
+	addUname := func(uname string) {
+		if uname != "" && !seenUnames[uname] {
+			seenUnames[uname] = true
+			profile.Unames = append(profile.Unames, uname)
+		}
+	}
+	// 有规则 ID 的按 ID 合并，规则改名后沿用最近一次举报时的名称
+	type ruleKey struct {
+		id   uint
+		name string
+	}
+	ruleHits := map[ruleKey]*RuleHit{}
+	targets := map[int64]*TargetHit{}
+	for _, record := range records {
+		addUname(record.CommentUser)
+		profile.Reports++
+		if record.Success {
+			profile.Succeeded++
+		}
+		switch record.RemovalStatus {
+		case "removed":
+			profile.Removed++
+		case "kept":
+			profile.Kept++
+		}
+		profile.observe(record.CreatedAt)
+		for _, hit := range reportRules(record) {
+			key := ruleKey{id: hit.RuleID}
+			if hit.RuleID == 0 {
+				key.name = hit.RuleName
+			}
+			if existing, ok := ruleHits[key]; ok {
+				existing.Count++
+				continue
+			}
+			hit.Count = 1
+			ruleHits[key] = &hit
+		}
+		target, ok := targets[record.TargetUID]
+		if !ok {
+			// 记录按时间倒序，第一条即最近一次
+			target = &TargetHit{TargetUID: record.TargetUID, TargetUname: record.TargetUname, LastReportedAt: record.CreatedAt}
+			targets[record.TargetUID] = target
+		}
+		target.Reports++
+		if record.Success {
+			target.Succeeded++
+		}
+	}
+	if profile.Reports > 0 {
+		profile.SuccessRatio = float64(profile.Succeeded) / float64(profile.Reports)
+	}
+	if len(records) > RecentReports {
+		records = records[:RecentReports]
+	}
+	profile.RecentReports = records
+
+	clusters := map[uint]bool{}
+	for _, comment := range scanned {
+		addUname(comment.CommentUser)
+		profile.ScannedComments++
+		clusters[comment.ClusterID] = true
+		profile.observe(comment.SeenAt)
+	}
+	profile.Clusters = int64(len(clusters))
+
+	for _, hit := range ruleHits {
+		profile.Rules = append(profile.Rules, *hit)
+	}
+	sort.Slice(profile.Rules, func(i, j int) bool {
+		if profile.Rules[i].Count != profile.Rules[j].Count {
+			return profile.Rules[i].Count > profile.Rules[j].Count
+		}
+		return profile.Rules[i].RuleName < profile.Rules[j].RuleName
+	})
+	for _, hit := range targets {
+		profile.Targets = append(profile.Targets, *hit)
+	}
+	sort.Slice(profile.Targets, func(i, j int) bool {
+		if profile.Targets[i].Reports != profile.Targets[j].Reports {
+			return profile.Targets[i].Reports > profile.Targets[j].Reports
+		}
+		return profile.Targets[i].TargetUID < profile.Targets[j].TargetUID
+	})
+
+	var whitelistRows []models.WhitelistUser
+	if err := db.Order("created_at ASC").Find(&whitelistRows).Error; err != nil {
+		return profile, err
+	}
+	profile.Whitelist = whitelist.NewIndex(whitelistRows).Lookup(uid, profile.Unames...)
+	profile.Whitelisted = anyActive(profile.Whitelist, now)
+
+	var watched models.WatchlistUser
+	err := db.Where("uid = ?", uid).Limit(1).Find(&watched).Error
+	if err != nil {
+		return profile, err
+	}
+	if watched.ID > 0 {
+		profile.Watchlist = &watched
+	}
+	return profile, nil
+}
+
+func (p *Profile) observe(at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	if p.FirstSeen == nil || at.Before(*p.FirstSeen) {
+		first := at
+		p.FirstSeen = &first
+	}
+	if p.LastSeen == nil || at.After(*p.LastSeen) {
+		last := at
+		p.LastSeen = &last
+	}
+}
+
+// reportRules 返回举报记录命中的全部规则：优先读取得分明细，旧记录退回到主规则。
+func reportRules(record models.ReportRecord) []RuleHit {
+	var contributions []rules.Contribution
+	if record.MatchedRules != "" && json.Unmarshal([]byte(record.MatchedRules), &contributions) == nil && len(contributions) > 0 {
+		hits := make([]RuleHit, 0, len(contributions))
+		for _, item := range contributions {
+			hits = append(hits, RuleHit{RuleID: item.RuleID, RuleName: item.RuleName})
+		}
+		return hits
+	}
+	hit := RuleHit{RuleName: record.KeywordRuleName}
+	if record.KeywordRuleID != nil {
+		hit.RuleID = *record.KeywordRuleID
+	}
+	return []RuleHit{hit}
+}
+
+// anyActive 判断条目中是否有启用且在 now 时未过期的。
+func anyActive(rows []models.WhitelistUser, now time.Time) bool {
+	for _, row := range rows {
+		if row.Enabled && !whitelist.Expired(row, now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package commenter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/watchlist"
+	"gorm.io/gorm"
+)
+
+func setupDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	return database.GetDB()
+}
+
+func TestLoadProfileAggregatesReportsAndStatus(t *testing.T) {
+	db := setupDB(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	ruleID := uint(3)
+	reports := []models.ReportRecord{
+		{TaskID: 1, CommentID: 1, CommentUserID: 42, CommentUser: "旧名字", TargetUID: 100, TargetUname: "UP甲", Success: true, RemovalStatus: "removed",
+			KeywordRuleID: &ruleID, KeywordRuleName: "广告", MatchedRules: `[{"rule_id":3,"rule_name":"广告","score":1},{"rule_id":0,"rule_name":"文本分类器","score":1}]`},
+		{TaskID: 1, CommentID: 2, CommentUserID: 42, CommentUser: "新名字", TargetUID: 100, TargetUname: "UP甲", Success: false, KeywordRuleID: &ruleID, KeywordRuleName: "广告"},
+		{TaskID: 2, CommentID: 3, CommentUserID: 42, CommentUser: "新名字", TargetUID: 200, TargetUname: "UP乙", Success: true, RemovalStatus: "kept", KeywordRuleName: "重点关注用户"},
+		{TaskID: 2, CommentID: 4, CommentUserID: 7, CommentUser: "别人", TargetUID: 200, Success: true},
+	}
+	for i := range reports {
+		reports[i].CreatedAt = base.Add(time.Duration(i) * time.Hour)
+	}
+	if err := db.Create(&reports).Error; err != nil {
+		t.Fatalf("create reports: %v", err)
+	}
+	scanned := models.ClusterComment{ClusterID: 9, CommentID: 50, CommentUserID: 42, CommentUser: "更早的名字", SeenAt: base.Add(-24 * time.Hour)}
+	if err := db.Create(&scanned).Error; err != nil {
+		t.Fatalf("create cluster comment: %v", err)
+	}
+	past := base.Add(-time.Hour)
+	whitelistRows := []models.WhitelistUser{
+		{Uname: "*名字", UnameMatch: "glob", Enabled: true, TaskID: 5},
+		{UID: 42, Enabled: true, ExpiresAt: &past},
+		{UID: 8, Enabled: true},
+	}
+	if err := db.Create(&whitelistRows).Error; err != nil {
+		t.Fatalf("create whitelist: %v", err)
+	}
+	if err := db.Create(&models.WatchlistUser{UID: 42, Mode: watchlist.ModeAlwaysReport, Enabled: true}).Error; err != nil {
+		t.Fatalf("create watchlist: %v", err)
+	}
+
+	profile, err := Load(db, 42, base)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if profile.Reports != 3 || profile.Succeeded != 2 || profile.Removed != 1 || profile.Kept != 1 {
+		t.Fatalf("unexpected counts %+v", profile)
+	}
+	if profile.SuccessRatio < 0.66 || profile.SuccessRatio > 0.67 {
+		t.Fatalf("SuccessRatio = %v", profile.SuccessRatio)
+	}
+	if len(profile.Unames) != 3 || profile.Unames[0] != "新名字" || profile.Unames[2] != "更早的名字" {
+		t.Fatalf("Unames = %v", profile.Unames)
+	}
+	if profile.FirstSeen == nil || !profile.FirstSeen.Equal(scanned.SeenAt) || profile.LastSeen == nil || !profile.LastSeen.Equal(reports[2].CreatedAt) {
+		t.Fatalf("FirstSeen/LastSeen = %v/%v", profile.FirstSeen, profile.LastSeen)
+	}
+	if len(profile.Rules) != 3 || profile.Rules[0].RuleID != 3 || profile.Rules[0].Count != 2 {
+		t.Fatalf("Rules = %+v", profile.Rules)
+	}
+	if len(profile.Targets) != 2 || profile.Targets[0].TargetUID != 100 || profile.Targets[0].Reports != 2 || profile.Targets[0].Succeeded != 1 {
+		t.Fatalf("Targets = %+v", profile.Targets)
+	}
+	if profile.ScannedComments != 1 || profile.Clusters != 1 {
+		t.Fatalf("ScannedComments/Clusters = %d/%d", profile.ScannedComments, profile.Clusters)
+	}
+	if len(profile.Whitelist) != 2 || !profile.Whitelisted {
+		t.Fatalf("Whitelist = %+v, whitelisted = %v", profile.Whitelist, profile.Whitelisted)
+	}
+	if profile.Watchlist == nil || profile.Watchlist.Mode != watchlist.ModeAlwaysReport {
+		t.Fatalf("Watchlist = %+v", profile.Watchlist)
+	}
+	if len(profile.RecentReports) != 3 || profile.RecentReports[0].CommentID != 3 {
+		t.Fatalf("RecentReports = %+v", profile.RecentReports)
+	}
+
+	// 排行按 UP 主和时间范围统计
+	top, err := Top(db, Filter{}, 10, base)
+	if err != nil || len(top) != 2 || top[0].UID != 42 || top[0].Reports != 3 || top[0].Targets != 2 || top[0].Uname != "新名字" {
+		t.Fatalf("Top = %+v (%v)", top, err)
+	}
+	if !top[0].Whitelisted || top[0].WatchMode != watchlist.ModeAlwaysReport || top[1].Whitelisted || top[1].WatchMode != "" {
+		t.Fatalf("unexpected status in %+v", top)
+	}
+	from := base.Add(90 * time.Minute)
+	top, err = Top(db, Filter{TargetUID: 200, From: &from}, 10, base)
+	if err != nil || len(top) != 2 || top[0].Reports != 1 || top[1].Reports != 1 {
+		t.Fatalf("filtered Top = %+v (%v)", top, err)
+	}
+	top, err = Top(db, Filter{TaskID: 1}, 1, base)
+	if err != nil || len(top) != 1 || top[0].UID != 42 || top[0].Succeeded != 1 {
+		t.Fatalf("task Top = %+v (%v)", top, err)
+	}
+}
@@ -0,0 +1,113 @@
+package commenter
+
+import (
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/whitelist"
+	"gorm.io/gorm"
+)
+
+// Filter 限定排行统计的举报记录，零值表示不限。
+type Filter struct {
+	TaskID    uint
+	TargetUID int64
+	From      *time.Time
+	To        *time.Time
+}
+
+// Offender 是排行中的一名评论者，计数只包含 Filter 范围内的举报记录。
+type Offender struct {
+	UID            int64     `json:"uid"`
+	Uname          string    `json:"uname"` // 最近一次举报时的用户名
+	Reports        int64     `json:"reports"`
+	Succeeded      int64     `json:"succeeded"`
+	SuccessRatio   float64   `json:"success_ratio"`
+	Targets        int64     `json:"targets"` // 涉及的 UP 主数
+	LastReportedAt time.Time `json:"last_reported_at"`
+	Whitelisted    bool      `json:"whitelisted"`
+	WatchMode      string    `json:"watch_mode"` // 在重点关注列表中时为其处理方式，否则为空
+}
+
+// Top 返回举报次数最多的 limit 名评论者，次数相同时成功举报多的在前。
+func Top(db *gorm.DB, filter Filter, limit int, now time.Time) ([]Offender, error) {
+	query := db.Model(&models.ReportRecord{}).Where("comment_user_id > 0")
+	if filter.TaskID > 0 {
+		query = query.Where("task_id = ?", filter.TaskID)
+	}
+	if filter.TargetUID > 0 {
+		query = query.Where("target_uid = ?", filter.TargetUID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	var rows []struct {
+		UID       int64
+		Reports   int64
+		Succeeded int64
+		Targets   int64
+		LastID    uint
+	}
+	if err := query.
+		Select("comment_user_id AS uid, COUNT(*) AS reports, SUM(CASE WHEN success = ? THEN 1 ELSE 0 END) AS succeeded, COUNT(DISTINCT target_uid) AS targets, MAX(id) AS last_id", true).
+		Group("comment_user_id").
+		Order("reports DESC").Order("succeeded DESC").Order("uid ASC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	offenders := make([]Offender, 0, len(rows))
+	if len(rows) == 0 {
+		return offenders, nil
+	}
+
+	uids := make([]int64, 0, len(rows))
+	lastIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		uids = append(uids, row.UID)
+		lastIDs = append(lastIDs, row.LastID)
+	}
+	var lastRecords []models.ReportRecord
+	if err := db.Select("id", "created_at", "comment_user").Where("id IN ?", lastIDs).Find(&lastRecords).Error; err != nil {
+		return nil, err
+	}
+	lastByID := make(map[uint]models.ReportRecord, len(lastRecords))
+	for _, record := range lastRecords {
+		lastByID[record.ID] = record
+	}
+	var watched []models.WatchlistUser
+	if err := db.Where("uid IN ?", uids).Find(&watched).Error; err != nil {
+		return nil, err
+	}
+	watchModes := make(map[int64]string, len(watched))
+	for _, row := range watched {
+		watchModes[row.UID] = row.Mode
+	}
+	var whitelistRows []models.WhitelistUser
+	if err := db.Where("enabled = ?", true).Find(&whitelistRows).Error; err != nil {
+		return nil, err
+	}
+	index := whitelist.NewIndex(whitelistRows)
+
+	for _, row := range rows {
+		last := lastByID[row.LastID]
+		offender := Offender{
+			UID:            row.UID,
+			Uname:          last.CommentUser,
+			Reports:        row.Reports,
+			Succeeded:      row.Succeeded,
+			Targets:        row.Targets,
+			LastReportedAt: last.CreatedAt,
+			WatchMode:      watchModes[row.UID],
+			Whitelisted:    anyActive(index.Lookup(row.UID, last.CommentUser), now),
+		}
+		if row.Reports > 0 {
+			offender.SuccessRatio = float64(row.Succeeded) / float64(row.Reports)
+		}
+		offenders = append(offenders, offender)
+	}
+	return offenders, nil
+}
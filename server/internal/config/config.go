@@ -4,12 +4,14 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/spiritlhl/goban/internal/logging"
 )
 
 type Config struct {
@@ -24,6 +26,9 @@ type Config struct {
 	DBMaxOpenConns     int
 	DBMaxIdleConns     int
 	DBConnMaxLifetime  time.Duration
+	OTLPEndpoint       string
+	LogLevel           string
+	LogFormat          string
 }
 
 var globalConfig *Config
@@ -45,6 +50,21 @@ func LoadConfig() *Config {
 
 	debug := os.Getenv("DEBUG")
 
+	logLevel := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL")))
+	if logLevel == "" {
+		logLevel = "info"
+		if debug == "true" {
+			logLevel = "debug"
+		}
+	}
+	logFormat := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
+	if logFormat != logging.FormatJSON {
+		logFormat = logging.FormatText
+	}
+	// 尽早安装日志处理器，使下面的安全提示也按配置的级别和格式输出。
+	logging.Setup(logLevel, logFormat)
+	logger := logging.For("config")
+
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
 		dbPath = "data/goban.db"
@@ -54,10 +74,10 @@ func LoadConfig() *Config {
 	if password == "" {
 		generated, path, err := loadOrCreateSecretFile("GOBAN_PASSWORD_FILE", dbPath, ".goban_admin_password", 24)
 		if err != nil {
-			log.Printf("[安全] 未设置 PASSWORD，且无法创建管理员密码文件: %v", err)
+			logger.Warn("未设置 PASSWORD，且无法创建管理员密码文件", slog.Any(logging.KeyError, err))
 			generated = mustRandomSecret(24)
 		} else {
-			log.Printf("[安全] 未设置 PASSWORD，使用管理员密码文件: %s", path)
+			logger.Warn("未设置 PASSWORD，使用管理员密码文件", "path", path)
 		}
 		password = generated
 	}
@@ -66,10 +86,10 @@ func LoadConfig() *Config {
 	if secretKey == "" {
 		generated, path, err := loadOrCreateSecretFile("GOBAN_SECRET_KEY_FILE", dbPath, ".goban_secret_key", 32)
 		if err != nil {
-			log.Printf("[安全] 未设置 GOBAN_SECRET_KEY，且无法创建密钥文件: %v", err)
+			logger.Warn("未设置 GOBAN_SECRET_KEY，且无法创建密钥文件", slog.Any(logging.KeyError, err))
 			generated = mustRandomSecret(32)
 		} else {
-			log.Printf("[安全] 未设置 GOBAN_SECRET_KEY，使用密钥文件: %s", path)
+			logger.Warn("未设置 GOBAN_SECRET_KEY，使用密钥文件", "path", path)
 		}
 		secretKey = generated
 	}
@@ -106,6 +126,9 @@ func LoadConfig() *Config {
 		DBMaxOpenConns:     dbMaxOpenConns,
 		DBMaxIdleConns:     dbMaxIdleConns,
 		DBConnMaxLifetime:  time.Duration(dbConnLifetimeSeconds) * time.Second,
+		OTLPEndpoint:       firstEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"),
+		LogLevel:           logLevel,
+		LogFormat:          logFormat,
 	}
 
 	return globalConfig
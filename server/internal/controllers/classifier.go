@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/classifier"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+const maxClassifierSampleLength = 1000
+
+// classifierTraining 保证同一时间只有一次训练，训练会读取全部举报记录。
+var classifierTraining sync.Mutex
+
+// ListClassifierModels 列出训练过的分类器模型，并返回当前可用于训练的样本数量。
+func ListClassifierModels(c *gin.Context) {
+	db := database.GetDB()
+	var rows []models.ClassifierModel
+	if err := db.Omit("data").Order("id DESC").Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "获取分类器模型失败")
+		return
+	}
+	dataset, err := classifier.LoadDataset(db)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "统计训练样本失败: "+err.Error())
+		return
+	}
+	respondOK(c, gin.H{"models": rows, "dataset": dataset})
+}
+
+// TrainClassifier 用举报历史和审核结果重新训练分类器，并在留出集上评估。
+func TrainClassifier(c *gin.Context) {
+	var req struct {
+		NGram    int   `json:"ngram"`
+		Holdout  int   `json:"holdout"`
+		Activate *bool `json:"activate"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	if req.NGram == 0 {
+		req.NGram = classifier.DefaultNGram
+	}
+	if req.NGram < 1 || req.NGram > classifier.MaxNGram {
+		respondError(c, http.StatusBadRequest, "n-gram 长度需在 1-"+strconv.Itoa(classifier.MaxNGram)+" 之间")
+		return
+	}
+	holdout, ok := classifierHoldout(c, req.Holdout)
+	if !ok {
+		return
+	}
+	if !classifierTraining.TryLock() {
+		respondError(c, http.StatusConflict, "分类器正在训练中")
+		return
+	}
+	defer classifierTraining.Unlock()
+
+	result, err := classifier.Retrain(database.GetDB(), classifier.TrainOptions{
+		NGram:     req.NGram,
+		Holdout:   holdout,
+		Activate:  req.Activate == nil || *req.Activate,
+		CreatedBy: requestAuthor(c),
+	})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "训练失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "训练完成", gin.H{"message": "训练完成", "result": result})
+}
+
+// EvaluateClassifier 在当前数据的留出集上评估指定模型（默认为正在使用的模型）的精确率和召回率。
+func EvaluateClassifier(c *gin.Context) {
+	var req struct {
+		ModelID   uint    `json:"model_id"`
+		Threshold float64 `json:"threshold"`
+		Holdout   int     `json:"holdout"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	if req.Threshold == 0 {
+		req.Threshold = classifier.DefaultThreshold
+	}
+	if req.Threshold <= 0 || req.Threshold >= 1 {
+		respondError(c, http.StatusBadRequest, "阈值需在 0-1 之间")
+		return
+	}
+
+	db := database.GetDB()
+	var row models.ClassifierModel
+	query := db.Model(&models.ClassifierModel{})
+	if req.ModelID > 0 {
+		query = query.Where("id = ?", req.ModelID)
+	} else {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Order("id DESC").First(&row).Error; err != nil {
+		respondError(c, http.StatusNotFound, "分类器模型不存在")
+		return
+	}
+	// 默认使用训练时的留出比例，保证评估样本没有参与训练
+	if req.Holdout == 0 {
+		req.Holdout = row.Holdout
+	}
+	holdout, ok := classifierHoldout(c, req.Holdout)
+	if !ok {
+		return
+	}
+	model, err := classifier.Decode(row.Data)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	dataset, err := classifier.LoadDataset(db)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "读取评估样本失败: "+err.Error())
+		return
+	}
+	_, testSet := classifier.Split(dataset.Samples, holdout)
+	metrics, curve := classifier.Evaluate(model, testSet, req.Threshold)
+	respondOK(c, gin.H{"model_id": row.ID, "holdout": holdout, "metrics": metrics, "curve": curve})
+}
+
+// ActivateClassifierModel 把模型设为监控使用的模型。
+func ActivateClassifierModel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "分类器模型不存在")
+		return
+	}
+	if err := classifier.Activate(database.GetDB(), uint(id)); err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	respondCreated(c, "已启用", gin.H{"message": "已启用", "model_id": id})
+}
+
+// DeleteClassifierModel 删除未在使用的模型。
+func DeleteClassifierModel(c *gin.Context) {
+	db := database.GetDB()
+	var row models.ClassifierModel
+	if err := db.Omit("data").First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "分类器模型不存在")
+		return
+	}
+	if row.Active {
+		respondError(c, http.StatusBadRequest, "不能删除正在使用的模型")
+		return
+	}
+	if !requireDeleteConfirmation(c, strconv.FormatUint(uint64(row.ID), 10)) {
+		return
+	}
+	if err := db.Delete(&row).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "删除模型失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "删除成功", gin.H{"message": "删除成功", "deleted_id": row.ID})
+}
+
+// ListClassifierSamples 分页列出人工标注的样本。
+func ListClassifierSamples(c *gin.Context) {
+	page, pageSize := pagination(c)
+	query := database.GetDB().Model(&models.ClassifierSample{})
+	if label := c.Query("label"); label != "" {
+		query = query.Where("label = ?", label)
+	}
+	var total int64
+	query.Count(&total)
+	var rows []models.ClassifierSample
+	if err := query.Order("id DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "获取标注样本失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "page": page, "page_size": pageSize, "data": rows})
+}
+
+// CreateClassifierSample 标注一条样本，可直接填写文本，也可引用举报记录（如把误报标为正常评论）。
+// 同一条举报记录重复标注时更新原有标签。
+func CreateClassifierSample(c *gin.Context) {
+	var req struct {
+		Content  string `json:"content"`
+		Label    string `json:"label"`
+		ReportID uint   `json:"report_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	if req.Label != classifier.LabelSpam && req.Label != classifier.LabelHam {
+		respondError(c, http.StatusBadRequest, "标签必须为 spam 或 ham")
+		return
+	}
+	db := database.GetDB()
+	row := models.ClassifierSample{Content: strings.TrimSpace(req.Content), Label: req.Label, CreatedBy: requestAuthor(c)}
+	if req.ReportID > 0 {
+		var report models.ReportRecord
+		if err := db.Select("id", "comment_content").First(&report, req.ReportID).Error; err != nil {
+			respondError(c, http.StatusNotFound, "举报记录不存在")
+			return
+		}
+		row.Content = report.CommentContent
+		row.ReportID = &report.ID
+		var existing models.ClassifierSample
+		if err := db.Where("report_id = ?", report.ID).Limit(1).Find(&existing).Error; err == nil && existing.ID > 0 {
+			row.ID = existing.ID
+			row.CreatedAt = existing.CreatedAt
+		}
+	}
+	if row.Content == "" {
+		respondError(c, http.StatusBadRequest, "样本内容不能为空")
+		return
+	}
+	if runeLen(row.Content) > maxClassifierSampleLength {
+		respondError(c, http.StatusBadRequest, "样本内容不能超过 "+strconv.Itoa(maxClassifierSampleLength)+" 个字符")
+		return
+	}
+	if err := db.Save(&row).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "保存标注失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "标注成功", gin.H{"message": "标注成功", "sample": row})
+}
+
+// DeleteClassifierSample 删除一条标注样本。
+func DeleteClassifierSample(c *gin.Context) {
+	db := database.GetDB()
+	var row models.ClassifierSample
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "标注样本不存在")
+		return
+	}
+	if !requireDeleteConfirmation(c, strconv.FormatUint(uint64(row.ID), 10)) {
+		return
+	}
+	if err := db.Delete(&row).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "删除标注失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "删除成功", gin.H{"message": "删除成功", "deleted_id": row.ID})
+}
+
+func classifierHoldout(c *gin.Context, holdout int) (int, bool) {
+	if holdout == 0 {
+		holdout = classifier.DefaultHoldout
+	}
+	if holdout < classifier.MinHoldout || holdout > classifier.MaxHoldout {
+		respondError(c, http.StatusBadRequest, "留出集比例需在 "+strconv.Itoa(classifier.MinHoldout)+"-"+strconv.Itoa(classifier.MaxHoldout)+"% 之间")
+		return 0, false
+	}
+	return holdout, true
+}
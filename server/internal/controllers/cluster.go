@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/copypasta"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"gorm.io/gorm"
+)
+
+type clusterItem struct {
+	models.CommentCluster
+	Users    int64 `json:"users"`    // 不同评论者数
+	Videos   int64 `json:"videos"`   // 涉及的视频数
+	Reported int64 `json:"reported"` // 已举报成功的成员数
+}
+
+type clusterSummary struct {
+	ClusterID uint
+	Users     int64
+	Videos    int64
+	Reported  int64
+}
+
+// ListCommentClusters 分页列出近似重复评论簇，默认按最近出现时间排序。
+func ListCommentClusters(c *gin.Context) {
+	page, pageSize := pagination(c)
+	query := database.GetDB().Model(&models.CommentCluster{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if minSize, err := strconv.Atoi(c.Query("min_size")); err == nil && minSize > 0 {
+		query = query.Where("size >= ?", minSize)
+	}
+
+	var total int64
+	query.Count(&total)
+	var rows []models.CommentCluster
+	if err := query.Order("last_seen_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "获取评论簇失败")
+		return
+	}
+
+	summaries := map[uint]clusterSummary{}
+	if len(rows) > 0 {
+		ids := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		var items []clusterSummary
+		database.GetDB().Model(&models.ClusterComment{}).
+			Select("cluster_id, COUNT(DISTINCT comment_user_id) AS users, COUNT(DISTINCT av_id) AS videos, SUM(CASE WHEN report_status = ? THEN 1 ELSE 0 END) AS reported", copypasta.ReportSuccess).
+			Where("cluster_id IN ?", ids).Group("cluster_id").Scan(&items)
+		for _, item := range items {
+			summaries[item.ClusterID] = item
+		}
+	}
+	data := make([]clusterItem, 0, len(rows))
+	for _, row := range rows {
+		summary := summaries[row.ID]
+		data = append(data, clusterItem{CommentCluster: row, Users: summary.Users, Videos: summary.Videos, Reported: summary.Reported})
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "page": page, "page_size": pageSize, "data": data})
+}
+
+// GetCommentCluster 返回评论簇及全部成员，供审核。
+func GetCommentCluster(c *gin.Context) {
+	var row models.CommentCluster
+	if err := database.GetDB().Preload("Comments", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("seen_at ASC")
+	}).First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "评论簇不存在")
+		return
+	}
+	respondOK(c, row)
+}
+
+// ReportCommentCluster 提交整簇举报，监控服务在后台逐条举报尚未举报的成员。
+func ReportCommentCluster(c *gin.Context) {
+	db := database.GetDB()
+	var row models.CommentCluster
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "评论簇不存在")
+		return
+	}
+	if row.Status == copypasta.StatusQueued {
+		respondError(c, http.StatusBadRequest, "评论簇已在举报队列中")
+		return
+	}
+	var pending int64
+	db.Model(&models.ClusterComment{}).Where("cluster_id = ? AND report_status = ?", row.ID, "").Count(&pending)
+	if pending == 0 {
+		respondError(c, http.StatusBadRequest, "评论簇没有待举报的评论")
+		return
+	}
+	if err := db.Model(&row).Update("status", copypasta.StatusQueued).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "提交举报失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "已加入举报队列", gin.H{"message": "已加入举报队列", "cluster": row, "pending": pending})
+}
+
+// DismissCommentCluster 驳回评论簇，之后簇内评论不再作为近似重复命中，排队中的整簇举报也会取消。
+func DismissCommentCluster(c *gin.Context) {
+	db := database.GetDB()
+	var row models.CommentCluster
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "评论簇不存在")
+		return
+	}
+	if row.Status == copypasta.StatusDismissed {
+		respondError(c, http.StatusBadRequest, "评论簇已驳回")
+		return
+	}
+	if err := db.Model(&row).Update("status", copypasta.StatusDismissed).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "驳回失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "已驳回", gin.H{"message": "已驳回", "cluster": row})
+}
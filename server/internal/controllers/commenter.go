@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/commenter"
+	"github.com/spiritlhl/goban/internal/database"
+)
+
+const (
+	defaultTopCommenters = 20
+	maxTopCommenters     = 100
+)
+
+// GetCommenterProfile 返回评论者档案：被举报的评论、命中的规则、涉及的 UP 主、首次和最近出现时间、
+// 举报成功率，以及白名单和重点关注状态。
+func GetCommenterProfile(c *gin.Context) {
+	uid, err := strconv.ParseInt(c.Param("uid"), 10, 64)
+	if err != nil || uid <= 0 {
+		respondError(c, http.StatusBadRequest, "UID 无效")
+		return
+	}
+	profile, err := commenter.Load(database.GetDB(), uid, time.Now())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取评论者档案失败: "+err.Error())
+		return
+	}
+	if profile.Reports == 0 && profile.ScannedComments == 0 && profile.Watchlist == nil && len(profile.Whitelist) == 0 {
+		respondError(c, http.StatusNotFound, "没有该评论者的记录")
+		return
+	}
+	respondOK(c, profile)
+}
+
+// ListTopCommenters 返回举报次数最多的评论者，可按任务、UP 主和举报时间范围统计。
+func ListTopCommenters(c *gin.Context) {
+	limit, err := boundedIntQuery(c, "limit", defaultTopCommenters, 1, maxTopCommenters)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := commenter.Filter{
+		From: parseTimeQuery(c.Query("start_time")),
+		To:   parseTimeQuery(c.Query("end_time")),
+	}
+	if raw := c.Query("task_id"); raw != "" {
+		taskID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || taskID == 0 {
+			respondError(c, http.StatusBadRequest, "task_id 无效")
+			return
+		}
+		filter.TaskID = uint(taskID)
+	}
+	if raw := c.Query("target_uid"); raw != "" {
+		targetUID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || targetUID <= 0 {
+			respondError(c, http.StatusBadRequest, "target_uid 无效")
+			return
+		}
+		filter.TargetUID = targetUID
+	}
+	offenders, err := commenter.Top(database.GetDB(), filter, limit, time.Now())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取评论者排行失败: "+err.Error())
+		return
+	}
+	respondOK(c, offenders)
+}
@@ -5,21 +5,38 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spiritlhl/goban/internal/database"
 	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
 	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/ruleset"
+	"github.com/spiritlhl/goban/internal/rulestats"
+	"gorm.io/gorm"
 )
 
 type keywordRuleRequest struct {
-	Name          string `json:"name"`
-	Pattern       string `json:"pattern" binding:"required"`
-	MatchType     string `json:"match_type"`
-	MatchLogic    string `json:"match_logic"`
-	CaseSensitive bool   `json:"case_sensitive"`
-	Enabled       *bool  `json:"enabled"`
-	Description   string `json:"description"`
+	Name          string     `json:"name"`
+	Pattern       string     `json:"pattern" binding:"required"`
+	MatchType     string     `json:"match_type"`
+	MatchLogic    string     `json:"match_logic"`
+	CaseSensitive bool       `json:"case_sensitive"`
+	Homophone     bool       `json:"homophone"`
+	Normalize     string     `json:"normalize"`
+	MaxGap        int        `json:"max_gap"`
+	GapNoise      string     `json:"gap_noise"`
+	Weight        float64    `json:"weight"`
+	Conditions    string     `json:"conditions"`
+	MustMatch     string     `json:"must_match"`
+	MustNotMatch  string     `json:"must_not_match"`
+	Enabled       *bool      `json:"enabled"`
+	Description   string     `json:"description"`
+	ActiveFrom    *time.Time `json:"active_from"`
+	ActiveUntil   *time.Time `json:"active_until"`
+	ActiveDays    string     `json:"active_days"`
+	ActiveHours   string     `json:"active_hours"`
 }
 
 const (
@@ -44,16 +61,59 @@ func CreateKeywordRule(c *gin.Context) {
 		respondError(c, http.StatusBadRequest, "请求参数错误")
 		return
 	}
+	row, err := buildKeywordRule(req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		_, err := rulerev.Record(tx, &row, rulerev.ActionCreate, requestAuthor(c), 0)
+		return err
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "创建关键字规则失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "创建成功", gin.H{"message": "创建成功", "rule": row})
+}
+
+// buildKeywordRule 规范化并校验规则请求，创建和批量导入共用。
+func buildKeywordRule(req keywordRuleRequest) (models.KeywordRule, error) {
 	req.Pattern = strings.TrimSpace(req.Pattern)
 	req.MatchType = normalizedRuleType(req.MatchType)
 	req.MatchLogic = normalizedRuleLogic(req.MatchLogic)
+	if singleLogicType(req.MatchType) {
+		req.MatchLogic = rules.MatchLogicSingle
+	}
 	if err := validateKeywordRuleInput(req); err != nil {
-		respondError(c, http.StatusBadRequest, err.Error())
-		return
+		return models.KeywordRule{}, err
 	}
 	if err := rules.Validate(req.Pattern, req.MatchType, req.CaseSensitive, req.MatchLogic); err != nil {
-		respondError(c, http.StatusBadRequest, err.Error())
-		return
+		return models.KeywordRule{}, err
+	}
+	steps, err := rules.ParseNormalizeSteps(req.Normalize)
+	if err != nil {
+		return models.KeywordRule{}, err
+	}
+	if err := rules.ValidateFuzzyGap(req.MaxGap, req.GapNoise); err != nil {
+		return models.KeywordRule{}, err
+	}
+	if err := rules.ValidateWeight(req.Weight); err != nil {
+		return models.KeywordRule{}, err
+	}
+	conditions, err := rules.ParseConditions(req.Conditions)
+	if err != nil {
+		return models.KeywordRule{}, err
+	}
+	mustMatch, err := rules.ParseExamples(req.MustMatch)
+	if err != nil {
+		return models.KeywordRule{}, err
+	}
+	mustNotMatch, err := rules.ParseExamples(req.MustNotMatch)
+	if err != nil {
+		return models.KeywordRule{}, err
 	}
 
 	enabled := true
@@ -66,17 +126,27 @@ func CreateKeywordRule(c *gin.Context) {
 		MatchType:     req.MatchType,
 		MatchLogic:    req.MatchLogic,
 		CaseSensitive: req.CaseSensitive,
+		Homophone:     req.Homophone,
+		Normalize:     rules.FormatNormalizeSteps(steps),
+		MaxGap:        req.MaxGap,
+		GapNoise:      strings.TrimSpace(req.GapNoise),
+		Weight:        ruleWeight(req.Weight),
+		Conditions:    rules.FormatConditions(conditions),
+		MustMatch:     rules.FormatExamples(mustMatch),
+		MustNotMatch:  rules.FormatExamples(mustNotMatch),
 		Enabled:       enabled,
 		Description:   strings.TrimSpace(req.Description),
 	}
 	if row.Name == "" {
 		row.Name = row.Pattern
 	}
-	if err := database.GetDB().Create(&row).Error; err != nil {
-		respondError(c, http.StatusInternalServerError, "创建关键字规则失败: "+err.Error())
-		return
+	if err := applyRuleSchedule(&row, req); err != nil {
+		return models.KeywordRule{}, err
 	}
-	respondCreated(c, "创建成功", gin.H{"message": "创建成功", "rule": row})
+	if err := checkRuleExamples(row); err != nil {
+		return models.KeywordRule{}, err
+	}
+	return row, nil
 }
 
 func UpdateKeywordRule(c *gin.Context) {
@@ -92,6 +162,7 @@ func UpdateKeywordRule(c *gin.Context) {
 		respondError(c, http.StatusNotFound, "关键字规则不存在")
 		return
 	}
+	before := row
 
 	if strings.TrimSpace(req.Pattern) != "" {
 		row.Pattern = strings.TrimSpace(req.Pattern)
@@ -102,7 +173,46 @@ func UpdateKeywordRule(c *gin.Context) {
 	if strings.TrimSpace(req.MatchLogic) != "" {
 		row.MatchLogic = normalizedRuleLogic(req.MatchLogic)
 	}
+	if singleLogicType(row.MatchType) {
+		row.MatchLogic = rules.MatchLogicSingle
+	}
 	row.CaseSensitive = req.CaseSensitive
+	row.Homophone = req.Homophone
+	steps, err := rules.ParseNormalizeSteps(req.Normalize)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row.Normalize = rules.FormatNormalizeSteps(steps)
+	if err := rules.ValidateFuzzyGap(req.MaxGap, req.GapNoise); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row.MaxGap = req.MaxGap
+	row.GapNoise = strings.TrimSpace(req.GapNoise)
+	if err := rules.ValidateWeight(req.Weight); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row.Weight = ruleWeight(req.Weight)
+	conditions, err := rules.ParseConditions(req.Conditions)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row.Conditions = rules.FormatConditions(conditions)
+	mustMatch, err := rules.ParseExamples(req.MustMatch)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	mustNotMatch, err := rules.ParseExamples(req.MustNotMatch)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row.MustMatch = rules.FormatExamples(mustMatch)
+	row.MustNotMatch = rules.FormatExamples(mustNotMatch)
 	if err := validateKeywordRuleInput(keywordRuleRequest{
 		Name:        firstNonEmpty(req.Name, row.Name),
 		Pattern:     row.Pattern,
@@ -127,8 +237,26 @@ func UpdateKeywordRule(c *gin.Context) {
 		row.Enabled = *req.Enabled
 	}
 	row.Description = strings.TrimSpace(req.Description)
+	if err := applyRuleSchedule(&row, req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkRuleExamples(row); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if err := db.Save(&row).Error; err != nil {
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&row).Error; err != nil {
+			return err
+		}
+		// 内容没有变化时不产生新修订
+		if len(rulerev.Changes(before, row)) == 0 {
+			return nil
+		}
+		_, err := rulerev.Record(tx, &row, rulerev.ActionUpdate, requestAuthor(c), 0)
+		return err
+	}); err != nil {
 		respondError(c, http.StatusInternalServerError, "更新关键字规则失败: "+err.Error())
 		return
 	}
@@ -145,7 +273,15 @@ func DeleteKeywordRule(c *gin.Context) {
 	if !requireDeleteConfirmation(c, row.Name, strconv.FormatUint(uint64(row.ID), 10)) {
 		return
 	}
-	if err := db.Delete(&row).Error; err != nil {
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := ruleset.DetachRule(tx, row.ID); err != nil {
+			return err
+		}
+		if err := rulestats.DeleteRule(tx, row.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&row).Error
+	}); err != nil {
 		respondError(c, http.StatusInternalServerError, "删除关键字规则失败: "+err.Error())
 		return
 	}
@@ -159,13 +295,21 @@ func DeleteKeywordRule(c *gin.Context) {
 
 func PreviewKeywordRules(c *gin.Context) {
 	var req struct {
-		Text          string `json:"text"`
-		Name          string `json:"name"`
-		Pattern       string `json:"pattern"`
-		MatchType     string `json:"match_type"`
-		MatchLogic    string `json:"match_logic"`
-		CaseSensitive bool   `json:"case_sensitive"`
-		UseEnabled    bool   `json:"use_enabled"`
+		Text          string           `json:"text"`
+		Name          string           `json:"name"`
+		Pattern       string           `json:"pattern"`
+		MatchType     string           `json:"match_type"`
+		MatchLogic    string           `json:"match_logic"`
+		CaseSensitive bool             `json:"case_sensitive"`
+		Homophone     bool             `json:"homophone"`
+		Normalize     string           `json:"normalize"`
+		MaxGap        int              `json:"max_gap"`
+		GapNoise      string           `json:"gap_noise"`
+		Weight        float64          `json:"weight"`
+		Conditions    string           `json:"conditions"`
+		Threshold     float64          `json:"threshold"`
+		Commenter     *rules.Commenter `json:"commenter"`
+		UseEnabled    bool             `json:"use_enabled"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		respondError(c, http.StatusBadRequest, "请求参数错误")
@@ -175,6 +319,10 @@ func PreviewKeywordRules(c *gin.Context) {
 		respondError(c, http.StatusBadRequest, "预览文本过长")
 		return
 	}
+	if err := rules.ValidateScoreThreshold(req.Threshold); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	var compiled []rules.CompiledRule
 	var compileErrors []error
@@ -189,8 +337,18 @@ func PreviewKeywordRules(c *gin.Context) {
 			MatchType:     normalizedRuleType(req.MatchType),
 			MatchLogic:    normalizedRuleLogic(req.MatchLogic),
 			CaseSensitive: req.CaseSensitive,
+			Homophone:     req.Homophone,
+			Normalize:     req.Normalize,
+			MaxGap:        req.MaxGap,
+			GapNoise:      req.GapNoise,
+			Weight:        req.Weight,
+			Conditions:    req.Conditions,
 			Enabled:       true,
 		}
+		if err := rules.ValidateWeight(req.Weight); err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		one, err := rules.Compile(rule)
 		if err != nil {
 			respondError(c, http.StatusBadRequest, err.Error())
@@ -209,17 +367,141 @@ func PreviewKeywordRules(c *gin.Context) {
 		compileErrors = append(compileErrors, errs...)
 	}
 
+	// 未提供评论者资料时不检查附加条件，只预览文本匹配。
+	matches := rules.MatchAllFor(req.Text, req.Commenter, compiled)
+	score := rules.TotalScore(matches)
 	respondOK(c, gin.H{
-		"matches":        rules.MatchAll(req.Text, compiled),
-		"compile_errors": stringifyErrors(compileErrors),
+		"matches":           matches,
+		"score":             score,
+		"threshold":         req.Threshold,
+		"reaches_threshold": len(matches) > 0 && rules.ReachesThreshold(score, req.Threshold),
+		"compile_errors":    stringifyErrors(compileErrors),
+		"entities":          rules.Extract(req.Text),
 	})
 }
 
+// RunKeywordRuleTests 运行所有规则（包括停用的）保存的测试用例，返回通过/失败报告。
+func RunKeywordRuleTests(c *gin.Context) {
+	var rows []models.KeywordRule
+	if err := database.GetDB().Order("created_at ASC").Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "获取关键字规则失败")
+		return
+	}
+	type ruleTestResult struct {
+		RuleID   uint                   `json:"rule_id"`
+		RuleName string                 `json:"rule_name"`
+		Enabled  bool                   `json:"enabled"`
+		Passed   bool                   `json:"passed"`
+		Cases    int                    `json:"cases"`
+		Failures []rules.ExampleFailure `json:"failures"`
+		Error    string                 `json:"error,omitempty"`
+	}
+	results := make([]ruleTestResult, 0)
+	passed, failed, untested := 0, 0, 0
+	for _, row := range rows {
+		if strings.TrimSpace(row.MustMatch) == "" && strings.TrimSpace(row.MustNotMatch) == "" {
+			untested++
+			continue
+		}
+		result := ruleTestResult{RuleID: row.ID, RuleName: row.Name, Enabled: row.Enabled}
+		report, err := rules.TestRule(row)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Cases = report.Cases
+			result.Failures = report.Failures
+			result.Passed = report.Passed()
+		}
+		if result.Passed {
+			passed++
+		} else {
+			failed++
+		}
+		results = append(results, result)
+	}
+	respondOK(c, gin.H{
+		"total":    len(rows),
+		"passed":   passed,
+		"failed":   failed,
+		"untested": untested,
+		"results":  results,
+	})
+}
+
+// applyRuleSchedule 校验并写入规则的生效时间，创建、更新和导入共用。
+func applyRuleSchedule(row *models.KeywordRule, req keywordRuleRequest) error {
+	days, err := rules.ParseActiveDays(req.ActiveDays)
+	if err != nil {
+		return err
+	}
+	windows, err := rules.ParseActiveHours(req.ActiveHours)
+	if err != nil {
+		return err
+	}
+	row.ActiveFrom = req.ActiveFrom
+	row.ActiveUntil = req.ActiveUntil
+	row.ActiveDays = rules.FormatActiveDays(days)
+	row.ActiveHours = rules.FormatActiveHours(windows)
+	return checkRuleSchedule(*row)
+}
+
+// checkRuleSchedule 校验生效时间，并拒绝启用已过失效时间的规则。
+func checkRuleSchedule(row models.KeywordRule) error {
+	schedule, err := rules.ParseSchedule(row)
+	if err != nil {
+		return err
+	}
+	if row.Enabled && schedule.Expired(time.Now()) {
+		return fmt.Errorf("失效时间已过，请修改失效时间或停用规则")
+	}
+	return nil
+}
+
+// checkRuleExamples 保存前运行规则的测试用例，任一用例失败都拒绝保存。
+func checkRuleExamples(row models.KeywordRule) error {
+	if row.MustMatch == "" && row.MustNotMatch == "" {
+		return nil
+	}
+	report, err := rules.TestRule(row)
+	if err != nil {
+		return err
+	}
+	return report.Error()
+}
+
+// ruleWeight 把未设置的权重存为默认值 1。
+func ruleWeight(weight float64) float64 {
+	if weight <= 0 {
+		return rules.DefaultWeight
+	}
+	return weight
+}
+
 func normalizedRuleType(matchType string) string {
-	if strings.EqualFold(matchType, rules.MatchTypeRegex) {
+	switch {
+	case strings.EqualFold(matchType, rules.MatchTypeRegex):
 		return rules.MatchTypeRegex
+	case strings.EqualFold(matchType, rules.MatchTypePinyin):
+		return rules.MatchTypePinyin
+	case strings.EqualFold(matchType, rules.MatchTypeExpression):
+		return rules.MatchTypeExpression
+	case strings.EqualFold(matchType, rules.MatchTypeFlood):
+		return rules.MatchTypeFlood
+	case strings.EqualFold(matchType, rules.MatchTypeBlocklist):
+		return rules.MatchTypeBlocklist
+	default:
+		return rules.MatchTypePlain
+	}
+}
+
+// singleLogicType 表示该类型不按逗号拆分条件，组合逻辑固定为单条。
+func singleLogicType(matchType string) bool {
+	switch matchType {
+	case rules.MatchTypeExpression, rules.MatchTypeFlood, rules.MatchTypeBlocklist:
+		return true
+	default:
+		return false
 	}
-	return rules.MatchTypePlain
 }
 
 func validateKeywordRuleInput(req keywordRuleRequest) error {
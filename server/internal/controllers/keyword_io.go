@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/ruleio"
+	"github.com/spiritlhl/goban/internal/rulerev"
+	"github.com/spiritlhl/goban/internal/ruleset"
+	"gorm.io/gorm"
+)
+
+type keywordImportRequest struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+	Policy  string `json:"policy"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+const (
+	maxKeywordImportBytes = 2 << 20
+	maxKeywordImportRules = 5000
+)
+
+var keywordExportFiles = map[string]struct{ name, contentType string }{
+	ruleio.FormatJSON:     {"goban-keyword-rules.json", "application/json; charset=utf-8"},
+	ruleio.FormatYAML:     {"goban-keyword-rules.yaml", "application/yaml; charset=utf-8"},
+	ruleio.FormatWordlist: {"goban-keyword-rules.txt", "text/plain; charset=utf-8"},
+}
+
+// ImportKeywordRules 批量导入规则；dry_run 时只返回与现有规则的比对结果，不写库。
+func ImportKeywordRules(c *gin.Context) {
+	var req keywordImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	format, err := ruleio.ParseFormat(req.Format)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	policy, err := ruleio.ParsePolicy(req.Policy)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Content) > maxKeywordImportBytes {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("导入内容不能超过 %d MB", maxKeywordImportBytes>>20))
+		return
+	}
+	items, err := ruleio.Decode([]byte(req.Content), format)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		respondError(c, http.StatusBadRequest, "导入内容中没有规则")
+		return
+	}
+	if len(items) > maxKeywordImportRules {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("单次最多导入 %d 条规则", maxKeywordImportRules))
+		return
+	}
+
+	candidates := make([]ruleio.Candidate, 0, len(items))
+	for _, item := range items {
+		row, err := buildKeywordRule(keywordRuleRequest{
+			Name:          item.Name,
+			Pattern:       item.Pattern,
+			MatchType:     item.MatchType,
+			MatchLogic:    item.MatchLogic,
+			CaseSensitive: item.CaseSensitive,
+			Homophone:     item.Homophone,
+			Normalize:     item.Normalize,
+			MaxGap:        item.MaxGap,
+			GapNoise:      item.GapNoise,
+			Weight:        item.Weight,
+			Conditions:    item.Conditions,
+			MustMatch:     item.MustMatch,
+			MustNotMatch:  item.MustNotMatch,
+			Enabled:       item.Enabled,
+			Description:   item.Description,
+			ActiveFrom:    item.ActiveFrom,
+			ActiveUntil:   item.ActiveUntil,
+			ActiveDays:    item.ActiveDays,
+			ActiveHours:   item.ActiveHours,
+		})
+		if err != nil {
+			row.Name, row.Pattern = item.Name, item.Pattern
+		}
+		candidates = append(candidates, ruleio.Candidate{Rule: row, Err: err})
+	}
+
+	db := database.GetDB()
+	var existing []models.KeywordRule
+	if err := db.Find(&existing).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "读取现有规则失败: "+err.Error())
+		return
+	}
+	plan, summary := ruleio.BuildPlan(candidates, existing, policy)
+	result := gin.H{
+		"dry_run": req.DryRun,
+		"format":  format,
+		"policy":  policy,
+		"summary": summary,
+		"items":   plan,
+	}
+	if req.DryRun {
+		respondOK(c, result)
+		return
+	}
+
+	created, updated, skipped := 0, 0, 0
+	author := requestAuthor(c)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range plan {
+			switch plan[i].Action {
+			case ruleio.ActionCreate:
+				if err := tx.Create(&plan[i].Rule).Error; err != nil {
+					return fmt.Errorf("%s: %w", plan[i].Rule.Name, err)
+				}
+				created++
+			case ruleio.ActionUpdate:
+				if err := tx.Save(&plan[i].Rule).Error; err != nil {
+					return fmt.Errorf("%s: %w", plan[i].Rule.Name, err)
+				}
+				updated++
+			default:
+				skipped++
+				continue
+			}
+			if _, err := rulerev.Record(tx, &plan[i].Rule, rulerev.ActionImport, author, 0); err != nil {
+				return fmt.Errorf("%s: %w", plan[i].Rule.Name, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "导入失败: "+err.Error())
+		return
+	}
+	result["created"] = created
+	result["updated"] = updated
+	result["skipped"] = skipped
+	respondCreated(c, "导入成功", gin.H{"message": "导入成功", "result": result})
+}
+
+// ExportKeywordRules 导出规则，可用 rule_set_id 只导出某个规则集。
+func ExportKeywordRules(c *gin.Context) {
+	format, err := ruleio.ParseFormat(c.Query("format"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	db := database.GetDB()
+	query := db.Order("created_at ASC")
+	if raw := c.Query("rule_set_id"); raw != "" {
+		setID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || setID == 0 {
+			respondError(c, http.StatusBadRequest, "rule_set_id 无效")
+			return
+		}
+		var set models.RuleSet
+		if err := db.First(&set, setID).Error; err != nil {
+			respondError(c, http.StatusNotFound, "规则集不存在")
+			return
+		}
+		query = ruleset.MembersOf(query, uint(setID))
+	}
+	var rows []models.KeywordRule
+	if err := query.Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "导出失败")
+		return
+	}
+	data, skipped, err := ruleio.Encode(rows, format)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "导出失败: "+err.Error())
+		return
+	}
+	file := keywordExportFiles[format]
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.name))
+	c.Header("X-Skipped-Rules", strconv.Itoa(skipped))
+	c.Data(http.StatusOK, file.contentType, data)
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+	"github.com/spiritlhl/goban/internal/rules"
+	"gorm.io/gorm"
+)
+
+type ruleRollbackRequest struct {
+	Revision       int  `json:"revision" binding:"required"`
+	RestoreEnabled bool `json:"restore_enabled"` // 同时恢复修订中的启用状态，默认保留当前状态
+}
+
+// ListKeywordRuleRevisions 返回规则的修订历史。规则删除后修订仍然保留，
+// 举报记录中的修订号依然可以查到当时的内容。
+func ListKeywordRuleRevisions(c *gin.Context) {
+	ruleID, ok := ruleIDParam(c)
+	if !ok {
+		return
+	}
+	db := database.GetDB()
+	revs, err := rulerev.List(db, ruleID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取规则修订失败")
+		return
+	}
+	var row models.KeywordRule
+	deleted := errors.Is(db.First(&row, ruleID).Error, gorm.ErrRecordNotFound)
+	if deleted && len(revs) == 0 {
+		respondError(c, http.StatusNotFound, "关键字规则不存在")
+		return
+	}
+	respondOK(c, gin.H{
+		"rule_id":   ruleID,
+		"current":   row.Revision,
+		"deleted":   deleted,
+		"revisions": revs,
+	})
+}
+
+// GetKeywordRuleRevision 返回规则的某个修订。
+func GetKeywordRuleRevision(c *gin.Context) {
+	ruleID, ok := ruleIDParam(c)
+	if !ok {
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		respondError(c, http.StatusBadRequest, "修订号无效")
+		return
+	}
+	rev, ok := loadRuleRevision(c, ruleID, revision)
+	if !ok {
+		return
+	}
+	respondOK(c, rev)
+}
+
+// DiffKeywordRuleRevisions 比较规则的两个修订，to 省略时与当前内容比较。
+func DiffKeywordRuleRevisions(c *gin.Context) {
+	ruleID, ok := ruleIDParam(c)
+	if !ok {
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		respondError(c, http.StatusBadRequest, "from 修订号无效")
+		return
+	}
+	fromRev, ok := loadRuleRevision(c, ruleID, from)
+	if !ok {
+		return
+	}
+
+	var to models.KeywordRule
+	if raw := c.Query("to"); raw != "" {
+		revision, err := strconv.Atoi(raw)
+		if err != nil || revision <= 0 {
+			respondError(c, http.StatusBadRequest, "to 修订号无效")
+			return
+		}
+		toRev, ok := loadRuleRevision(c, ruleID, revision)
+		if !ok {
+			return
+		}
+		to = rulerev.Content(toRev)
+	} else if err := database.GetDB().First(&to, ruleID).Error; err != nil {
+		respondError(c, http.StatusNotFound, "关键字规则不存在，请指定 to 修订号")
+		return
+	}
+
+	changes := rulerev.Changes(rulerev.Content(fromRev), to)
+	if changes == nil {
+		changes = []rulerev.Change{}
+	}
+	respondOK(c, gin.H{
+		"rule_id": ruleID,
+		"from":    from,
+		"to":      to.Revision,
+		"changes": changes,
+	})
+}
+
+// RollbackKeywordRule 把规则内容恢复为指定修订，并记为一条新的 rollback 修订，历史不会被改写。
+// 除非请求指定 restore_enabled，规则的启用状态保持不变。
+func RollbackKeywordRule(c *gin.Context) {
+	var req ruleRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Revision <= 0 {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	db := database.GetDB()
+	var row models.KeywordRule
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "关键字规则不存在")
+		return
+	}
+	rev, ok := loadRuleRevision(c, row.ID, req.Revision)
+	if !ok {
+		return
+	}
+	restored := row
+	rulerev.Restore(&restored, rev, req.RestoreEnabled)
+	if len(rulerev.Changes(row, restored)) == 0 {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("规则当前内容与修订 %d 相同", rev.Revision))
+		return
+	}
+	row = restored
+	// 匹配引擎可能已经更新，旧修订需要重新校验才能恢复
+	if _, err := rules.Compile(row); err != nil {
+		respondError(c, http.StatusBadRequest, "修订已无法编译: "+err.Error())
+		return
+	}
+	if err := checkRuleSchedule(row); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkRuleExamples(row); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&row).Error; err != nil {
+			return err
+		}
+		_, err := rulerev.Record(tx, &row, rulerev.ActionRollback, requestAuthor(c), rev.Revision)
+		return err
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "回滚规则失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "回滚成功", gin.H{"message": "回滚成功", "rule": row})
+}
+
+func ruleIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		respondError(c, http.StatusBadRequest, "规则ID无效")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func loadRuleRevision(c *gin.Context, ruleID uint, revision int) (models.KeywordRuleRevision, bool) {
+	rev, err := rulerev.Get(database.GetDB(), ruleID, revision)
+	if errors.Is(err, rulerev.ErrNotFound) {
+		respondError(c, http.StatusNotFound, fmt.Sprintf("规则没有修订 %d", revision))
+		return rev, false
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取规则修订失败")
+		return rev, false
+	}
+	return rev, true
+}
+
+// requestAuthor 返回当前请求的管理员用户名，由 BasicAuth 中间件写入。
+func requestAuthor(c *gin.Context) string {
+	return c.GetString(gin.AuthUserKey)
+}
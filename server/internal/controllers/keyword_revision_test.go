@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+)
+
+func TestRollbackKeywordRuleKeepsEnabledState(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	row := models.KeywordRule{Name: "引流", Pattern: "加群", MatchType: "plain", MatchLogic: "single", Enabled: true}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	if _, err := rulerev.Record(db, &row, rulerev.ActionCreate, "admin", 0); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	// 修订 1 之后修改了内容，随后规则被停用
+	row.Pattern = "私聊"
+	row.Enabled = false
+	if err := db.Select("*").Save(&row).Error; err != nil {
+		t.Fatalf("save rule: %v", err)
+	}
+	if _, err := rulerev.Record(db, &row, rulerev.ActionUpdate, "admin", 0); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/keywords/:id/rollback", RollbackKeywordRule)
+	rollback := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/keywords/%d/rollback", row.ID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := rollback(`{"revision":1}`); code != http.StatusOK {
+		t.Fatalf("rollback status = %d", code)
+	}
+	var stored models.KeywordRule
+	db.First(&stored, row.ID)
+	if stored.Pattern != "加群" || stored.Enabled {
+		t.Fatalf("rollback should restore content but keep the rule disabled, got %+v", stored)
+	}
+
+	// 内容已与修订 1 相同，不恢复启用状态时没有可回滚的变化
+	if code := rollback(`{"revision":1}`); code != http.StatusBadRequest {
+		t.Fatalf("repeated rollback status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := rollback(`{"revision":1,"restore_enabled":true}`); code != http.StatusOK {
+		t.Fatalf("rollback with restore_enabled status = %d", code)
+	}
+	db.First(&stored, row.ID)
+	if !stored.Enabled {
+		t.Fatalf("restore_enabled should re-enable the rule, got %+v", stored)
+	}
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulestats"
+)
+
+const (
+	defaultRuleStatsDays  = 30
+	maxRuleStatsDays      = 180
+	defaultRuleStatsLimit = 10
+	maxRuleStatsLimit     = 100
+)
+
+// GetKeywordRuleStats 返回最近 days 天每条规则的每日计数，以及按误报近似值排序的规则，
+// 误报近似值 = (复查时评论仍在的举报 + 失败的举报) / 举报次数。
+func GetKeywordRuleStats(c *gin.Context) {
+	days, err := boundedIntQuery(c, "days", defaultRuleStatsDays, 1, maxRuleStatsDays)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := boundedIntQuery(c, "limit", defaultRuleStatsLimit, 1, maxRuleStatsLimit)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	db := database.GetDB()
+	var ruleID uint
+	if raw := c.Query("rule_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parsed == 0 {
+			respondError(c, http.StatusBadRequest, "rule_id 无效")
+			return
+		}
+		var row models.KeywordRule
+		if err := db.First(&row, parsed).Error; err != nil {
+			respondError(c, http.StatusNotFound, "关键字规则不存在")
+			return
+		}
+		ruleID = row.ID
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -(days - 1))
+	series, err := rulestats.Load(db, from, to, ruleID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取规则统计失败: "+err.Error())
+		return
+	}
+	respondOK(c, gin.H{
+		"from":      from.Format(rulestats.DayLayout),
+		"to":        to.Format(rulestats.DayLayout),
+		"days":      days,
+		"series":    series,
+		"top_noisy": rulestats.TopNoisy(series, limit),
+	})
+}
+
+func boundedIntQuery(c *gin.Context, key string, fallback, lower, upper int) (int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < lower || value > upper {
+		return 0, fmt.Errorf("%s 必须在 %d-%d 之间", key, lower, upper)
+	}
+	return value, nil
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,11 +14,15 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/classifier"
 	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/logging"
 	"github.com/spiritlhl/goban/internal/models"
 	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/ruleset"
 	"github.com/spiritlhl/goban/internal/secure"
 	"github.com/spiritlhl/goban/internal/settings"
+	"github.com/spiritlhl/goban/internal/whitelist"
 	"gorm.io/gorm"
 )
 
@@ -30,7 +34,7 @@ type taskRequest struct {
 	VideoCount       int               `json:"video_count"`
 	CommentCount     int               `json:"comment_count"`
 	Keywords         string            `json:"keywords"`
-	KeywordRuleIDs   []uint            `json:"keyword_rule_ids"`
+	RuleSetIDs       []uint            `json:"rule_set_ids"`
 	Enabled          *bool             `json:"enabled"`
 	Interval         int               `json:"interval"`
 	ReportDelay      int               `json:"report_delay"`
@@ -38,6 +42,13 @@ type taskRequest struct {
 	MaxRetries       *int              `json:"max_retries"`
 	RetryInterval    int               `json:"retry_interval"`
 	ProxyURL         string            `json:"proxy_url"`
+	ScoreThreshold   *float64          `json:"score_threshold"`
+	NearDuplicate    *bool             `json:"near_duplicate"`
+	// ClassifierThreshold 为 0 时不使用文本分类器
+	ClassifierThreshold *float64 `json:"classifier_threshold"`
+	SkipOwnerComments   *bool    `json:"skip_owner_comments"`
+	SkipUpInteracted    *bool    `json:"skip_up_interacted"`
+	SkipFansMedalLevel  *int     `json:"skip_fans_medal_level"`
 }
 
 type taskStatusRequest struct {
@@ -72,7 +83,7 @@ const (
 func ListMonitorTasks(c *gin.Context) {
 	db := database.GetDB()
 	var tasks []models.MonitorTask
-	if err := db.Preload("User").Preload("Targets").Order("created_at DESC").Find(&tasks).Error; err != nil {
+	if err := db.Preload("User").Preload("Targets").Preload("RuleSets").Order("created_at DESC").Find(&tasks).Error; err != nil {
 		respondError(c, http.StatusInternalServerError, "获取任务列表失败")
 		return
 	}
@@ -128,7 +139,6 @@ func CreateMonitorTask(c *gin.Context) {
 		VideoCount:       withDefault(req.VideoCount, "default_video_count", 5),
 		CommentCount:     withDefault(req.CommentCount, "default_comment_count", 50),
 		Keywords:         strings.TrimSpace(req.Keywords),
-		KeywordRuleIDs:   rules.FormatRuleIDs(req.KeywordRuleIDs),
 		Enabled:          true,
 		Interval:         withDefault(req.Interval, "default_interval", 300),
 		ReportDelay:      withDefault(req.ReportDelay, "default_report_delay", 30),
@@ -138,6 +148,16 @@ func CreateMonitorTask(c *gin.Context) {
 		ProxyURL:         strings.TrimSpace(req.ProxyURL),
 		LastStatus:       "created",
 	}
+	if req.ScoreThreshold != nil {
+		task.ScoreThreshold = *req.ScoreThreshold
+	}
+	if req.NearDuplicate != nil {
+		task.NearDuplicate = *req.NearDuplicate
+	}
+	if req.ClassifierThreshold != nil {
+		task.ClassifierThreshold = *req.ClassifierThreshold
+	}
+	applyTaskTrustOptions(&task, req)
 	if req.Enabled != nil {
 		task.Enabled = *req.Enabled
 	}
@@ -145,17 +165,23 @@ func CreateMonitorTask(c *gin.Context) {
 		task.Name = defaultTaskName(targets)
 	}
 
-	if err := validateTaskRules(req.KeywordRuleIDs, task.Keywords); err != nil {
+	ruleSets, err := loadRuleSets(req.RuleSetIDs)
+	if err != nil {
 		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := validateTaskRules(req.RuleSetIDs, task.Keywords); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	task.RuleSets = ruleSets
 
 	if err := db.Create(&task).Error; err != nil {
 		respondError(c, http.StatusInternalServerError, "创建任务失败: "+err.Error())
 		return
 	}
 
-	db.Preload("User").Preload("Targets").First(&task, task.ID)
+	db.Preload("User").Preload("Targets").Preload("RuleSets").First(&task, task.ID)
 	respondCreated(c, "创建成功", gin.H{"message": "创建成功", "task": task})
 }
 
@@ -194,14 +220,30 @@ func UpdateMonitorTask(c *gin.Context) {
 		}
 	}
 
-	if len(req.KeywordRuleIDs) > 0 || strings.TrimSpace(req.Keywords) != "" {
-		if err := validateTaskRules(req.KeywordRuleIDs, req.Keywords); err != nil {
+	ruleSets, err := loadRuleSets(req.RuleSetIDs)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RuleSetIDs != nil || strings.TrimSpace(req.Keywords) != "" {
+		setIDs := req.RuleSetIDs
+		if setIDs == nil {
+			if setIDs, err = ruleset.SetIDsForTask(db, task.ID); err != nil {
+				respondError(c, http.StatusInternalServerError, "读取任务规则集失败: "+err.Error())
+				return
+			}
+		}
+		keywords := req.Keywords
+		if strings.TrimSpace(keywords) == "" {
+			keywords = task.Keywords
+		}
+		if err := validateTaskRules(setIDs, keywords); err != nil {
 			respondError(c, http.StatusBadRequest, err.Error())
 			return
 		}
 	}
 
-	err := db.Transaction(func(tx *gorm.DB) error {
+	err = db.Transaction(func(tx *gorm.DB) error {
 		if strings.TrimSpace(req.Name) != "" {
 			task.Name = strings.TrimSpace(req.Name)
 		}
@@ -214,8 +256,10 @@ func UpdateMonitorTask(c *gin.Context) {
 		if req.Keywords != "" {
 			task.Keywords = strings.TrimSpace(req.Keywords)
 		}
-		if req.KeywordRuleIDs != nil {
-			task.KeywordRuleIDs = rules.FormatRuleIDs(req.KeywordRuleIDs)
+		if req.RuleSetIDs != nil {
+			if err := replaceTaskRuleSets(tx, &task, ruleSets); err != nil {
+				return err
+			}
 		}
 		if req.Enabled != nil {
 			task.Enabled = *req.Enabled
@@ -236,6 +280,16 @@ func UpdateMonitorTask(c *gin.Context) {
 			task.RetryInterval = req.RetryInterval
 		}
 		task.ProxyURL = strings.TrimSpace(req.ProxyURL)
+		if req.ScoreThreshold != nil {
+			task.ScoreThreshold = *req.ScoreThreshold
+		}
+		if req.NearDuplicate != nil {
+			task.NearDuplicate = *req.NearDuplicate
+		}
+		if req.ClassifierThreshold != nil {
+			task.ClassifierThreshold = *req.ClassifierThreshold
+		}
+		applyTaskTrustOptions(&task, req)
 
 		if len(targets) > 0 {
 			if err := tx.Where("task_id = ?", task.ID).Delete(&models.MonitorTarget{}).Error; err != nil {
@@ -251,14 +305,14 @@ func UpdateMonitorTask(c *gin.Context) {
 				task.Name = defaultTaskName(targets)
 			}
 		}
-		return tx.Save(&task).Error
+		return tx.Omit("RuleSets").Save(&task).Error
 	})
 	if err != nil {
 		respondError(c, http.StatusInternalServerError, "更新失败: "+err.Error())
 		return
 	}
 
-	db.Preload("User").Preload("Targets").First(&task, task.ID)
+	db.Preload("User").Preload("Targets").Preload("RuleSets").First(&task, task.ID)
 	respondCreated(c, "更新成功", gin.H{"message": "更新成功", "task": task})
 }
 
@@ -286,6 +340,12 @@ func DeleteMonitorTask(c *gin.Context) {
 		if err := tx.Where("task_id = ?", task.ID).Delete(&models.ReportRecord{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.WhitelistUser{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&task).Association("RuleSets").Clear(); err != nil {
+			return err
+		}
 		return tx.Delete(&task).Error
 	}); err != nil {
 		respondError(c, http.StatusInternalServerError, "删除失败: "+err.Error())
@@ -303,7 +363,7 @@ func DeleteMonitorTask(c *gin.Context) {
 func ListTaskProgress(c *gin.Context) {
 	db := database.GetDB()
 	var tasks []models.MonitorTask
-	if err := db.Preload("User").Preload("Targets").Order("updated_at DESC").Find(&tasks).Error; err != nil {
+	if err := db.Preload("User").Preload("Targets").Preload("RuleSets").Order("updated_at DESC").Find(&tasks).Error; err != nil {
 		respondError(c, http.StatusInternalServerError, "获取任务进度失败")
 		return
 	}
@@ -313,7 +373,7 @@ func ListTaskProgress(c *gin.Context) {
 func GetTaskProgress(c *gin.Context) {
 	db := database.GetDB()
 	var task models.MonitorTask
-	if err := db.Preload("User").Preload("Targets").First(&task, c.Param("id")).Error; err != nil {
+	if err := db.Preload("User").Preload("Targets").Preload("RuleSets").First(&task, c.Param("id")).Error; err != nil {
 		respondError(c, http.StatusNotFound, "任务不存在")
 		return
 	}
@@ -471,7 +531,7 @@ func ExportReportRecords(c *gin.Context) {
 	c.Header("Content-Type", "text/csv; charset=utf-8")
 	c.Header("Content-Disposition", `attachment; filename="goban-report-records.csv"`)
 	writer := csv.NewWriter(c.Writer)
-	_ = writer.Write([]string{"时间", "任务ID", "UP主UID", "UP主", "视频BVID", "视频标题", "评论ID", "评论用户ID", "评论用户", "匹配规则", "匹配内容", "状态", "消息"})
+	_ = writer.Write([]string{"时间", "任务ID", "UP主UID", "UP主", "视频BVID", "视频标题", "评论ID", "评论用户ID", "评论用户", "匹配规则", "匹配内容", "得分", "状态", "消息"})
 	for _, record := range records {
 		status := "失败"
 		if record.Success {
@@ -489,6 +549,7 @@ func ExportReportRecords(c *gin.Context) {
 			record.CommentUser,
 			record.KeywordRuleName,
 			record.MatchedKeyword,
+			strconv.FormatFloat(record.Score, 'f', -1, 64),
 			status,
 			record.Message,
 		})
@@ -517,12 +578,13 @@ func TestMonitorTask(c *gin.Context) {
 		return
 	}
 
-	compiledRules, compileErrors := compiledRulesForTask(task)
+	compiledRules, compileErrors := ruleset.CompileForTask(database.GetDB(), task)
 	if len(compiledRules) == 0 {
 		respondError(c, http.StatusBadRequest, "未设置可用关键字规则")
 		return
 	}
 
+	engine := rules.NewEngine(compiledRules)
 	client := bili.NewBiliClient(cookies, task.User.UID)
 	client.SetRetryPolicy(task.MaxRetries, task.RetryInterval)
 
@@ -535,7 +597,8 @@ func TestMonitorTask(c *gin.Context) {
 		}
 		videos, err := client.GetUserVideosContext(ctx, target.UID, minInt(task.VideoCount, 3))
 		if err != nil {
-			log.Printf("[测试任务 %d] 获取UP主 %d 视频失败: %v", task.ID, target.UID, err)
+			logging.For("task_test").Warn("获取UP主视频失败",
+				logging.KeyTaskID, task.ID, logging.KeyTargetUID, target.UID, slog.Any(logging.KeyError, err))
 			result = append(result, map[string]interface{}{
 				"target_uid":   target.UID,
 				"target_uname": target.Uname,
@@ -551,7 +614,8 @@ func TestMonitorTask(c *gin.Context) {
 			}
 			comments, err := client.GetVideoCommentsContext(ctx, video.AID, minInt(task.CommentCount, 20))
 			if err != nil {
-				log.Printf("[测试任务 %d] 获取视频评论失败: %v", task.ID, err)
+				logging.For("task_test").Warn("获取视频评论失败",
+					logging.KeyTaskID, task.ID, logging.KeyBVID, video.BVID, slog.Any(logging.KeyError, err))
 				continue
 			}
 
@@ -565,10 +629,16 @@ func TestMonitorTask(c *gin.Context) {
 			}
 
 			matches := []rules.MatchResult{}
+			wouldReport := 0
 			for _, comment := range comments {
-				matches = append(matches, rules.MatchAll(comment.Content.Message, compiledRules)...)
+				commentMatches := engine.MatchAllFor(comment.Content.Message, rules.CommenterFromComment(comment))
+				if len(commentMatches) > 0 && rules.ReachesThreshold(rules.TotalScore(commentMatches), task.ScoreThreshold) {
+					wouldReport++
+				}
+				matches = append(matches, commentMatches...)
 			}
 			videoResult["matches"] = matches
+			videoResult["would_report"] = wouldReport
 			result = append(result, videoResult)
 		}
 	}
@@ -586,12 +656,24 @@ func filteredReportQuery(c *gin.Context) *gorm.DB {
 	if targetUID := c.Query("target_uid"); targetUID != "" {
 		query = query.Where("target_uid = ?", targetUID)
 	}
+	if commentUserID := c.Query("comment_user_id"); commentUserID != "" {
+		query = query.Where("comment_user_id = ?", commentUserID)
+	}
 	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
 		query = query.Where("matched_keyword LIKE ? OR keyword_rule_name LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
 	}
 	if success := c.Query("success"); success != "" {
 		query = query.Where("success = ?", success == "true" || success == "1")
 	}
+	if clusterID := c.Query("cluster_id"); clusterID != "" {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if removal := c.Query("removal_status"); removal != "" {
+		if removal == "pending" {
+			removal = ""
+		}
+		query = query.Where("success = ? AND removal_status = ?", true, removal)
+	}
 	if start := parseTimeQuery(c.Query("start_time")); start != nil {
 		query = query.Where("created_at >= ?", *start)
 	}
@@ -618,7 +700,7 @@ func buildTaskProgressItems(tasks []models.MonitorTask) []taskProgressItem {
 		Order("created_at DESC").
 		Limit(len(taskIDs) * 5).
 		Find(&logs).Error; err != nil {
-		log.Printf("[任务进度] 查询最近日志失败: %v", err)
+		logging.For("task_progress").Error("查询最近日志失败", slog.Any(logging.KeyError, err))
 	}
 	logsByTask := map[uint][]models.MonitorLog{}
 	for _, row := range logs {
@@ -697,9 +779,37 @@ func validateMonitorTaskInput(req taskRequest, targetUIDs []int64) error {
 	if err := validateOptionalInt("重试间隔", req.RetryInterval, minTaskRetrySeconds, maxTaskRetrySeconds); err != nil {
 		return err
 	}
+	if req.ScoreThreshold != nil {
+		if err := rules.ValidateScoreThreshold(*req.ScoreThreshold); err != nil {
+			return err
+		}
+	}
+	if req.ClassifierThreshold != nil {
+		if err := classifier.ValidateThreshold(*req.ClassifierThreshold); err != nil {
+			return err
+		}
+	}
+	if req.SkipFansMedalLevel != nil {
+		if err := whitelist.ValidateFansMedalLevel(*req.SkipFansMedalLevel); err != nil {
+			return err
+		}
+	}
 	return validateProxyURL(req.ProxyURL)
 }
 
+// applyTaskTrustOptions 写入自动跳过可信评论的设置，未提供的字段保持不变。
+func applyTaskTrustOptions(task *models.MonitorTask, req taskRequest) {
+	if req.SkipOwnerComments != nil {
+		task.SkipOwnerComments = *req.SkipOwnerComments
+	}
+	if req.SkipUpInteracted != nil {
+		task.SkipUpInteracted = *req.SkipUpInteracted
+	}
+	if req.SkipFansMedalLevel != nil {
+		task.SkipFansMedalLevel = *req.SkipFansMedalLevel
+	}
+}
+
 func validateOptionalInt(label string, value, minValue, maxValue int) error {
 	if value == 0 {
 		return nil
@@ -727,50 +837,28 @@ func validateProxyURL(raw string) error {
 	}
 }
 
-func validateTaskRules(ruleIDs []uint, adHocKeywords string) error {
-	if len(ruleIDs) == 0 && len(rules.ParseAdHocKeywords(adHocKeywords)) > 0 {
+// validateTaskRules 校验任务至少有一条生效的规则或临时关键字。
+func validateTaskRules(setIDs []uint, adHocKeywords string) error {
+	if len(rules.ParseAdHocKeywords(adHocKeywords)) > 0 {
 		return nil
 	}
-	if len(ruleIDs) == 0 {
-		count := int64(0)
-		if err := database.GetDB().Model(&models.KeywordRule{}).Where("enabled = ?", true).Count(&count).Error; err != nil {
-			return err
-		}
-		if count == 0 {
-			return fmt.Errorf("请先创建至少一个启用的关键字规则，或填写临时关键字")
-		}
-		return nil
+	if len(setIDs) == 0 {
+		return fmt.Errorf("请选择至少一个规则集，或填写临时关键字")
 	}
-
-	var count int64
-	if err := database.GetDB().Model(&models.KeywordRule{}).Where("id IN ? AND enabled = ?", ruleIDs, true).Count(&count).Error; err != nil {
+	rows, err := ruleset.RulesForSets(database.GetDB(), setIDs)
+	if err != nil {
 		return err
 	}
-	if count != int64(len(ruleIDs)) {
-		return fmt.Errorf("存在无效或已停用的关键字规则")
+	if len(rows) > 0 {
+		return nil
 	}
-	return nil
+	return fmt.Errorf("所选规则集中没有启用的关键字规则，请调整规则集或填写临时关键字")
 }
 
 func runeLen(value string) int {
 	return len([]rune(value))
 }
 
-func compiledRulesForTask(task models.MonitorTask) ([]rules.CompiledRule, []error) {
-	db := database.GetDB()
-	var rows []models.KeywordRule
-	ruleIDs := rules.ParseRuleIDs(task.KeywordRuleIDs)
-	query := db.Where("enabled = ?", true)
-	if len(ruleIDs) > 0 {
-		query = query.Where("id IN ?", ruleIDs)
-	}
-	if err := query.Order("created_at ASC").Find(&rows).Error; err != nil {
-		return nil, []error{err}
-	}
-
-	return rules.CompileMany(rows, task.Keywords)
-}
-
 func resolveTargets(ctx context.Context, client *bili.BiliClient, targetUIDs []int64) ([]models.MonitorTarget, error) {
 	targets := make([]models.MonitorTarget, 0, len(targetUIDs))
 	for _, uid := range targetUIDs {
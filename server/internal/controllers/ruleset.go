@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/ruleset"
+	"gorm.io/gorm"
+)
+
+type ruleSetRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	RuleIDs     []uint `json:"rule_ids"`
+}
+
+type ruleSetItem struct {
+	models.RuleSet
+	TaskCount int64 `json:"task_count"`
+}
+
+const (
+	maxRuleSetName        = 80
+	maxRuleSetDescription = 500
+	maxRuleSetRules       = 1000
+)
+
+func ListRuleSets(c *gin.Context) {
+	db := database.GetDB()
+	var rows []models.RuleSet
+	if err := db.Preload("Rules", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("created_at ASC")
+	}).Order("created_at DESC").Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "获取规则集失败")
+		return
+	}
+	items := make([]ruleSetItem, 0, len(rows))
+	for _, row := range rows {
+		count, err := ruleset.TaskCount(db, row.ID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "获取规则集失败")
+			return
+		}
+		items = append(items, ruleSetItem{RuleSet: row, TaskCount: count})
+	}
+	respondOK(c, items)
+}
+
+func CreateRuleSet(c *gin.Context) {
+	var req ruleSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	members, err := validateRuleSetInput(req, 0)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row := models.RuleSet{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Rules:       members,
+	}
+	db := database.GetDB()
+	if err := db.Create(&row).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "创建规则集失败: "+err.Error())
+		return
+	}
+	db.Preload("Rules").First(&row, row.ID)
+	respondCreated(c, "创建成功", gin.H{"message": "创建成功", "rule_set": row})
+}
+
+func UpdateRuleSet(c *gin.Context) {
+	var req ruleSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	db := database.GetDB()
+	var row models.RuleSet
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "规则集不存在")
+		return
+	}
+	members, err := validateRuleSetInput(req, row.ID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	row.Name = strings.TrimSpace(req.Name)
+	row.Description = strings.TrimSpace(req.Description)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Rules").Save(&row).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return tx.Model(&row).Association("Rules").Clear()
+		}
+		return tx.Model(&row).Association("Rules").Replace(members)
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "更新规则集失败: "+err.Error())
+		return
+	}
+	db.Preload("Rules").First(&row, row.ID)
+	respondCreated(c, "更新成功", gin.H{"message": "更新成功", "rule_set": row})
+}
+
+func DeleteRuleSet(c *gin.Context) {
+	db := database.GetDB()
+	var row models.RuleSet
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "规则集不存在")
+		return
+	}
+	if !requireDeleteConfirmation(c, row.Name, strconv.FormatUint(uint64(row.ID), 10)) {
+		return
+	}
+	count, err := ruleset.TaskCount(db, row.ID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "删除规则集失败: "+err.Error())
+		return
+	}
+	if count > 0 {
+		respondError(c, http.StatusConflict, fmt.Sprintf("规则集仍被 %d 个任务使用，请先从任务中移除", count))
+		return
+	}
+	var watched int64
+	if err := db.Model(&models.WatchlistUser{}).Where("rule_set_id = ?", row.ID).Count(&watched).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "删除规则集失败: "+err.Error())
+		return
+	}
+	if watched > 0 {
+		respondError(c, http.StatusConflict, fmt.Sprintf("规则集仍被 %d 个重点关注用户使用，请先修改其处理方式", watched))
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&row).Association("Rules").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&row).Error
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "删除规则集失败: "+err.Error())
+		return
+	}
+	var remaining int64
+	if err := db.Model(&models.RuleSet{}).Where("id = ?", row.ID).Count(&remaining).Error; err != nil || remaining != 0 {
+		respondError(c, http.StatusInternalServerError, "删除结果校验失败")
+		return
+	}
+	respondCreated(c, "删除成功", gin.H{"message": "删除成功", "deleted_id": row.ID})
+}
+
+// GetTaskRules 返回任务实际生效的规则：关联规则集内启用规则的并集和临时关键字。
+// 未关联规则集的任务只使用临时关键字。
+func GetTaskRules(c *gin.Context) {
+	db := database.GetDB()
+	var task models.MonitorTask
+	if err := db.Preload("RuleSets").First(&task, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+	rows, err := ruleset.EffectiveRules(db, task)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "获取任务规则失败: "+err.Error())
+		return
+	}
+	// 启用但当前不在生效时间内的规则仍列出，由 inactive_rule_ids 标明
+	now := time.Now()
+	inactive := make([]uint, 0)
+	for _, row := range rows {
+		if schedule, err := rules.ParseSchedule(row); err == nil && !schedule.ActiveAt(now) {
+			inactive = append(inactive, row.ID)
+		}
+	}
+	_, compileErrors := rules.CompileManyAt(rows, task.Keywords, now)
+	respondOK(c, gin.H{
+		"task_id":           task.ID,
+		"rule_sets":         task.RuleSets,
+		"rules":             rows,
+		"inactive_rule_ids": inactive,
+		"ad_hoc_keywords":   rules.ParseAdHocKeywords(task.Keywords),
+		"compile_errors":    stringifyErrors(compileErrors),
+	})
+}
+
+func validateRuleSetInput(req ruleSetRequest, id uint) ([]models.KeywordRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("请填写规则集名称")
+	}
+	if runeLen(name) > maxRuleSetName {
+		return nil, fmt.Errorf("规则集名称不能超过 %d 个字符", maxRuleSetName)
+	}
+	if runeLen(strings.TrimSpace(req.Description)) > maxRuleSetDescription {
+		return nil, fmt.Errorf("规则集备注不能超过 %d 个字符", maxRuleSetDescription)
+	}
+	ids := rules.ParseRuleIDs(rules.FormatRuleIDs(req.RuleIDs))
+	if len(ids) > maxRuleSetRules {
+		return nil, fmt.Errorf("单个规则集最多包含 %d 条规则", maxRuleSetRules)
+	}
+
+	db := database.GetDB()
+	var duplicate int64
+	if err := db.Model(&models.RuleSet{}).Where("name = ? AND id <> ?", name, id).Count(&duplicate).Error; err != nil {
+		return nil, err
+	}
+	if duplicate > 0 {
+		return nil, fmt.Errorf("规则集名称已存在")
+	}
+	members := make([]models.KeywordRule, 0, len(ids))
+	if len(ids) == 0 {
+		return members, nil
+	}
+	if err := db.Where("id IN ?", ids).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	if len(members) != len(ids) {
+		return nil, fmt.Errorf("存在无效的关键字规则")
+	}
+	return members, nil
+}
+
+// loadRuleSets 按ID加载任务要关联的规则集，任一ID不存在时报错。
+func loadRuleSets(ids []uint) ([]models.RuleSet, error) {
+	ids = rules.ParseRuleIDs(rules.FormatRuleIDs(ids))
+	sets := make([]models.RuleSet, 0, len(ids))
+	if len(ids) == 0 {
+		return sets, nil
+	}
+	if err := database.GetDB().Where("id IN ?", ids).Find(&sets).Error; err != nil {
+		return nil, err
+	}
+	if len(sets) != len(ids) {
+		return nil, errors.New("存在无效的规则集")
+	}
+	return sets, nil
+}
+
+func replaceTaskRuleSets(tx *gorm.DB, task *models.MonitorTask, sets []models.RuleSet) error {
+	if len(sets) == 0 {
+		return tx.Model(task).Association("RuleSets").Clear()
+	}
+	return tx.Model(task).Association("RuleSets").Replace(sets)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/spiritlhl/goban/internal/config"
 	"github.com/spiritlhl/goban/internal/settings"
+	"github.com/spiritlhl/goban/internal/watchlist"
 )
 
 func GetSettings(c *gin.Context) {
@@ -57,19 +58,24 @@ type settingRange struct {
 }
 
 var numericSettingRanges = map[string]settingRange{
-	"default_video_count":        {min: 1, max: 50},
-	"default_comment_count":      {min: 1, max: 500},
-	"default_interval":           {min: 60, max: 86400},
-	"default_report_delay":       {min: 30, max: 3600},
-	"default_daily_report_limit": {min: 1, max: 5000},
-	"default_max_retries":        {min: 0, max: 10},
-	"default_retry_interval":     {min: 1, max: 300},
-	"cookie_check_interval":      {min: 60, max: 86400},
-	"cookie_refresh_interval":    {min: 300, max: 604800},
-	"log_dedupe_window_seconds":  {min: 0, max: 86400},
-	"risk_backoff_base_seconds":  {min: 60, max: 604800},
-	"risk_backoff_max_seconds":   {min: 60, max: 1209600},
-	"webhook_timeout":            {min: 1, max: 60},
+	"default_video_count":         {min: 1, max: 50},
+	"default_comment_count":       {min: 1, max: 500},
+	"default_interval":            {min: 60, max: 86400},
+	"default_report_delay":        {min: 30, max: 3600},
+	"default_daily_report_limit":  {min: 1, max: 5000},
+	"default_max_retries":         {min: 0, max: 10},
+	"default_retry_interval":      {min: 1, max: 300},
+	"cookie_check_interval":       {min: 60, max: 86400},
+	"cookie_refresh_interval":     {min: 300, max: 604800},
+	"log_dedupe_window_seconds":   {min: 0, max: 86400},
+	"risk_backoff_base_seconds":   {min: 60, max: 604800},
+	"risk_backoff_max_seconds":    {min: 60, max: 1209600},
+	"removal_check_delay_seconds": {min: 0, max: 604800},
+	"near_duplicate_window_hours": {min: 1, max: 168},
+	"near_duplicate_min_cluster":  {min: 2, max: 1000},
+	"near_duplicate_max_distance": {min: 0, max: 16},
+	"watchlist_auto_threshold":    {min: 0, max: 1000},
+	"webhook_timeout":             {min: 1, max: 60},
 }
 
 var textSettingLimits = map[string]int{
@@ -105,6 +111,10 @@ func validateSettingsInput(values map[string]string) error {
 			if _, err := strconv.ParseBool(value); err != nil {
 				return fmt.Errorf("webhook_enabled 必须是 true 或 false")
 			}
+		case "watchlist_auto_mode":
+			if err := watchlist.ValidateAutoMode(value); err != nil {
+				return err
+			}
 		case "webhook_type":
 			switch value {
 			case "none", "telegram", "feishu", "dingtalk":
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/spiritlhl/goban/internal/bili"
 	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/logging"
 	"github.com/spiritlhl/goban/internal/models"
 	"github.com/spiritlhl/goban/internal/secure"
 	"github.com/yeqown/go-qrcode/v2"
@@ -64,23 +65,24 @@ func ListBiliUsers(c *gin.Context) {
 
 // LoginUser 生成B站登录二维码
 func LoginUser(c *gin.Context) {
-	log.Println("开始生成TV端二维码...")
+	logger := logging.For("login")
+	logger.Debug("开始生成TV端二维码")
 
 	qrResp, err := bili.GenerateTVQRCode()
 	if err != nil {
-		log.Printf("生成二维码失败: %v", err)
+		logger.Error("生成二维码失败", slog.Any(logging.KeyError, err))
 		respondError(c, http.StatusBadGateway, "生成二维码失败: "+err.Error())
 		return
 	}
 
-	log.Println("TV端二维码生成成功")
+	logger.Debug("TV端二维码生成成功")
 
 	// 生成二维码图片
 	qrc, err := qrcode.NewWith(qrResp.Data.URL,
 		qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionMedium),
 	)
 	if err != nil {
-		log.Printf("创建二维码失败: %v", err)
+		logger.Error("创建二维码失败", slog.Any(logging.KeyError, err))
 		respondError(c, http.StatusInternalServerError, "创建二维码失败")
 		return
 	}
@@ -92,24 +94,24 @@ func LoginUser(c *gin.Context) {
 		standard.WithBuiltinImageEncoder(standard.PNG_FORMAT),
 	)
 	if err = qrc.Save(stdWriter); err != nil {
-		log.Printf("生成PNG失败: %v", err)
+		logger.Error("生成PNG失败", slog.Any(logging.KeyError, err))
 		respondError(c, http.StatusInternalServerError, "生成PNG失败")
 		return
 	}
 
 	pngBytes := buf.Bytes()
-	log.Printf("[INFO] 生成的PNG大小: %d bytes", len(pngBytes))
+	logger.Debug("生成二维码PNG", "bytes", len(pngBytes))
 
 	// 验证PNG头部
 	if len(pngBytes) < 8 || string(pngBytes[1:4]) != "PNG" {
-		log.Printf("[ERROR] PNG格式无效，头部: %v", pngBytes[:min(8, len(pngBytes))])
+		logger.Error("PNG格式无效", "header", pngBytes[:min(8, len(pngBytes))])
 		respondError(c, http.StatusInternalServerError, "生成的二维码图片格式无效")
 		return
 	}
 
 	// Base64编码
 	imageBase64 := base64.StdEncoding.EncodeToString(pngBytes)
-	log.Printf("[INFO] Base64编码长度: %d", len(imageBase64))
+	logger.Debug("二维码Base64编码完成", "length", len(imageBase64))
 
 	// 使用图片的最后100个字符作为session key
 	sessionKey := imageBase64
@@ -132,6 +134,7 @@ func LoginUser(c *gin.Context) {
 
 // LoginCheck 检查登录状态（轮询）
 func LoginCheck(c *gin.Context) {
+	logger := logging.For("login")
 	sessionKey := c.Query("key")
 	if sessionKey == "" {
 		respondOK(c, gin.H{
@@ -179,7 +182,7 @@ func LoginCheck(c *gin.Context) {
 	// 轮询登录状态
 	pollResp, err := bili.PollTVQRCodeStatus(session.AuthCode)
 	if err != nil {
-		log.Printf("[ERROR] 轮询失败: %v", err)
+		logger.Warn("轮询登录状态失败", slog.Any(logging.KeyError, err))
 		respondOK(c, gin.H{
 			"status":  "pending",
 			"message": "检查中...",
@@ -187,13 +190,12 @@ func LoginCheck(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[POLL] 轮询响应 - code: %d, message: %s",
-		pollResp.Data.Code, pollResp.Message)
+	logger.Debug("轮询登录状态", "code", pollResp.Data.Code, "message", pollResp.Message)
 
 	switch pollResp.Data.Code {
 	case 0: // 登录成功
 		cookieStr := bili.ExtractCookiesFromTVPollResponse(pollResp)
-		log.Printf("[TV] 提取到Cookie，长度: %d", len(cookieStr))
+		logger.Debug("提取到Cookie", "length", len(cookieStr))
 
 		if cookieStr == "" {
 			session.Status = "failed"
@@ -231,7 +233,7 @@ func LoginCheck(c *gin.Context) {
 		session.Status = "success"
 		session.Message = "登录成功"
 
-		log.Printf("[INFO] 用户登录成功: UID=%d, Uname=%s", user.UID, user.Uname)
+		logger.Info("用户扫码登录成功", logging.KeyAccountID, user.ID, "uid", user.UID, "uname", user.Uname)
 
 		respondOK(c, gin.H{
 			"status":  "success",
@@ -317,7 +319,7 @@ func LoginByCookie(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[INFO] 用户通过Cookie登录成功: UID=%d, Uname=%s", user.UID, user.Uname)
+	logging.For("login").Info("用户通过Cookie登录成功", logging.KeyAccountID, user.ID, "uid", user.UID, "uname", user.Uname)
 
 	respondCreated(c, "登录成功", gin.H{
 		"type": "success",
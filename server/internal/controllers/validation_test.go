@@ -1,8 +1,13 @@
 package controllers
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
 )
 
 func TestValidateMonitorTaskInputBounds(t *testing.T) {
@@ -46,6 +51,28 @@ func TestValidateKeywordRuleInputBounds(t *testing.T) {
 	}
 }
 
+func TestBuildKeywordRuleRejectsFailingExamples(t *testing.T) {
+	_, err := buildKeywordRule(keywordRuleRequest{
+		Pattern:      `v[信x]\d{5,}`,
+		MatchType:    "regex",
+		MustMatch:    "加v信12345\n加vx123456",
+		MustNotMatch: "v信号很好",
+	})
+	if err != nil {
+		t.Fatalf("passing examples should be accepted, got %v", err)
+	}
+
+	_, err = buildKeywordRule(keywordRuleRequest{
+		Pattern:      `v[信x]\d+`,
+		MatchType:    "regex",
+		MustMatch:    "加vx123456\n加微信123456",
+		MustNotMatch: "v信1",
+	})
+	if err == nil || !strings.Contains(err.Error(), "应命中「加微信123456」") || !strings.Contains(err.Error(), "不应命中「v信1」") {
+		t.Fatalf("expected both failing examples in the error, got %v", err)
+	}
+}
+
 func TestValidateSettingsInput(t *testing.T) {
 	err := validateSettingsInput(map[string]string{
 		"default_report_delay": "5",
@@ -69,3 +96,52 @@ func TestValidateSettingsInput(t *testing.T) {
 		t.Fatalf("expected valid settings, got %v", err)
 	}
 }
+
+func TestValidateWhitelistUserScope(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+	task := models.MonitorTask{Name: "任务"}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := db.Create(&models.MonitorTarget{TaskID: task.ID, UID: 100}).Error; err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	cases := []struct {
+		row     models.WhitelistUser
+		wantErr string
+	}{
+		{models.WhitelistUser{Remark: "空"}, "至少填写一个"},
+		{models.WhitelistUser{UID: 1, TaskID: task.ID + 1}, "任务不存在"},
+		{models.WhitelistUser{UID: 1, TaskID: task.ID, TargetUID: 200}, "不在任务的监控列表中"},
+		{models.WhitelistUser{UID: 1, ExpiresAt: &past}, "过期时间"},
+		{models.WhitelistUser{UID: 1, TaskID: task.ID, TargetUID: 100, ExpiresAt: &future}, ""},
+		{models.WhitelistUser{Uname: "房管", TargetUID: 200}, ""},
+		{models.WhitelistUser{Uname: "*官方*", UnameMatch: "glob"}, ""},
+		{models.WhitelistUser{Uname: "*", UnameMatch: "glob"}, "不能匹配所有用户"},
+		{models.WhitelistUser{Uname: "官方(", UnameMatch: "regex"}, "正则无效"},
+		{models.WhitelistUser{UID: 1, UnameMatch: "regex"}, "必须填写用户名"},
+	}
+	for _, tc := range cases {
+		err := validateWhitelistUser(db, tc.row, nil)
+		if tc.wantErr == "" && err != nil || tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("validateWhitelistUser(%+v) = %v, want %q", tc.row, err, tc.wantErr)
+		}
+	}
+
+	// 已过期的条目修改备注时保留原过期时间
+	stored := models.WhitelistUser{UID: 1, ExpiresAt: &past}
+	edited := stored
+	edited.Remark = "已过期的临时豁免"
+	if err := validateWhitelistUser(db, edited, &stored); err != nil {
+		t.Fatalf("expected unchanged expiry to be accepted, got %v", err)
+	}
+}
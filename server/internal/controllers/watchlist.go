@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/watchlist"
+	"gorm.io/gorm"
+)
+
+type watchlistRequest struct {
+	UID       int64  `json:"uid"`
+	Uname     string `json:"uname"`
+	Reason    string `json:"reason"`
+	Mode      string `json:"mode"`
+	RuleSetID uint   `json:"rule_set_id"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+// ListWatchlistUsers 返回重点关注用户，可用 mode 和 source 筛选。
+func ListWatchlistUsers(c *gin.Context) {
+	query := database.GetDB().Order("created_at DESC")
+	if mode := c.Query("mode"); mode != "" {
+		query = query.Where("mode = ?", mode)
+	}
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+	var rows []models.WatchlistUser
+	if err := query.Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "获取重点关注列表失败")
+		return
+	}
+	respondOK(c, rows)
+}
+
+func CreateWatchlistUser(c *gin.Context) {
+	var req watchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	row := models.WatchlistUser{
+		UID:       req.UID,
+		Uname:     strings.TrimSpace(req.Uname),
+		Reason:    strings.TrimSpace(req.Reason),
+		Mode:      req.Mode,
+		RuleSetID: req.RuleSetID,
+		Source:    watchlist.SourceManual,
+		Enabled:   enabled,
+	}
+	if row.Mode == "" {
+		row.Mode = watchlist.ModeNotifyOnly
+	}
+	db := database.GetDB()
+	if err := validateWatchlistUser(db, row); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := db.Create(&row).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "创建重点关注失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "创建成功", gin.H{"message": "创建成功", "user": row})
+}
+
+// UpdateWatchlistUser 修改重点关注条目，自动加入的条目修改后保留来源和当时的举报次数。
+func UpdateWatchlistUser(c *gin.Context) {
+	var req watchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	db := database.GetDB()
+	var row models.WatchlistUser
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "重点关注用户不存在")
+		return
+	}
+	row.UID = req.UID
+	row.Uname = strings.TrimSpace(req.Uname)
+	row.Reason = strings.TrimSpace(req.Reason)
+	if req.Mode != "" {
+		row.Mode = req.Mode
+	}
+	row.RuleSetID = req.RuleSetID
+	if row.Mode != watchlist.ModeExtraRules {
+		row.RuleSetID = 0
+	}
+	if req.Enabled != nil {
+		row.Enabled = *req.Enabled
+	}
+	if err := validateWatchlistUser(db, row); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := db.Save(&row).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "更新重点关注失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "更新成功", gin.H{"message": "更新成功", "user": row})
+}
+
+func DeleteWatchlistUser(c *gin.Context) {
+	db := database.GetDB()
+	var row models.WatchlistUser
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		respondError(c, http.StatusNotFound, "重点关注用户不存在")
+		return
+	}
+	if !requireDeleteConfirmation(c, row.Uname, strconv.FormatInt(row.UID, 10), strconv.FormatUint(uint64(row.ID), 10)) {
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := watchlist.ForgetNotices(tx, row.UID); err != nil {
+			return err
+		}
+		return tx.Delete(&row).Error
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "删除重点关注失败: "+err.Error())
+		return
+	}
+	respondCreated(c, "删除成功", gin.H{"message": "删除成功", "deleted_id": row.ID})
+}
+
+// validateWatchlistUser 校验重点关注条目：UID 必填且不能与其他条目重复，extra_rules 的规则集必须存在。
+func validateWatchlistUser(db *gorm.DB, row models.WatchlistUser) error {
+	if row.UID <= 0 {
+		return errors.New("UID 无效")
+	}
+	if runeLen(row.Reason) > 200 {
+		return errors.New("关注原因不能超过 200 个字符")
+	}
+	if err := watchlist.ValidateMode(row.Mode, row.RuleSetID); err != nil {
+		return err
+	}
+	if row.Mode == watchlist.ModeExtraRules {
+		var set models.RuleSet
+		if err := db.Select("id").First(&set, row.RuleSetID).Error; err != nil {
+			return errors.New("规则集不存在")
+		}
+	}
+	var count int64
+	if err := db.Model(&models.WatchlistUser{}).Where("uid = ? AND id <> ?", row.UID, row.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("该 UID 已在重点关注列表中")
+	}
+	return nil
+}
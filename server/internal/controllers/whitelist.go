@@ -1,25 +1,44 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spiritlhl/goban/internal/database"
 	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/whitelist"
+	"gorm.io/gorm"
 )
 
 type whitelistRequest struct {
-	UID     int64  `json:"uid"`
-	Uname   string `json:"uname"`
-	Remark  string `json:"remark"`
-	Enabled *bool  `json:"enabled"`
+	UID   int64  `json:"uid"`
+	Uname string `json:"uname"`
+	// UnameMatch 为空时按精确匹配处理
+	UnameMatch string     `json:"uname_match"`
+	Remark     string     `json:"remark"`
+	Enabled    *bool      `json:"enabled"`
+	TaskID     uint       `json:"task_id"`
+	TargetUID  int64      `json:"target_uid"`
+	ExpiresAt  *time.Time `json:"expires_at"`
 }
 
+// ListWhitelistUsers 返回白名单，可用 task_id 只列出对该任务生效的条目（包括全部任务的条目）。
 func ListWhitelistUsers(c *gin.Context) {
+	query := database.GetDB().Order("created_at DESC")
+	if raw := c.Query("task_id"); raw != "" {
+		taskID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || taskID == 0 {
+			respondError(c, http.StatusBadRequest, "task_id 无效")
+			return
+		}
+		query = query.Where("task_id IN ?", []uint{0, uint(taskID)})
+	}
 	var rows []models.WhitelistUser
-	if err := database.GetDB().Order("created_at DESC").Find(&rows).Error; err != nil {
+	if err := query.Find(&rows).Error; err != nil {
 		respondError(c, http.StatusInternalServerError, "获取白名单失败")
 		return
 	}
@@ -32,19 +51,23 @@ func CreateWhitelistUser(c *gin.Context) {
 		respondError(c, http.StatusBadRequest, "请求参数错误")
 		return
 	}
-	if req.UID <= 0 && strings.TrimSpace(req.Uname) == "" {
-		respondError(c, http.StatusBadRequest, "UID 和用户名至少填写一个")
-		return
-	}
 	enabled := true
 	if req.Enabled != nil {
 		enabled = *req.Enabled
 	}
 	row := models.WhitelistUser{
-		UID:     req.UID,
-		Uname:   strings.TrimSpace(req.Uname),
-		Remark:  strings.TrimSpace(req.Remark),
-		Enabled: enabled,
+		UID:        req.UID,
+		Uname:      strings.TrimSpace(req.Uname),
+		UnameMatch: unameMatchOrDefault(req.UnameMatch),
+		Remark:     strings.TrimSpace(req.Remark),
+		Enabled:    enabled,
+		TaskID:     req.TaskID,
+		TargetUID:  req.TargetUID,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := validateWhitelistUser(database.GetDB(), row, nil); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
 	}
 	if err := database.GetDB().Create(&row).Error; err != nil {
 		respondError(c, http.StatusInternalServerError, "创建白名单失败: "+err.Error())
@@ -65,14 +88,19 @@ func UpdateWhitelistUser(c *gin.Context) {
 		respondError(c, http.StatusNotFound, "白名单不存在")
 		return
 	}
+	before := row
 	row.UID = req.UID
 	row.Uname = strings.TrimSpace(req.Uname)
+	row.UnameMatch = unameMatchOrDefault(req.UnameMatch)
 	row.Remark = strings.TrimSpace(req.Remark)
 	if req.Enabled != nil {
 		row.Enabled = *req.Enabled
 	}
-	if row.UID <= 0 && row.Uname == "" {
-		respondError(c, http.StatusBadRequest, "UID 和用户名至少填写一个")
+	row.TaskID = req.TaskID
+	row.TargetUID = req.TargetUID
+	row.ExpiresAt = req.ExpiresAt
+	if err := validateWhitelistUser(db, row, &before); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 	if err := db.Save(&row).Error; err != nil {
@@ -103,3 +131,49 @@ func DeleteWhitelistUser(c *gin.Context) {
 	}
 	respondCreated(c, "删除成功", gin.H{"message": "删除成功", "deleted_id": row.ID})
 }
+
+// unameMatchOrDefault 把空的用户名匹配方式视为精确匹配。
+func unameMatchOrDefault(match string) string {
+	match = strings.TrimSpace(match)
+	if match == "" {
+		return whitelist.UnameExact
+	}
+	return match
+}
+
+// validateWhitelistUser 校验白名单条目：UID 和用户名至少填写一个，通配符和正则用户名必须有效，任务范围必须存在，
+// UP 主范围在指定任务时必须是该任务监控的 UP 主；新设置的过期时间必须晚于当前时间。
+// 更新时 before 为修改前的条目，未改动的过期时间即使已过也允许保存。
+func validateWhitelistUser(db *gorm.DB, row models.WhitelistUser, before *models.WhitelistUser) error {
+	if row.UID < 0 || row.TargetUID < 0 {
+		return errors.New("UID 无效")
+	}
+	if row.UID == 0 && row.Uname == "" {
+		return errors.New("UID 和用户名至少填写一个")
+	}
+	if err := whitelist.ValidateUname(row.Uname, row.UnameMatch); err != nil {
+		return err
+	}
+	if row.TaskID > 0 {
+		var task models.MonitorTask
+		if err := db.Select("id").First(&task, row.TaskID).Error; err != nil {
+			return errors.New("任务不存在")
+		}
+		if row.TargetUID > 0 {
+			var count int64
+			if err := db.Model(&models.MonitorTarget{}).Where("task_id = ? AND uid = ?", row.TaskID, row.TargetUID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return errors.New("该 UP 主不在任务的监控列表中")
+			}
+		}
+	}
+	if row.ExpiresAt != nil && !row.ExpiresAt.After(time.Now()) {
+		unchanged := before != nil && before.ExpiresAt != nil && before.ExpiresAt.Equal(*row.ExpiresAt)
+		if !unchanged {
+			return errors.New("过期时间必须晚于当前时间")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/whitelist"
+	"gorm.io/gorm"
+)
+
+type whitelistImportRequest struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+	Policy  string `json:"policy"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// whitelistImportItem 是单条导入条目与现有白名单的比对结果，状态和操作取值与规则导入相同。
+type whitelistImportItem struct {
+	Index      int    `json:"index"`
+	UID        int64  `json:"uid"`
+	Uname      string `json:"uname"`
+	UnameMatch string `json:"uname_match"`
+	Status     string `json:"status"`
+	Action     string `json:"action"`
+	ExistingID uint   `json:"existing_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	row models.WhitelistUser
+}
+
+const (
+	maxWhitelistImportBytes   = 2 << 20
+	maxWhitelistImportEntries = 5000
+)
+
+var whitelistExportFiles = map[string]struct{ name, contentType string }{
+	whitelist.FormatCSV:  {"goban-whitelist.csv", "text/csv; charset=utf-8"},
+	whitelist.FormatJSON: {"goban-whitelist.json", "application/json; charset=utf-8"},
+}
+
+// ImportWhitelistUsers 批量导入白名单。UID、用户名、匹配方式和适用范围都相同的条目视为同一条，
+// 按 policy 跳过或覆盖其备注、启用状态和过期时间；dry_run 时只返回比对结果，不写库。
+func ImportWhitelistUsers(c *gin.Context) {
+	var req whitelistImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	format, err := whitelist.ParseFormat(req.Format)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	policy := strings.ToLower(strings.TrimSpace(req.Policy))
+	switch policy {
+	case "":
+		policy = "skip"
+	case "skip", "overwrite":
+	default:
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("不支持的冲突策略: %s，可选 skip、overwrite", req.Policy))
+		return
+	}
+	if len(req.Content) > maxWhitelistImportBytes {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("导入内容不能超过 %d MB", maxWhitelistImportBytes>>20))
+		return
+	}
+	entries, err := whitelist.Decode([]byte(req.Content), format)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		respondError(c, http.StatusBadRequest, "导入内容中没有白名单条目")
+		return
+	}
+	if len(entries) > maxWhitelistImportEntries {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("单次最多导入 %d 条白名单", maxWhitelistImportEntries))
+		return
+	}
+
+	db := database.GetDB()
+	var existing []models.WhitelistUser
+	if err := db.Find(&existing).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "读取现有白名单失败: "+err.Error())
+		return
+	}
+	byKey := make(map[string]models.WhitelistUser, len(existing))
+	for _, row := range existing {
+		byKey[whitelist.Key(row)] = row
+	}
+
+	items := make([]whitelistImportItem, 0, len(entries))
+	summary := map[string]int{"new": 0, "changed": 0, "duplicate": 0, "invalid": 0}
+	seen := map[string]bool{}
+	for i, entry := range entries {
+		row := whitelistRowFromEntry(entry)
+		item := whitelistImportItem{Index: i, UID: row.UID, Uname: row.Uname, UnameMatch: row.UnameMatch, Action: "skip"}
+		// 导出文件中已过期的条目原样导入，与编辑时未改动的过期时间一样允许保存
+		if err := validateWhitelistUser(db, row, &row); err != nil {
+			item.Status, item.Error = "invalid", err.Error()
+		} else if key := whitelist.Key(row); seen[key] {
+			item.Status, item.Error = "duplicate", "与本次导入中的前一条重复"
+		} else if current, ok := byKey[key]; ok {
+			seen[key] = true
+			item.ExistingID = current.ID
+			item.Status = "duplicate"
+			if whitelistEntryChanged(current, row) {
+				item.Status = "changed"
+				if policy == "overwrite" {
+					current.Remark, current.Enabled, current.ExpiresAt = row.Remark, row.Enabled, row.ExpiresAt
+					item.row, item.Action = current, "update"
+				}
+			}
+		} else {
+			seen[key] = true
+			item.Status, item.row, item.Action = "new", row, "create"
+		}
+		summary[item.Status]++
+		items = append(items, item)
+	}
+
+	result := gin.H{
+		"dry_run": req.DryRun,
+		"format":  format,
+		"policy":  policy,
+		"summary": summary,
+		"items":   items,
+	}
+	if req.DryRun {
+		respondOK(c, result)
+		return
+	}
+
+	created, updated, skipped := 0, 0, 0
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			switch items[i].Action {
+			case "create":
+				if err := tx.Create(&items[i].row).Error; err != nil {
+					return fmt.Errorf("第 %d 条: %w", items[i].Index+1, err)
+				}
+				created++
+			case "update":
+				if err := tx.Save(&items[i].row).Error; err != nil {
+					return fmt.Errorf("第 %d 条: %w", items[i].Index+1, err)
+				}
+				updated++
+			default:
+				skipped++
+			}
+		}
+		return nil
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, "导入失败: "+err.Error())
+		return
+	}
+	result["created"] = created
+	result["updated"] = updated
+	result["skipped"] = skipped
+	respondCreated(c, "导入成功", gin.H{"message": "导入成功", "result": result})
+}
+
+// ExportWhitelistUsers 导出白名单，可用 task_id 只导出对该任务生效的条目。
+func ExportWhitelistUsers(c *gin.Context) {
+	format, err := whitelist.ParseFormat(c.Query("format"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := database.GetDB().Order("created_at ASC")
+	if raw := c.Query("task_id"); raw != "" {
+		taskID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || taskID == 0 {
+			respondError(c, http.StatusBadRequest, "task_id 无效")
+			return
+		}
+		query = query.Where("task_id IN ?", []uint{0, uint(taskID)})
+	}
+	var rows []models.WhitelistUser
+	if err := query.Find(&rows).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "导出失败")
+		return
+	}
+	data, err := whitelist.Encode(rows, format)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "导出失败: "+err.Error())
+		return
+	}
+	file := whitelistExportFiles[format]
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.name))
+	c.Data(http.StatusOK, file.contentType, data)
+}
+
+func whitelistRowFromEntry(entry whitelist.Entry) models.WhitelistUser {
+	enabled := true
+	if entry.Enabled != nil {
+		enabled = *entry.Enabled
+	}
+	return models.WhitelistUser{
+		UID:        entry.UID,
+		Uname:      strings.TrimSpace(entry.Uname),
+		UnameMatch: unameMatchOrDefault(entry.UnameMatch),
+		Remark:     strings.TrimSpace(entry.Remark),
+		Enabled:    enabled,
+		TaskID:     entry.TaskID,
+		TargetUID:  entry.TargetUID,
+		ExpiresAt:  entry.ExpiresAt,
+	}
+}
+
+// whitelistEntryChanged 比较同一条白名单导入时可覆盖的字段。
+func whitelistEntryChanged(current, incoming models.WhitelistUser) bool {
+	if current.Remark != incoming.Remark || current.Enabled != incoming.Enabled {
+		return true
+	}
+	if (current.ExpiresAt == nil) != (incoming.ExpiresAt == nil) {
+		return true
+	}
+	return current.ExpiresAt != nil && !current.ExpiresAt.Equal(*incoming.ExpiresAt)
+}
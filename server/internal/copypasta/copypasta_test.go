@@ -0,0 +1,118 @@
+package copypasta
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestFingerprintToleratesSmallEdits(t *testing.T) {
+	base, ok := Fingerprint("兄弟们快来看这个视频，点我主页有惊喜福利，先到先得")
+	if !ok {
+		t.Fatal("expected long comment to be fingerprinted")
+	}
+	edited, _ := Fingerprint("兄弟們快來看這個視頻！！點我主页有惊喜福利～先到先得")
+	other, _ := Fingerprint("这期视频剪辑节奏很好，背景音乐也选得不错，支持一下")
+	if d := Distance(base, edited); d > 3 {
+		t.Fatalf("expected small distance for edited copy, got %d", d)
+	}
+	if d := Distance(base, other); d <= 10 {
+		t.Fatalf("expected unrelated comments to be far apart, got %d", d)
+	}
+	if _, ok := Fingerprint("哈哈哈哈哈"); ok {
+		t.Fatal("expected short comment to be skipped")
+	}
+}
+
+func TestDetectorClustersAcrossVideos(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+	cfg := Config{Window: 24 * time.Hour, MinCluster: 3, MaxDistance: 3}
+	now := time.Now()
+	detector := NewDetector()
+
+	spam := []string{
+		"兄弟们快来看这个视频，点我主页有惊喜福利，先到先得",
+		"兄弟们快来看这个视频！点我主页有惊喜福利，先到先得！",
+		"兄弟們快來看這個視頻，點我主頁有驚喜福利，先到先得",
+	}
+	var last Result
+	for i, text := range spam {
+		comment := models.ClusterComment{TaskID: 1, AVID: int64(100 + i), CommentID: int64(i + 1), CommentUserID: int64(500 + i), Content: text, SeenAt: now.Add(-time.Hour)}
+		result, ok, err := detector.Observe(db, comment, cfg, now)
+		if err != nil || !ok {
+			t.Fatalf("Observe %d failed: %v (ok=%v)", i, err, ok)
+		}
+		if i == 0 && result.Cluster.ID != 0 {
+			t.Fatalf("expected first comment to stay unclustered, got %+v", result)
+		}
+		if i == 1 && (result.Cluster.ID == 0 || result.Flagged) {
+			t.Fatalf("expected a cluster below the threshold, got %+v", result)
+		}
+		last = result
+	}
+	if !last.Flagged || last.WindowSize != 3 || last.Users != 3 || last.Cluster.Size != 3 {
+		t.Fatalf("expected flagged cluster of 3, got %+v", last)
+	}
+	if match := last.Match(); match.ClusterID != last.Cluster.ID || match.MatchType != MatchType || match.RuleID != 0 {
+		t.Fatalf("unexpected synthetic match: %+v", match)
+	}
+
+	// 同一评论再次出现不重复计数
+	again, ok, err := detector.Observe(db, models.ClusterComment{CommentID: 1, CommentUserID: 500, Content: spam[0], SeenAt: now.Add(-time.Hour)}, cfg, now)
+	if err != nil || !ok || !again.Flagged || again.WindowSize != 3 {
+		t.Fatalf("expected repeated comment to report the same cluster, got %+v (%v)", again, err)
+	}
+
+	unrelated, ok, err := detector.Observe(db, models.ClusterComment{CommentID: 9, Content: "这期视频剪辑节奏很好，背景音乐也选得不错，支持一下"}, cfg, now)
+	if err != nil || !ok || unrelated.Cluster.ID != 0 {
+		t.Fatalf("expected unrelated comment to stay alone, got %+v (%v)", unrelated, err)
+	}
+	if _, ok, _ := detector.Observe(db, models.ClusterComment{CommentID: 10, Content: spam[1], SeenAt: now.Add(-48 * time.Hour)}, cfg, now); ok {
+		t.Fatal("expected comment outside the window to be ignored")
+	}
+
+	// 驳回后不再作为命中；新的检测器从数据库恢复索引
+	db.Model(&models.CommentCluster{}).Where("id = ?", last.Cluster.ID).Update("status", StatusDismissed)
+	restored, ok, err := NewDetector().Observe(db, models.ClusterComment{CommentID: 4, CommentUserID: 503, Content: "兄弟们快来看这个视频，点我主页有惊喜福利，先到先得～", SeenAt: now}, cfg, now)
+	if err != nil || !ok || restored.Cluster.ID != last.Cluster.ID || restored.Flagged || restored.WindowSize != 4 {
+		t.Fatalf("expected dismissed cluster to absorb but not flag, got %+v (%v)", restored, err)
+	}
+}
+
+func TestDetectorIgnoresOneUserRepeatingText(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+	cfg := Config{Window: 24 * time.Hour, MinCluster: 3, MaxDistance: 3}
+	now := time.Now()
+	detector := NewDetector()
+
+	// 同一账号粘贴五次是刷屏规则的范围，不构成多账号的近似重复簇
+	var last Result
+	for i := 0; i < 5; i++ {
+		comment := models.ClusterComment{TaskID: 1, AVID: int64(200 + i), CommentID: int64(100 + i), CommentUserID: 42, Content: "家人们这个副业真的能日结，想了解的看我主页联系方式", SeenAt: now.Add(-time.Hour)}
+		result, ok, err := detector.Observe(db, comment, cfg, now)
+		if err != nil || !ok {
+			t.Fatalf("Observe %d failed: %v (ok=%v)", i, err, ok)
+		}
+		last = result
+	}
+	if last.Cluster.ID == 0 || last.WindowSize != 5 || last.Users != 1 || last.Flagged {
+		t.Fatalf("expected an unflagged cluster of 5 comments from one user, got %+v", last)
+	}
+}
@@ -0,0 +1,282 @@
+package copypasta
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/settings"
+	"gorm.io/gorm"
+)
+
+// 簇的审核状态。
+const (
+	StatusOpen      = "open"      // 待审核，达到阈值后监控会自动举报新出现的成员
+	StatusQueued    = "queued"    // 已提交整簇举报，等待后台逐条举报
+	StatusReported  = "reported"  // 整簇举报已处理完成
+	StatusDismissed = "dismissed" // 审核认为不是刷屏，不再作为命中
+)
+
+// 簇成员的举报结果。
+const (
+	ReportSuccess = "success"
+	ReportFailed  = "failed"
+	ReportSkipped = "skipped" // 任务已删除、达到每日上限或已被单独举报
+)
+
+// MatchType 是近似重复合成规则的匹配类型，写入举报记录的 match_type。
+const MatchType = "near_duplicate"
+
+// RuleName 是近似重复合成规则的名称。
+const RuleName = "近似重复评论"
+
+// cleanupInterval 控制清理窗口外未成簇评论的频率。
+const cleanupInterval = time.Hour
+
+// Config 近似重复检测参数。
+type Config struct {
+	Window      time.Duration // 滑动窗口，只有发布时间在窗口内的评论参与聚类
+	MinCluster  int64         // 窗口内发布簇成员的不同评论者达到该数量才视为刷屏
+	MaxDistance int           // 指纹汉明距离不超过该值视为近似重复
+}
+
+// ConfigFromSettings 从系统设置读取检测参数。
+func ConfigFromSettings() Config {
+	return Config{
+		Window:      time.Duration(settings.GetInt("near_duplicate_window_hours", 24)) * time.Hour,
+		MinCluster:  int64(settings.GetInt("near_duplicate_min_cluster", 5)),
+		MaxDistance: settings.GetInt("near_duplicate_max_distance", 3),
+	}
+}
+
+// Result 是一条评论的检测结果。
+type Result struct {
+	Cluster    models.CommentCluster // 评论所属的簇，未成簇时 ID 为 0
+	WindowSize int64                 // 簇内发布时间在窗口内的评论数
+	Users      int64                 // 窗口内发布这些评论的不同评论者数
+	Flagged    bool                  // 窗口内不同评论者数达到阈值且簇未被驳回
+}
+
+// Match 把检测结果转为合成规则的命中，得分与默认权重的关键字规则相同。
+func (r Result) Match() rules.MatchResult {
+	return ClusterMatch(r.Cluster.ID, r.WindowSize)
+}
+
+// ClusterMatch 构造簇 clusterID 的合成命中。
+func ClusterMatch(clusterID uint, size int64) rules.MatchResult {
+	return rules.MatchResult{
+		RuleName:  RuleName,
+		MatchType: MatchType,
+		Matched:   fmt.Sprintf("簇 #%d，%d 条相似评论", clusterID, size),
+		Score:     1,
+		ClusterID: clusterID,
+	}
+}
+
+type entry struct {
+	id        uint
+	clusterID uint
+	fp        uint64
+	seenAt    time.Time
+}
+
+// Detector 在内存中维护窗口内评论的指纹索引，并把评论和簇持久化到数据库，
+// 服务重启后从数据库恢复索引。同一个 Detector 供所有任务共享，因此可以跨任务、跨视频聚类。
+type Detector struct {
+	mu          sync.Mutex
+	loaded      bool
+	loadedSince time.Time
+	entries     []entry
+	lastCleanup time.Time
+}
+
+// NewDetector 创建检测器，索引在第一次 Observe 时从数据库加载。
+func NewDetector() *Detector {
+	return &Detector{}
+}
+
+// Observe 记录一条评论并返回它所属簇的状态。comment 需填好评论和视频信息，
+// SeenAt 为评论发布时间，零值取 now。评论过短或发布时间已在窗口外时返回 false。
+func (d *Detector) Observe(db *gorm.DB, comment models.ClusterComment, cfg Config, now time.Time) (Result, bool, error) {
+	fp, ok := Fingerprint(comment.Content)
+	if !ok {
+		return Result{}, false, nil
+	}
+	if comment.SeenAt.IsZero() || comment.SeenAt.After(now) {
+		comment.SeenAt = now
+	}
+	since := now.Add(-cfg.Window)
+	if comment.SeenAt.Before(since) {
+		return Result{}, false, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.load(db, since); err != nil {
+		return Result{}, false, err
+	}
+	d.prune(db, since, now)
+
+	// 每轮监控都会重复扫描到同一条评论，用 Find 避免未找到时的日志
+	var existing []models.ClusterComment
+	if err := db.Where("comment_id = ?", comment.CommentID).Limit(1).Find(&existing).Error; err != nil {
+		return Result{}, false, err
+	}
+	if len(existing) > 0 {
+		comment = existing[0]
+	} else {
+		comment.Fingerprint = FormatFingerprint(fp)
+		if err := d.insert(db, &comment, fp, cfg.MaxDistance); err != nil {
+			return Result{}, false, err
+		}
+	}
+
+	if comment.ClusterID == 0 {
+		return Result{}, true, nil
+	}
+	return evaluate(db, comment.ClusterID, since, cfg)
+}
+
+// insert 保存新评论；若窗口内有足够接近的评论，加入它的簇，对方尚未成簇时新建一个簇。
+func (d *Detector) insert(db *gorm.DB, comment *models.ClusterComment, fp uint64, maxDistance int) error {
+	neighbor := -1
+	best := maxDistance + 1
+	for i, e := range d.entries {
+		dist := Distance(fp, e.fp)
+		// 距离相同时优先加入已有簇
+		if dist < best || (dist == best && neighbor >= 0 && d.entries[neighbor].clusterID == 0 && e.clusterID != 0) {
+			neighbor, best = i, dist
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if neighbor >= 0 {
+			near := &d.entries[neighbor]
+			if near.clusterID == 0 {
+				var first models.ClusterComment
+				if err := tx.First(&first, near.id).Error; err != nil {
+					return err
+				}
+				cluster := models.CommentCluster{
+					Fingerprint: first.Fingerprint,
+					Sample:      first.Content,
+					Status:      StatusOpen,
+					FirstSeenAt: first.SeenAt,
+					LastSeenAt:  first.SeenAt,
+					Size:        1,
+				}
+				if err := tx.Create(&cluster).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&first).Update("cluster_id", cluster.ID).Error; err != nil {
+					return err
+				}
+				near.clusterID = cluster.ID
+			}
+			comment.ClusterID = near.clusterID
+		}
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if comment.ClusterID == 0 {
+			return nil
+		}
+		var cluster models.CommentCluster
+		if err := tx.First(&cluster, comment.ClusterID).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"size": gorm.Expr("size + ?", 1)}
+		if comment.SeenAt.After(cluster.LastSeenAt) {
+			updates["last_seen_at"] = comment.SeenAt
+		}
+		if comment.SeenAt.Before(cluster.FirstSeenAt) {
+			updates["first_seen_at"] = comment.SeenAt
+		}
+		return tx.Model(&cluster).Updates(updates).Error
+	})
+	if err != nil {
+		// 事务回滚后新建的簇不存在，重新加载索引以免内存与数据库不一致
+		d.loaded = false
+		return err
+	}
+	d.entries = append(d.entries, entry{id: comment.ID, clusterID: comment.ClusterID, fp: fp, seenAt: comment.SeenAt})
+	return nil
+}
+
+// load 在首次使用或窗口变大时从数据库加载窗口内的评论指纹。
+func (d *Detector) load(db *gorm.DB, since time.Time) error {
+	if d.loaded && !since.Before(d.loadedSince) {
+		return nil
+	}
+	var rows []models.ClusterComment
+	if err := db.Select("id", "cluster_id", "fingerprint", "seen_at").
+		Where("seen_at >= ?", since).Order("id ASC").Find(&rows).Error; err != nil {
+		return err
+	}
+	entries := make([]entry, 0, len(rows))
+	for _, row := range rows {
+		fp, err := ParseFingerprint(row.Fingerprint)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{id: row.ID, clusterID: row.ClusterID, fp: fp, seenAt: row.SeenAt})
+	}
+	d.entries = entries
+	d.loaded = true
+	d.loadedSince = since
+	return nil
+}
+
+// prune 从索引中移除窗口外的评论，并定期删除数据库中窗口外且未成簇的评论；
+// 已成簇的评论保留，供审核和整簇举报使用。
+func (d *Detector) prune(db *gorm.DB, since, now time.Time) {
+	kept := d.entries[:0]
+	for _, e := range d.entries {
+		if !e.seenAt.Before(since) {
+			kept = append(kept, e)
+		}
+	}
+	d.entries = kept
+	d.loadedSince = since
+	if now.Sub(d.lastCleanup) < cleanupInterval {
+		return
+	}
+	d.lastCleanup = now
+	db.Where("cluster_id = ? AND seen_at < ?", 0, since).Delete(&models.ClusterComment{})
+}
+
+func evaluate(db *gorm.DB, clusterID uint, since time.Time, cfg Config) (Result, bool, error) {
+	var cluster models.CommentCluster
+	if err := db.First(&cluster, clusterID).Error; err != nil {
+		return Result{}, false, err
+	}
+	// 同一账号反复粘贴由刷屏规则处理，成簇阈值只看有多少不同账号发布了相似评论
+	var counts struct {
+		Size  int64
+		Users int64
+	}
+	if err := db.Model(&models.ClusterComment{}).
+		Select("COUNT(*) AS size, COUNT(DISTINCT comment_user_id) AS users").
+		Where("cluster_id = ? AND seen_at >= ?", clusterID, since).Scan(&counts).Error; err != nil {
+		return Result{}, false, err
+	}
+	return Result{
+		Cluster:    cluster,
+		WindowSize: counts.Size,
+		Users:      counts.Users,
+		Flagged:    counts.Users >= cfg.MinCluster && cluster.Status != StatusDismissed,
+	}, true, nil
+}
+
+// FormatFingerprint 把指纹格式化为 16 位十六进制字符串。
+func FormatFingerprint(fp uint64) string {
+	return fmt.Sprintf("%016x", fp)
+}
+
+// ParseFingerprint 解析 FormatFingerprint 的结果。
+func ParseFingerprint(value string) (uint64, error) {
+	return strconv.ParseUint(value, 16, 64)
+}
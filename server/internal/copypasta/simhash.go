@@ -0,0 +1,55 @@
+// Package copypasta 检测多个账号发布的近似重复评论（复制粘贴刷屏）：
+// 对评论文本做 SimHash 指纹，在滑动时间窗口内跨视频、跨任务聚类，簇达到阈值后作为合成规则命中。
+package copypasta
+
+import (
+	"hash/fnv"
+	"math/bits"
+
+	"github.com/spiritlhl/goban/internal/rules"
+)
+
+// 指纹参数：按 3 字滑窗切分，归一化后少于 MinTextLength 个字的评论不参与检测，
+// 避免“哈哈哈”“前排”这类短评论被聚成大簇。
+const (
+	ShingleSize   = 3
+	MinTextLength = 12
+)
+
+// Canonical 返回用于指纹的规范化文本，与 rules.Canonicalize 相同。
+func Canonical(text string) string {
+	return rules.Canonicalize(text)
+}
+
+// Fingerprint 计算评论的 64 位 SimHash，文本过短时返回 false。
+func Fingerprint(text string) (uint64, bool) {
+	runes := []rune(Canonical(text))
+	if len(runes) < MinTextLength {
+		return 0, false
+	}
+	var weights [64]int
+	for i := 0; i+ShingleSize <= len(runes); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(string(runes[i : i+ShingleSize])))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var fp uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			fp |= 1 << uint(bit)
+		}
+	}
+	return fp, true
+}
+
+// Distance 返回两个指纹的汉明距离。
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
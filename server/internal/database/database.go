@@ -8,6 +8,8 @@ import (
 	"github.com/glebarez/sqlite"
 	"github.com/spiritlhl/goban/internal/config"
 	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+	"github.com/spiritlhl/goban/internal/telemetry"
 	"gorm.io/gorm"
 )
 
@@ -24,6 +26,9 @@ func InitDB() error {
 	if err != nil {
 		return err
 	}
+	if err := db.Use(telemetry.NewGormPlugin()); err != nil {
+		return fmt.Errorf("注册数据库追踪插件失败: %w", err)
+	}
 	if sqlDB, err := db.DB(); err == nil {
 		sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
 		sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
@@ -41,13 +46,29 @@ func InitDB() error {
 		&models.MonitorTask{},
 		&models.MonitorTarget{},
 		&models.KeywordRule{},
+		&models.KeywordRuleRevision{},
+		&models.RuleSet{},
 		&models.WhitelistUser{},
+		&models.WatchlistUser{},
+		&models.WatchlistNotice{},
 		&models.AppSetting{},
 		&models.MonitorLog{},
 		&models.ReportRecord{},
+		&models.RuleDailyStat{},
+		&models.RuleStatComment{},
+		&models.CommentCluster{},
+		&models.ClusterComment{},
+		&models.ClassifierModel{},
+		&models.ClassifierSample{},
 	); err != nil {
 		return err
 	}
+	if err := migrateTaskRuleSets(db); err != nil {
+		return err
+	}
+	if err := rulerev.Backfill(db); err != nil {
+		return err
+	}
 
 	return seedDefaultSettings(db)
 }
@@ -58,21 +79,27 @@ func GetDB() *gorm.DB {
 
 func seedDefaultSettings(db *gorm.DB) error {
 	defaults := map[string]string{
-		"default_video_count":        "5",
-		"default_comment_count":      "50",
-		"default_interval":           "300",
-		"default_report_delay":       "30",
-		"default_daily_report_limit": "100",
-		"default_max_retries":        "3",
-		"default_retry_interval":     "2",
-		"cookie_check_interval":      "3600",
-		"cookie_refresh_interval":    "21600",
-		"log_dedupe_window_seconds":  "300",
-		"risk_backoff_base_seconds":  "1800",
-		"risk_backoff_max_seconds":   "86400",
-		"webhook_enabled":            "false",
-		"webhook_type":               "none",
-		"webhook_timeout":            "8",
+		"default_video_count":         "5",
+		"default_comment_count":       "50",
+		"default_interval":            "300",
+		"default_report_delay":        "30",
+		"default_daily_report_limit":  "100",
+		"default_max_retries":         "3",
+		"default_retry_interval":      "2",
+		"cookie_check_interval":       "3600",
+		"cookie_refresh_interval":     "21600",
+		"log_dedupe_window_seconds":   "300",
+		"risk_backoff_base_seconds":   "1800",
+		"risk_backoff_max_seconds":    "86400",
+		"removal_check_delay_seconds": "86400",
+		"near_duplicate_window_hours": "24",
+		"near_duplicate_min_cluster":  "5",
+		"near_duplicate_max_distance": "3",
+		"watchlist_auto_threshold":    "5",
+		"watchlist_auto_mode":         "notify_only",
+		"webhook_enabled":             "false",
+		"webhook_type":                "none",
+		"webhook_timeout":             "8",
 	}
 
 	for key, value := range defaults {
@@ -44,3 +44,97 @@ func TestInitDBMigratesOperationalStateAndDefaults(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrateTaskRuleSetsConvertsLegacyRuleIDs(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := GetDB()
+	if db.Migrator().HasColumn(&models.MonitorTask{}, legacyRuleIDsColumn) {
+		t.Fatalf("fresh database should not have legacy column")
+	}
+	if err := db.Exec("ALTER TABLE `monitor_tasks` ADD COLUMN `keyword_rule_ids` text").Error; err != nil {
+		t.Fatalf("add legacy column: %v", err)
+	}
+
+	ruleRows := []models.KeywordRule{{Name: "a", Pattern: "a"}, {Name: "b", Pattern: "b"}, {Name: "c", Pattern: "c"}, {Name: "d", Pattern: "d"}}
+	if err := db.Create(&ruleRows).Error; err != nil {
+		t.Fatalf("create rules: %v", err)
+	}
+	if err := db.Model(&ruleRows[3]).Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable rule: %v", err)
+	}
+	tasks := []models.MonitorTask{{Name: "one"}, {Name: "two"}, {Name: "all"}}
+	if err := db.Create(&tasks).Error; err != nil {
+		t.Fatalf("create tasks: %v", err)
+	}
+	legacy := map[uint]string{tasks[0].ID: "1,2", tasks[1].ID: "2, 1", tasks[2].ID: ""}
+	for id, value := range legacy {
+		if err := db.Exec("UPDATE monitor_tasks SET keyword_rule_ids = ? WHERE id = ?", value, id).Error; err != nil {
+			t.Fatalf("set legacy ids: %v", err)
+		}
+	}
+
+	if err := migrateTaskRuleSets(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if db.Migrator().HasColumn(&models.MonitorTask{}, legacyRuleIDsColumn) {
+		t.Fatalf("legacy column should be dropped after migration")
+	}
+
+	var sets []models.RuleSet
+	if err := db.Preload("Rules").Order("id ASC").Find(&sets).Error; err != nil {
+		t.Fatalf("load sets: %v", err)
+	}
+	if len(sets) != 2 || len(sets[0].Rules) != 2 {
+		t.Fatalf("tasks with the same rule ids should share one set of two rules, got %+v", sets)
+	}
+	// 未指定规则的任务关联到迁移时全部启用规则的快照，之后新增的规则不会自动加入
+	if len(sets[1].Rules) != 3 {
+		t.Fatalf("task with empty rule ids should get a set of the 3 enabled rules, got %+v", sets[1])
+	}
+	for i, want := range []uint{sets[0].ID, sets[0].ID, sets[1].ID} {
+		var task models.MonitorTask
+		if err := db.Preload("RuleSets").First(&task, tasks[i].ID).Error; err != nil {
+			t.Fatalf("load task: %v", err)
+		}
+		if len(task.RuleSets) != 1 || task.RuleSets[0].ID != want {
+			t.Fatalf("task %s has rule sets %+v, want only #%d", task.Name, task.RuleSets, want)
+		}
+	}
+
+	if err := migrateTaskRuleSets(db); err != nil {
+		t.Fatalf("second migration should be a no-op: %v", err)
+	}
+
+	// 模拟上次删除旧列失败：旧列仍在时再次迁移应复用已有规则集
+	if err := db.Exec("ALTER TABLE `monitor_tasks` ADD COLUMN `keyword_rule_ids` text").Error; err != nil {
+		t.Fatalf("re-add legacy column: %v", err)
+	}
+	for id, value := range legacy {
+		if err := db.Exec("UPDATE monitor_tasks SET keyword_rule_ids = ? WHERE id = ?", value, id).Error; err != nil {
+			t.Fatalf("set legacy ids: %v", err)
+		}
+	}
+	if err := migrateTaskRuleSets(db); err != nil {
+		t.Fatalf("migration with leftover legacy column: %v", err)
+	}
+	var count int64
+	if err := db.Model(&models.RuleSet{}).Count(&count).Error; err != nil || count != 2 {
+		t.Fatalf("rerun should reuse existing sets, got %d (%v)", count, err)
+	}
+	for i, want := range []uint{sets[0].ID, sets[0].ID, sets[1].ID} {
+		var task models.MonitorTask
+		if err := db.Preload("RuleSets").First(&task, tasks[i].ID).Error; err != nil {
+			t.Fatalf("load task: %v", err)
+		}
+		if len(task.RuleSets) != 1 || task.RuleSets[0].ID != want {
+			t.Fatalf("after rerun task %s has rule sets %+v, want only #%d", task.Name, task.RuleSets, want)
+		}
+	}
+}
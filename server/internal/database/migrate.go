@@ -0,0 +1,103 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"gorm.io/gorm"
+)
+
+// legacyRuleIDsColumn 是旧版任务直接保存规则ID的列，逗号分隔。
+const legacyRuleIDsColumn = "keyword_rule_ids"
+
+type legacyTaskRules struct {
+	ID             uint
+	Name           string
+	KeywordRuleIDs string
+}
+
+// migrateTaskRuleSets 把旧版任务的规则ID列表迁移为规则集。
+// 规则ID完全相同的任务共用一个规则集；迁移完成后删除旧列，重复启动不会再次迁移。
+// 旧列为空的任务原本使用所有启用规则，迁移后关联到迁移时全部启用规则组成的规则集，
+// 之后新增的规则需要手动加入规则集才会生效。
+// 删除旧列不在迁移事务内，删除失败时下次启动会重新迁移，已存在的同名规则集会被复用。
+func migrateTaskRuleSets(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.MonitorTask{}, legacyRuleIDsColumn) {
+		return nil
+	}
+	var legacy []legacyTaskRules
+	if err := db.Table("monitor_tasks").Select("id, name, COALESCE(" + legacyRuleIDsColumn + ", '') AS keyword_rule_ids").
+		Order("id ASC").Scan(&legacy).Error; err != nil {
+		return fmt.Errorf("读取旧版任务规则失败: %w", err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		sets := map[string]*models.RuleSet{}
+		var allRules *models.RuleSet
+		for _, task := range legacy {
+			ids := rules.ParseRuleIDs(task.KeywordRuleIDs)
+			if len(ids) == 0 {
+				if allRules == nil {
+					var members []models.KeywordRule
+					if err := tx.Where("enabled = ?", true).Order("created_at ASC").Find(&members).Error; err != nil {
+						return err
+					}
+					allRules = &models.RuleSet{
+						Name:        "迁移时的全部启用规则",
+						Description: "由未指定规则的旧版任务自动迁移，包含迁移时所有启用的规则",
+						Rules:       members,
+					}
+					if err := firstOrCreateRuleSet(tx, allRules); err != nil {
+						return err
+					}
+				}
+				if err := tx.Model(&models.MonitorTask{ID: task.ID}).Association("RuleSets").Append(allRules); err != nil {
+					return err
+				}
+				continue
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			key := rules.FormatRuleIDs(ids)
+			set := sets[key]
+			if set == nil {
+				var members []models.KeywordRule
+				if err := tx.Where("id IN ?", ids).Order("created_at ASC").Find(&members).Error; err != nil {
+					return err
+				}
+				set = &models.RuleSet{
+					Name:        fmt.Sprintf("任务 #%d %s 的规则", task.ID, task.Name),
+					Description: "由任务原有的规则列表自动迁移: " + key,
+					Rules:       members,
+				}
+				if err := firstOrCreateRuleSet(tx, set); err != nil {
+					return err
+				}
+				sets[key] = set
+			}
+			if err := tx.Model(&models.MonitorTask{ID: task.ID}).Association("RuleSets").Append(set); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("迁移任务规则集失败: %w", err)
+	}
+	return db.Migrator().DropColumn(&models.MonitorTask{}, legacyRuleIDsColumn)
+}
+
+// firstOrCreateRuleSet 按名称复用已有规则集，不存在时才创建，保证迁移可以重复执行。
+func firstOrCreateRuleSet(tx *gorm.DB, set *models.RuleSet) error {
+	var existing models.RuleSet
+	err := tx.Where("name = ?", set.Name).Limit(1).Find(&existing).Error
+	if err != nil {
+		return err
+	}
+	if existing.ID != 0 {
+		*set = existing
+		return nil
+	}
+	return tx.Create(set).Error
+}
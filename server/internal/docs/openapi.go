@@ -68,7 +68,7 @@ const OpenAPIJSON = `{
           "video_count": { "type": "integer" },
           "comment_count": { "type": "integer" },
           "keywords": { "type": "string" },
-          "keyword_rule_ids": { "type": "string" },
+          "rule_sets": { "type": "array", "items": { "$ref": "#/components/schemas/RuleSet" }, "description": "Rule sets whose enabled rules the task uses; with no sets the task only uses its ad-hoc keywords. Write with rule_set_ids" },
           "enabled": { "type": "boolean" },
           "interval": { "type": "integer" },
           "report_delay": { "type": "integer" },
@@ -76,6 +76,12 @@ const OpenAPIJSON = `{
           "max_retries": { "type": "integer" },
           "retry_interval": { "type": "integer" },
           "proxy_url": { "type": "string" },
+          "score_threshold": { "type": "number", "minimum": 0, "maximum": 1000, "description": "Report only when the summed score of all matched rules reaches this value; 0 reports on any match" },
+          "near_duplicate": { "type": "boolean", "description": "Fingerprint comments for near-duplicate (copypasta) detection; comments in a cluster posted by at least near_duplicate_min_cluster distinct commenters match the synthetic rule with score 1" },
+          "classifier_threshold": { "type": "number", "description": "0 disables the classifier; otherwise 0.5-0.99. Comments whose spam probability under the active classifier model reaches this value match the synthetic classifier rule with score 1" },
+          "skip_owner_comments": { "type": "boolean", "description": "Skip comments posted by the UP who owns the video" },
+          "skip_up_interacted": { "type": "boolean", "description": "Skip comments the UP liked or replied to (up_action in the reply payload)" },
+          "skip_fans_medal_level": { "type": "integer", "minimum": 0, "maximum": 40, "description": "Skip commenters wearing the UP's fan medal at this level or above; 0 disables. Skipped comments count as whitelist skips in rule statistics" },
           "last_status": { "type": "string" },
           "last_error": { "type": "string" },
           "next_run_at": { "type": "string", "format": "date-time", "nullable": true },
@@ -111,22 +117,106 @@ const OpenAPIJSON = `{
           "id": { "type": "integer" },
           "name": { "type": "string" },
           "pattern": { "type": "string" },
-          "match_type": { "type": "string", "enum": ["plain", "regex"] },
-          "match_logic": { "type": "string", "enum": ["single", "or", "and"] },
+          "match_type": { "type": "string", "enum": ["plain", "regex", "pinyin", "expression", "flood", "blocklist"], "description": "expression: boolean expression with AND/OR/NOT, parentheses, quoted phrases, re:/.../ literals and NEAR/n; flood: per-commenter activity across all monitored targets, pattern 'comments|repeats|videos >= N within 10m' (window 1m-24h), never matches text alone and does not accept must_match examples; blocklist: extracts links (including b23.tv short links and spaced or 点-separated domains), QQ numbers, WeChat IDs and phone numbers and matches them against comma/newline separated entries (domain, qq:, wechat:, phone:, * as wildcard), recording the extracted entity as matched_keyword" },
+          "match_logic": { "type": "string", "enum": ["single", "or", "and"], "description": "Ignored for expression, flood and blocklist rules" },
           "case_sensitive": { "type": "boolean" },
+          "homophone": { "type": "boolean", "description": "Pinyin rules only: treat near homophones (zh/z, n/l, ang/an...) as equal" },
+          "normalize": { "type": "string", "description": "Comma-separated normalization steps applied before matching: invisible, nfkc, confusables, t2s, emoji", "example": "invisible,nfkc,t2s" },
+          "max_gap": { "type": "integer", "minimum": 0, "maximum": 10, "description": "Plain rules only: noise characters allowed between adjacent term characters; hits then report the original comment substring" },
+          "gap_noise": { "type": "string", "description": "Comma-separated noise classes skipped by max_gap: space, punct, emoji, invisible; empty means all" },
+          "weight": { "type": "number", "minimum": 0, "maximum": 100, "default": 1, "description": "Rule weight; a hit scores weight x sum of matched term weights. Plain and pinyin or/and terms accept a ^weight suffix, e.g. 日结^2" },
+          "conditions": { "type": "string", "description": "Commenter conditions that must all hold, separated by semicolons. Fields: level, uid, vip, vip_type, has_fans_medal, fans_medal_level, sex, has_pendant", "example": "level <= 2; has_fans_medal = false" },
+          "must_match": { "type": "string", "description": "Regression examples that must match, one per line (max 50, 500 characters each); create and update are rejected when any example fails" },
+          "must_not_match": { "type": "string", "description": "Regression examples that must not match, one per line" },
           "enabled": { "type": "boolean" },
           "description": { "type": "string" },
+          "active_from": { "type": "string", "format": "date-time", "nullable": true, "description": "The rule is skipped before this time" },
+          "active_until": { "type": "string", "format": "date-time", "nullable": true, "description": "Must be later than active_from; once passed, the rule is disabled automatically (within a minute) and an expire revision is recorded. Enabling a rule whose active_until has passed is rejected" },
+          "active_days": { "type": "string", "description": "Comma-separated ISO weekdays 1-7 (Monday-Sunday) on which the rule is active; ranges such as 1-5 are accepted; empty means every day", "example": "6,7" },
+          "active_hours": { "type": "string", "description": "Comma-separated HH:MM-HH:MM windows in server time; an end not after the start crosses midnight and belongs to the weekday it starts on; empty means all day", "example": "20:00-23:30" },
+          "revision": { "type": "integer", "readOnly": true, "description": "Current revision number; every create, content change, import and rollback adds one" },
           "last_matched_at": { "type": "string", "format": "date-time", "nullable": true }
         }
       },
+      "RuleSet": {
+        "type": "object",
+        "properties": {
+          "id": { "type": "integer" },
+          "name": { "type": "string" },
+          "description": { "type": "string" },
+          "rules": { "type": "array", "items": { "$ref": "#/components/schemas/KeywordRule" } },
+          "task_count": { "type": "integer", "format": "int64", "description": "Tasks referencing the set; only returned by the list endpoint" }
+        }
+      },
+      "RuleSetInput": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": { "type": "string", "maxLength": 80 },
+          "description": { "type": "string", "maxLength": 500 },
+          "rule_ids": { "type": "array", "items": { "type": "integer" }, "description": "Member keyword rules; a rule may belong to several sets" }
+        }
+      },
       "WhitelistUser": {
         "type": "object",
         "properties": {
           "id": { "type": "integer" },
           "uid": { "type": "integer", "format": "int64" },
           "uname": { "type": "string" },
+          "uname_match": { "type": "string", "enum": ["exact", "glob", "regex"], "default": "exact", "description": "exact compares case-insensitively; glob supports * and ? against the whole username (e.g. *官方*); regex matches anywhere in the username, case-insensitive. Patterns need uname, at most 200 characters, and may not match an empty username" },
           "remark": { "type": "string" },
-          "enabled": { "type": "boolean" }
+          "enabled": { "type": "boolean" },
+          "task_id": { "type": "integer", "description": "Only applies to this task; 0 applies to all tasks. Must reference an existing task" },
+          "target_uid": { "type": "integer", "format": "int64", "description": "Only applies to comments under this UP's videos; 0 applies to all targets. With task_id set it must be one of the task's targets" },
+          "expires_at": { "type": "string", "format": "date-time", "nullable": true, "description": "Entry stops applying at this time; a newly set value must be in the future" }
+        }
+      },
+      "WatchlistUser": {
+        "type": "object",
+        "description": "Known offender whose comments on monitored videos are handled more strictly. Whitelist entries still take precedence; watched commenters are never skipped by the task's trusted-comment options",
+        "properties": {
+          "id": { "type": "integer" },
+          "uid": { "type": "integer", "format": "int64", "description": "Required and unique" },
+          "uname": { "type": "string" },
+          "reason": { "type": "string", "maxLength": 200 },
+          "mode": { "type": "string", "enum": ["always_report", "extra_rules", "notify_only"], "default": "notify_only", "description": "always_report reports every comment regardless of rules and score threshold; extra_rules also matches the rules of rule_set_id; notify_only keeps normal matching and sends one webhook per comment posted after the entry was created" },
+          "rule_set_id": { "type": "integer", "description": "Required for extra_rules, cleared for other modes" },
+          "enabled": { "type": "boolean" },
+          "source": { "type": "string", "enum": ["manual", "auto"], "readOnly": true, "description": "auto entries are added with the watchlist_auto_mode setting (always_report or notify_only) once a commenter reaches watchlist_auto_threshold successful reports; 0 disables auto promotion" },
+          "report_count": { "type": "integer", "readOnly": true, "description": "Successful reports when the entry was added automatically" },
+          "last_comment_at": { "type": "string", "format": "date-time", "nullable": true, "readOnly": true, "description": "Publish time of the newest comment seen on a monitored video" }
+        }
+      },
+      "KeywordRuleRevision": {
+        "type": "object",
+        "description": "Immutable snapshot of a keyword rule",
+        "properties": {
+          "id": { "type": "integer" },
+          "created_at": { "type": "string", "format": "date-time" },
+          "rule_id": { "type": "integer" },
+          "revision": { "type": "integer" },
+          "action": { "type": "string", "enum": ["create", "update", "import", "rollback", "baseline", "expire"], "description": "baseline is created on upgrade for rules without history; expire is recorded when a rule is disabled after active_until" },
+          "author": { "type": "string", "description": "Admin username that made the change" },
+          "restored_from": { "type": "integer", "description": "Revision restored by a rollback" },
+          "name": { "type": "string" },
+          "pattern": { "type": "string" },
+          "match_type": { "type": "string" },
+          "match_logic": { "type": "string" },
+          "case_sensitive": { "type": "boolean" },
+          "homophone": { "type": "boolean" },
+          "normalize": { "type": "string" },
+          "max_gap": { "type": "integer" },
+          "gap_noise": { "type": "string" },
+          "weight": { "type": "number" },
+          "conditions": { "type": "string" },
+          "must_match": { "type": "string" },
+          "must_not_match": { "type": "string" },
+          "enabled": { "type": "boolean" },
+          "description": { "type": "string" },
+          "active_from": { "type": "string", "format": "date-time", "nullable": true },
+          "active_until": { "type": "string", "format": "date-time", "nullable": true },
+          "active_days": { "type": "string" },
+          "active_hours": { "type": "string" }
         }
       },
       "ReportRecord": {
@@ -143,8 +233,101 @@ const OpenAPIJSON = `{
           "comment_user": { "type": "string" },
           "matched_keyword": { "type": "string" },
           "keyword_rule_name": { "type": "string" },
+          "keyword_rule_revision": { "type": "integer", "description": "Revision of the primary rule when the comment matched; look it up with /api/keywords/{id}/revisions/{revision}" },
+          "score": { "type": "number", "description": "Summed score of all matched rules" },
+          "matched_rules": { "type": "string", "description": "JSON array of contributing rules: rule_id, rule_name, match_type, revision, matched, score" },
           "success": { "type": "boolean" },
-          "message": { "type": "string" }
+          "message": { "type": "string" },
+          "removal_status": { "type": "string", "enum": ["", "removed", "kept", "error"], "description": "Follow-up check of successful reports after removal_check_delay_seconds: empty while pending, error after repeated failed checks" },
+          "removal_check_at": { "type": "string", "format": "date-time", "nullable": true, "description": "Time of the check, or of the latest failed attempt" },
+          "removal_attempts": { "type": "integer", "description": "Number of failed follow-up checks" },
+          "cluster_id": { "type": "integer", "nullable": true, "description": "Near-duplicate cluster when the report matched the synthetic near_duplicate rule" },
+          "evidence": { "type": "string", "description": "JSON array of flood rule evidence: rule_id, rule_name and the commenter's related comments (rpid, aid, bvid, target_uid, message, at)" },
+          "match_spans": { "type": "string", "description": "JSON array of match explanations: rule_id, rule_name, clause, steps and spans (start, end, rune_start, rune_end, text, term, group) locating each hit in comment_content" }
+        }
+      },
+      "ClassifierModel": {
+        "type": "object",
+        "properties": {
+          "id": { "type": "integer" },
+          "created_at": { "type": "string", "format": "date-time" },
+          "active": { "type": "boolean", "description": "Model used by tasks with classifier_threshold > 0; at most one model is active" },
+          "ngram": { "type": "integer", "minimum": 1, "maximum": 3 },
+          "train_samples": { "type": "integer" },
+          "test_samples": { "type": "integer" },
+          "spam_samples": { "type": "integer" },
+          "ham_samples": { "type": "integer" },
+          "features": { "type": "integer" },
+          "holdout": { "type": "integer", "description": "Held-out percentage used for evaluation" },
+          "precision": { "type": "number" },
+          "recall": { "type": "number" },
+          "f1": { "type": "number" },
+          "accuracy": { "type": "number" },
+          "created_by": { "type": "string" }
+        }
+      },
+      "ClassifierSample": {
+        "type": "object",
+        "properties": {
+          "id": { "type": "integer" },
+          "created_at": { "type": "string", "format": "date-time" },
+          "content": { "type": "string" },
+          "label": { "type": "string", "enum": ["spam", "ham"] },
+          "report_id": { "type": "integer", "nullable": true },
+          "created_by": { "type": "string" }
+        }
+      },
+      "ClassifierMetrics": {
+        "type": "object",
+        "description": "Held-out evaluation at one threshold; spam is the positive class",
+        "properties": {
+          "threshold": { "type": "number" },
+          "samples": { "type": "integer" },
+          "skipped": { "type": "integer", "description": "Samples without usable features" },
+          "true_positives": { "type": "integer" },
+          "false_positives": { "type": "integer" },
+          "true_negatives": { "type": "integer" },
+          "false_negatives": { "type": "integer" },
+          "precision": { "type": "number" },
+          "recall": { "type": "number" },
+          "f1": { "type": "number" },
+          "accuracy": { "type": "number" }
+        }
+      },
+      "CommentCluster": {
+        "type": "object",
+        "properties": {
+          "id": { "type": "integer" },
+          "fingerprint": { "type": "string", "description": "64-bit SimHash of the first comment, hex encoded" },
+          "sample": { "type": "string" },
+          "size": { "type": "integer", "format": "int64" },
+          "status": { "type": "string", "enum": ["open", "queued", "reported", "dismissed"] },
+          "first_seen_at": { "type": "string", "format": "date-time" },
+          "last_seen_at": { "type": "string", "format": "date-time" },
+          "users": { "type": "integer", "description": "Distinct commenters; list endpoint only" },
+          "videos": { "type": "integer", "description": "Distinct videos; list endpoint only" },
+          "reported": { "type": "integer", "description": "Members reported successfully; list endpoint only" },
+          "comments": { "type": "array", "items": { "$ref": "#/components/schemas/ClusterComment" } }
+        }
+      },
+      "ClusterComment": {
+        "type": "object",
+        "properties": {
+          "id": { "type": "integer" },
+          "cluster_id": { "type": "integer" },
+          "task_id": { "type": "integer" },
+          "target_uid": { "type": "integer", "format": "int64" },
+          "target_uname": { "type": "string" },
+          "avid": { "type": "integer", "format": "int64" },
+          "bvid": { "type": "string" },
+          "video_title": { "type": "string" },
+          "comment_id": { "type": "integer", "format": "int64" },
+          "comment_user": { "type": "string" },
+          "comment_user_id": { "type": "integer", "format": "int64" },
+          "content": { "type": "string" },
+          "fingerprint": { "type": "string" },
+          "seen_at": { "type": "string", "format": "date-time", "description": "Comment publish time used for the sliding window" },
+          "report_status": { "type": "string", "enum": ["", "success", "failed", "skipped"] }
         }
       },
       "MonitorLog": {
@@ -194,6 +377,14 @@ const OpenAPIJSON = `{
           }
         }
       },
+      "NotFound": {
+        "description": "Resource not found",
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/ErrorResponse" }
+          }
+        }
+      },
       "Unauthorized": {
         "description": "Basic authentication required"
       },
@@ -364,6 +555,14 @@ const OpenAPIJSON = `{
         "responses": { "200": { "description": "Preview matches and compile errors" } }
       }
     },
+    "/api/tasks/{id}/rules": {
+      "get": {
+        "summary": "Get the effective rules of a monitor task",
+        "tags": ["Tasks"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "task_id, rule_sets, rules (enabled members of all linked sets, deduplicated), inactive_rule_ids (enabled rules currently outside their active_from/active_until, weekday or time window and therefore skipped), ad_hoc_keywords and compile_errors" }, "404": { "description": "Task not found" } }
+      }
+    },
     "/api/keywords/list": {
       "get": {
         "summary": "List keyword rules",
@@ -382,7 +581,53 @@ const OpenAPIJSON = `{
       "post": {
         "summary": "Preview keyword matching",
         "tags": ["Keywords"],
-        "responses": { "200": { "description": "Match preview" } }
+        "responses": { "200": { "description": "Match preview; commenter conditions are checked only when the optional commenter object (uid, level, vip, vip_type, has_fans_medal, fans_medal_level, sex, has_pendant) is sent. Includes per-rule score, total score and reaches_threshold for the optional threshold field; normalization explains pinyin hits (original, pinyin, initials, homophone) and gap-skipping hits (gap); entities lists links and contacts extracted from the text (kind, value, domain); each match also carries clause (the condition that fired), steps (normalization applied: invisible, nfkc, confusables, t2s, emoji, width, lowercase, extract) and spans (start/end byte offsets, rune_start/rune_end character offsets, text, term, regex group) in the original text" }, "400": { "description": "Invalid draft rule; expression parse errors include the 1-based character column" } }
+      }
+    },
+    "/api/keywords/tests": {
+      "get": {
+        "summary": "Run the regression examples of all keyword rules",
+        "tags": ["Keywords"],
+        "responses": { "200": { "description": "total, passed, failed and untested counts plus per-rule results (rule_id, rule_name, enabled, passed, cases, failures with text/expect/matched, compile error)" } }
+      }
+    },
+    "/api/keywords/stats": {
+      "get": {
+        "summary": "Per-rule daily counters and the noisiest rules",
+        "tags": ["Keywords"],
+        "parameters": [
+          { "name": "days", "in": "query", "schema": { "type": "integer", "minimum": 1, "maximum": 180, "default": 30 } },
+          { "name": "rule_id", "in": "query", "schema": { "type": "integer" }, "description": "Only return this rule" },
+          { "name": "limit", "in": "query", "schema": { "type": "integer", "minimum": 1, "maximum": 100, "default": 10 }, "description": "Size of top_noisy" }
+        ],
+        "responses": { "200": { "description": "from, to, series (per rule: totals and zero-filled daily matches, reports_attempted, reports_succeeded, confirmed_removed, review_rejected, whitelist_skips) and top_noisy ranked by false_positive_rate = (review_rejected + failed reports) / reports_attempted" }, "400": { "$ref": "#/components/responses/BadRequest" } }
+      }
+    },
+    "/api/keywords/import": {
+      "post": {
+        "summary": "Bulk import keyword rules",
+        "tags": ["Keywords"],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "type": "object", "required": ["content"], "properties": {
+            "format": { "type": "string", "enum": ["json", "yaml", "wordlist"], "default": "json", "description": "json/yaml accept {\"rules\": [...]} or a bare array with any KeywordRule fields; wordlist maps each non-empty line to a plain rule and skips lines starting with #" },
+            "content": { "type": "string", "description": "File content, at most 2 MB and 5000 rules" },
+            "policy": { "type": "string", "enum": ["skip", "overwrite", "rename"], "default": "skip", "description": "What to do with rules whose name exists with different content" },
+            "dry_run": { "type": "boolean", "description": "Only return the diff without writing" }
+          } } } }
+        },
+        "responses": { "200": { "description": "summary (new, changed, duplicate, invalid) and per-rule items with status, action (create/update/skip), changed fields, rename target and validation error; non dry runs also return created, updated and skipped counts" }, "400": { "$ref": "#/components/responses/BadRequest" } }
+      }
+    },
+    "/api/keywords/export": {
+      "get": {
+        "summary": "Export keyword rules",
+        "tags": ["Keywords"],
+        "parameters": [
+          { "name": "format", "in": "query", "schema": { "type": "string", "enum": ["json", "yaml", "wordlist"], "default": "json" } },
+          { "name": "rule_set_id", "in": "query", "schema": { "type": "integer" }, "description": "Only export members of this rule set" }
+        ],
+        "responses": { "200": { "description": "Rule file download. Wordlists only contain enabled plain single rules without extra options; X-Skipped-Rules reports how many were left out" } }
       }
     },
     "/api/keywords/{id}": {
@@ -399,10 +644,183 @@ const OpenAPIJSON = `{
         "responses": { "200": { "description": "Delete result" } }
       }
     },
+    "/api/keywords/{id}/revisions": {
+      "get": {
+        "summary": "List the revision history of a keyword rule",
+        "tags": ["Keywords"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "rule_id, current revision, deleted flag and revisions newest first; revisions are kept after the rule is deleted", "content": { "application/json": { "schema": { "type": "object", "properties": { "revisions": { "type": "array", "items": { "$ref": "#/components/schemas/KeywordRuleRevision" } } } } } } }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/keywords/{id}/revisions/{revision}": {
+      "get": {
+        "summary": "Get one revision of a keyword rule",
+        "tags": ["Keywords"],
+        "parameters": [
+          { "$ref": "#/components/parameters/ID" },
+          { "name": "revision", "in": "path", "required": true, "schema": { "type": "integer" } }
+        ],
+        "responses": { "200": { "description": "Revision", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/KeywordRuleRevision" } } } }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/keywords/{id}/diff": {
+      "get": {
+        "summary": "Diff two revisions of a keyword rule",
+        "tags": ["Keywords"],
+        "parameters": [
+          { "$ref": "#/components/parameters/ID" },
+          { "name": "from", "in": "query", "required": true, "schema": { "type": "integer" } },
+          { "name": "to", "in": "query", "schema": { "type": "integer" }, "description": "Defaults to the current rule content" }
+        ],
+        "responses": { "200": { "description": "from, to and changes as field/from/to triples" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/keywords/{id}/rollback": {
+      "post": {
+        "summary": "Restore a keyword rule to an earlier revision",
+        "tags": ["Keywords"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "requestBody": { "required": true, "content": { "application/json": { "schema": { "type": "object", "required": ["revision"], "properties": { "revision": { "type": "integer" }, "restore_enabled": { "type": "boolean", "default": false, "description": "Also restore the revision's enabled flag; by default the rule keeps its current enabled state" } } } } } },
+        "responses": { "200": { "description": "Restored rule; the rollback is recorded as a new revision and must still compile and pass its examples" }, "400": { "$ref": "#/components/responses/BadRequest" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/rule-sets/list": {
+      "get": {
+        "summary": "List rule sets with member rules",
+        "tags": ["RuleSets"],
+        "responses": { "200": { "description": "Rule set list", "content": { "application/json": { "schema": { "type": "array", "items": { "$ref": "#/components/schemas/RuleSet" } } } } } }
+      }
+    },
+    "/api/rule-sets/create": {
+      "post": {
+        "summary": "Create rule set",
+        "tags": ["RuleSets"],
+        "requestBody": { "required": true, "content": { "application/json": { "schema": { "$ref": "#/components/schemas/RuleSetInput" } } } },
+        "responses": { "200": { "description": "Created rule set" }, "400": { "$ref": "#/components/responses/BadRequest" } }
+      }
+    },
+    "/api/rule-sets/{id}": {
+      "put": {
+        "summary": "Update rule set and replace its members",
+        "tags": ["RuleSets"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "requestBody": { "required": true, "content": { "application/json": { "schema": { "$ref": "#/components/schemas/RuleSetInput" } } } },
+        "responses": { "200": { "description": "Updated rule set" }, "400": { "$ref": "#/components/responses/BadRequest" } }
+      },
+      "delete": {
+        "summary": "Delete rule set",
+        "tags": ["RuleSets"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Delete result" }, "409": { "description": "Rule set is still referenced by tasks or watchlist entries" } }
+      }
+    },
+    "/api/clusters/list": {
+      "get": {
+        "summary": "List near-duplicate comment clusters",
+        "tags": ["Clusters"],
+        "parameters": [
+          { "$ref": "#/components/parameters/Page" },
+          { "$ref": "#/components/parameters/PageSize" },
+          { "name": "status", "in": "query", "schema": { "type": "string", "enum": ["open", "queued", "reported", "dismissed"] } },
+          { "name": "min_size", "in": "query", "schema": { "type": "integer" } }
+        ],
+        "responses": { "200": { "description": "Paginated clusters ordered by last_seen_at", "content": { "application/json": { "schema": { "type": "object", "properties": { "total": { "type": "integer" }, "data": { "type": "array", "items": { "$ref": "#/components/schemas/CommentCluster" } } } } } } } }
+      }
+    },
+    "/api/clusters/{id}": {
+      "get": {
+        "summary": "Get a cluster with all member comments",
+        "tags": ["Clusters"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Cluster", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/CommentCluster" } } } }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/clusters/{id}/report": {
+      "post": {
+        "summary": "Queue every unreported member of the cluster for reporting",
+        "tags": ["Clusters"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Cluster queued; the monitor reports members in the background with the account of the task that saw each comment" }, "400": { "$ref": "#/components/responses/BadRequest" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/clusters/{id}/dismiss": {
+      "post": {
+        "summary": "Dismiss a cluster so its comments no longer match the near_duplicate rule",
+        "tags": ["Clusters"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Cluster dismissed" }, "400": { "$ref": "#/components/responses/BadRequest" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/classifier/models": {
+      "get": {
+        "summary": "List classifier models and count the training samples currently available",
+        "tags": ["Classifier"],
+        "responses": { "200": { "description": "models (newest first) and dataset (spam, ham and per-source counts: report, removed, kept, dismissed, manual)", "content": { "application/json": { "schema": { "type": "object", "properties": { "models": { "type": "array", "items": { "$ref": "#/components/schemas/ClassifierModel" } }, "dataset": { "type": "object" } } } } } } }
+      }
+    },
+    "/api/classifier/train": {
+      "post": {
+        "summary": "Retrain the classifier from report history and reviewer decisions",
+        "tags": ["Classifier"],
+        "requestBody": { "content": { "application/json": { "schema": { "type": "object", "properties": { "ngram": { "type": "integer", "minimum": 1, "maximum": 3, "default": 2 }, "holdout": { "type": "integer", "minimum": 5, "maximum": 50, "default": 20 }, "activate": { "type": "boolean", "default": true } } } } } },
+        "responses": { "200": { "description": "result: saved model, metrics at threshold 0.5, curve and dataset. Successful reports are spam unless the removal check found them kept; reports made only by content-free rules (classifier, watchlist always_report, flood) are skipped; comments of dismissed clusters are ham; manual samples take precedence" }, "400": { "$ref": "#/components/responses/BadRequest" }, "409": { "description": "Training already in progress" } }
+      }
+    },
+    "/api/classifier/evaluate": {
+      "post": {
+        "summary": "Evaluate precision and recall of a model on the held-out set",
+        "tags": ["Classifier"],
+        "requestBody": { "content": { "application/json": { "schema": { "type": "object", "properties": { "model_id": { "type": "integer", "description": "Defaults to the active model" }, "threshold": { "type": "number", "default": 0.5 }, "holdout": { "type": "integer", "description": "Defaults to the model's training holdout" } } } } } },
+        "responses": { "200": { "description": "model_id, holdout, metrics and curve", "content": { "application/json": { "schema": { "type": "object", "properties": { "metrics": { "$ref": "#/components/schemas/ClassifierMetrics" }, "curve": { "type": "array", "items": { "$ref": "#/components/schemas/ClassifierMetrics" } } } } } } }, "400": { "$ref": "#/components/responses/BadRequest" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/classifier/models/{id}/activate": {
+      "post": {
+        "summary": "Use a model for monitoring",
+        "tags": ["Classifier"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Model activated" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/classifier/models/{id}": {
+      "delete": {
+        "summary": "Delete an inactive model",
+        "tags": ["Classifier"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Delete result" }, "400": { "$ref": "#/components/responses/BadRequest" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/classifier/samples": {
+      "get": {
+        "summary": "List manually labelled samples",
+        "tags": ["Classifier"],
+        "parameters": [
+          { "$ref": "#/components/parameters/Page" },
+          { "$ref": "#/components/parameters/PageSize" },
+          { "name": "label", "in": "query", "schema": { "type": "string", "enum": ["spam", "ham"] } }
+        ],
+        "responses": { "200": { "description": "Paginated samples", "content": { "application/json": { "schema": { "type": "object", "properties": { "total": { "type": "integer" }, "data": { "type": "array", "items": { "$ref": "#/components/schemas/ClassifierSample" } } } } } } } }
+      },
+      "post": {
+        "summary": "Label a sample, either free text or the comment of a report record",
+        "tags": ["Classifier"],
+        "requestBody": { "required": true, "content": { "application/json": { "schema": { "type": "object", "required": ["label"], "properties": { "content": { "type": "string", "maxLength": 1000 }, "label": { "type": "string", "enum": ["spam", "ham"] }, "report_id": { "type": "integer", "description": "Copy the content from this report; labelling the same report again updates its label" } } } } } },
+        "responses": { "200": { "description": "Saved sample" }, "400": { "$ref": "#/components/responses/BadRequest" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
+    "/api/classifier/samples/{id}": {
+      "delete": {
+        "summary": "Delete a labelled sample",
+        "tags": ["Classifier"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Delete result" }, "404": { "$ref": "#/components/responses/NotFound" } }
+      }
+    },
     "/api/whitelist/list": {
       "get": {
         "summary": "List whitelist users",
         "tags": ["Whitelist"],
+        "parameters": [{ "name": "task_id", "in": "query", "schema": { "type": "integer" }, "description": "Only entries that apply to this task (including global ones)" }],
         "responses": { "200": { "description": "Whitelist", "content": { "application/json": { "schema": { "type": "array", "items": { "$ref": "#/components/schemas/WhitelistUser" } } } } } }
       }
     },
@@ -410,7 +828,35 @@ const OpenAPIJSON = `{
       "post": {
         "summary": "Create whitelist user",
         "tags": ["Whitelist"],
-        "responses": { "200": { "description": "Created whitelist entry" } }
+        "requestBody": { "content": { "application/json": { "schema": { "$ref": "#/components/schemas/WhitelistUser" } } } },
+        "responses": { "200": { "description": "Created whitelist entry" }, "400": { "description": "Neither uid nor uname, invalid username pattern, unknown task, target not monitored by the task, or expires_at in the past" } }
+      }
+    },
+    "/api/whitelist/import": {
+      "post": {
+        "summary": "Bulk import whitelist users",
+        "tags": ["Whitelist"],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "type": "object", "required": ["content"], "properties": {
+            "format": { "type": "string", "enum": ["csv", "json"], "default": "csv", "description": "csv needs a header row with at least uid or uname; recognized columns are uid, uname, uname_match, remark, enabled, task_id, target_uid, expires_at (RFC3339) in any order. json accepts {\"users\": [...]} or a bare array of WhitelistUser fields" },
+            "content": { "type": "string", "description": "File content, at most 2 MB and 5000 entries" },
+            "policy": { "type": "string", "enum": ["skip", "overwrite"], "default": "skip", "description": "Entries with the same uid, uname, uname_match, task_id and target_uid are the same entry; overwrite replaces their remark, enabled and expires_at" },
+            "dry_run": { "type": "boolean", "description": "Only return the comparison without writing" }
+          } } } }
+        },
+        "responses": { "200": { "description": "summary (new, changed, duplicate, invalid) and per-entry items with status, action (create/update/skip), existing_id and validation error; non dry runs also return created, updated and skipped counts. Already expired entries are imported as-is" }, "400": { "$ref": "#/components/responses/BadRequest" } }
+      }
+    },
+    "/api/whitelist/export": {
+      "get": {
+        "summary": "Export whitelist users",
+        "tags": ["Whitelist"],
+        "parameters": [
+          { "name": "format", "in": "query", "schema": { "type": "string", "enum": ["csv", "json"], "default": "csv" } },
+          { "name": "task_id", "in": "query", "schema": { "type": "integer" }, "description": "Only entries that apply to this task (including global ones)" }
+        ],
+        "responses": { "200": { "description": "Whitelist file download in the import format" } }
       }
     },
     "/api/whitelist/{id}": {
@@ -418,7 +864,8 @@ const OpenAPIJSON = `{
         "summary": "Update whitelist user",
         "tags": ["Whitelist"],
         "parameters": [{ "$ref": "#/components/parameters/ID" }],
-        "responses": { "200": { "description": "Updated whitelist entry" } }
+        "requestBody": { "content": { "application/json": { "schema": { "$ref": "#/components/schemas/WhitelistUser" } } } },
+        "responses": { "200": { "description": "Updated whitelist entry" }, "400": { "description": "Same validation as create; an unchanged past expires_at is kept" } }
       },
       "delete": {
         "summary": "Delete whitelist user",
@@ -427,6 +874,66 @@ const OpenAPIJSON = `{
         "responses": { "200": { "description": "Delete result" } }
       }
     },
+    "/api/watchlist/list": {
+      "get": {
+        "summary": "List watchlist users",
+        "tags": ["Watchlist"],
+        "parameters": [
+          { "name": "mode", "in": "query", "schema": { "type": "string", "enum": ["always_report", "extra_rules", "notify_only"] } },
+          { "name": "source", "in": "query", "schema": { "type": "string", "enum": ["manual", "auto"] } }
+        ],
+        "responses": { "200": { "description": "Watchlist", "content": { "application/json": { "schema": { "type": "array", "items": { "$ref": "#/components/schemas/WatchlistUser" } } } } } }
+      }
+    },
+    "/api/watchlist/create": {
+      "post": {
+        "summary": "Create watchlist user",
+        "tags": ["Watchlist"],
+        "requestBody": { "content": { "application/json": { "schema": { "$ref": "#/components/schemas/WatchlistUser" } } } },
+        "responses": { "200": { "description": "Created watchlist entry" }, "400": { "description": "Missing or duplicate uid, unsupported mode, or extra_rules without an existing rule set" } }
+      }
+    },
+    "/api/watchlist/{id}": {
+      "put": {
+        "summary": "Update watchlist user",
+        "tags": ["Watchlist"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "requestBody": { "content": { "application/json": { "schema": { "$ref": "#/components/schemas/WatchlistUser" } } } },
+        "responses": { "200": { "description": "Updated watchlist entry" }, "400": { "description": "Same validation as create" } }
+      },
+      "delete": {
+        "summary": "Delete watchlist user",
+        "tags": ["Watchlist"],
+        "parameters": [{ "$ref": "#/components/parameters/ID" }],
+        "responses": { "200": { "description": "Delete result" } }
+      }
+    },
+    "/api/commenters/top": {
+      "get": {
+        "summary": "Top reported commenters",
+        "tags": ["Commenters"],
+        "parameters": [
+          { "name": "limit", "in": "query", "schema": { "type": "integer", "minimum": 1, "maximum": 100, "default": 20 } },
+          { "name": "task_id", "in": "query", "schema": { "type": "integer" }, "description": "Only count reports of this task" },
+          { "name": "target_uid", "in": "query", "schema": { "type": "integer" }, "description": "Only count reports under videos of this UP" },
+          { "name": "start_time", "in": "query", "schema": { "type": "string", "format": "date-time" } },
+          { "name": "end_time", "in": "query", "schema": { "type": "string", "format": "date-time" } }
+        ],
+        "responses": { "200": { "description": "Commenters ordered by report count, with success ratio, distinct targets, last report time, whitelist status and watchlist mode" } }
+      }
+    },
+    "/api/commenters/{uid}": {
+      "get": {
+        "summary": "Commenter profile",
+        "tags": ["Commenters"],
+        "parameters": [{ "name": "uid", "in": "path", "required": true, "schema": { "type": "integer" } }],
+        "responses": {
+          "200": { "description": "Report counts and success ratio, rules hit, targets affected, first/last seen, stored near-duplicate comments, matching whitelist entries, watchlist entry and recent reports" },
+          "400": { "description": "Invalid UID" },
+          "404": { "description": "Nothing is known about this commenter" }
+        }
+      }
+    },
     "/api/settings": {
       "get": {
         "summary": "Get runtime and persisted settings",
@@ -458,7 +965,13 @@ const OpenAPIJSON = `{
       "get": {
         "summary": "List report records",
         "tags": ["Reports"],
-        "parameters": [{ "$ref": "#/components/parameters/Page" }, { "$ref": "#/components/parameters/PageSize" }],
+        "parameters": [
+          { "$ref": "#/components/parameters/Page" },
+          { "$ref": "#/components/parameters/PageSize" },
+          { "name": "removal_status", "in": "query", "schema": { "type": "string", "enum": ["pending", "removed", "kept", "error"] }, "description": "Only successful reports with this follow-up result" },
+          { "name": "cluster_id", "in": "query", "schema": { "type": "integer" }, "description": "Only reports of members of this near-duplicate cluster" },
+          { "name": "comment_user_id", "in": "query", "schema": { "type": "integer" }, "description": "Only reports of comments by this commenter UID" }
+        ],
         "responses": { "200": { "description": "Paginated report records" } }
       }
     },
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 统一的结构化日志字段名，便于日志管道按字段检索。
+const (
+	KeyComponent = "component"
+	KeyTaskID    = "task_id"
+	KeyTargetUID = "target_uid"
+	KeyBVID      = "bvid"
+	KeyRPID      = "rpid"
+	KeyAccountID = "account_id"
+	KeyRuleID    = "rule_id"
+	KeyAttempt   = "attempt"
+	KeyError     = "error"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	redacted = "[REDACTED]"
+)
+
+// sensitiveKeys 中的字段无论值为何都会整体脱敏。
+var sensitiveKeys = map[string]bool{
+	"cookie":        true,
+	"cookies":       true,
+	"password":      true,
+	"passwd":        true,
+	"secret":        true,
+	"secret_key":    true,
+	"authorization": true,
+	"sessdata":      true,
+	"bili_jct":      true,
+	"csrf":          true,
+	"token":         true,
+	"refresh_token": true,
+}
+
+// cookieValuePattern 匹配混在普通文本里的 B站登录 Cookie 键值对。
+var cookieValuePattern = regexp.MustCompile(`(?i)\b(SESSDATA|bili_jct|DedeUserID__ckMd5|sid|refresh_token|csrf)=([^;&\s"]+)`)
+
+// Setup 按级别和格式安装全局 slog 处理器，标准库 log 的输出也会经过该处理器。
+func Setup(level, format string) {
+	slog.SetDefault(slog.New(NewHandler(os.Stderr, level, format)))
+}
+
+// NewHandler 创建带脱敏能力的 text 或 json 处理器。
+func NewHandler(w io.Writer, level, format string) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redactAttr,
+	}
+	if strings.EqualFold(strings.TrimSpace(format), FormatJSON) {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// ParseLevel 解析 debug/info/warn/error，无法识别时返回 info。
+func ParseLevel(raw string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// For 返回带 component 字段的 Logger。每次调用都读取当前默认 Logger，
+// 因此在 Setup 之前获取的 Logger 不会被缓存到旧配置上。
+func For(component string) *slog.Logger {
+	return slog.Default().With(KeyComponent, component)
+}
+
+// Redact 脱敏文本中出现的 Cookie 键值对。
+func Redact(value string) string {
+	if value == "" {
+		return value
+	}
+	return cookieValuePattern.ReplaceAllString(value, "$1="+redacted)
+}
+
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		if value := attr.Value.String(); value != "" {
+			if cleaned := Redact(value); cleaned != value {
+				return slog.String(attr.Key, cleaned)
+			}
+		}
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok && err != nil {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+	return attr
+}
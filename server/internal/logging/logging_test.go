@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewHandlerRedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, "info", FormatJSON))
+
+	logger.Info("登录回调 SESSDATA=abc123; bili_jct=def456",
+		"cookie", "SESSDATA=abc123",
+		"Password", "hunter2",
+		"detail", "url?DedeUserID=1&SESSDATA=abc123&sid=xyz",
+		KeyError, errors.New("请求失败: bili_jct=def456"),
+		"uid", 42,
+	)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected JSON log line, got %q: %v", buf.String(), err)
+	}
+	for _, secret := range []string{"abc123", "def456", "hunter2", "xyz"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Fatalf("log line leaked %q: %s", secret, buf.String())
+		}
+	}
+	if entry["cookie"] != redacted || entry["Password"] != redacted {
+		t.Fatalf("expected sensitive keys to be redacted, got %#v", entry)
+	}
+	if entry["detail"] != "url?DedeUserID=1&SESSDATA=[REDACTED]&sid=[REDACTED]" {
+		t.Fatalf("unexpected detail redaction: %#v", entry["detail"])
+	}
+	if entry["uid"] != float64(42) {
+		t.Fatalf("expected non-sensitive field to be kept, got %#v", entry["uid"])
+	}
+}
+
+func TestNewHandlerHonorsLevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, "warn", "TEXT"))
+
+	logger.Info("ignored")
+	logger.Warn("kept", KeyTaskID, 7)
+
+	out := buf.String()
+	if strings.Contains(out, "ignored") {
+		t.Fatalf("info record should be filtered at warn level: %s", out)
+	}
+	if !strings.Contains(out, "msg=kept") || !strings.Contains(out, "task_id=7") {
+		t.Fatalf("expected text formatted warn record, got %s", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		" INFO ":  slog.LevelInfo,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"verbose": slog.LevelInfo,
+		"":        slog.LevelInfo,
+	}
+	for raw, want := range cases {
+		if got := ParseLevel(raw); got != want {
+			t.Fatalf("ParseLevel(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -106,6 +106,8 @@ func BasicAuth() gin.HandlerFunc {
 		}
 
 		basicAuthLimiter.reset(rateKey)
+		// 与 gin.BasicAuth 相同，把用户名放入上下文，规则修订等记录操作人时读取
+		c.Set(gin.AuthUserKey, username)
 		c.Next()
 	}
 }
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spiritlhl/goban/internal/logging"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	previous := slog.Default()
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, "debug", logging.FormatJSON)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestRequestLoggerWritesStructuredFieldsWithoutQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := captureLogs(t)
+
+	router := gin.New()
+	router.Use(RequestLogger())
+	router.GET("/api/items/:id", func(c *gin.Context) {
+		c.String(http.StatusNotFound, "missing")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/items/3?cookie=SESSDATA%3Dabc", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["component"] != "http" {
+		t.Fatalf("unexpected level/component: %#v", entry)
+	}
+	if entry["path"] != "/api/items/3" || entry["route"] != "/api/items/:id" || entry["status"] != float64(404) {
+		t.Fatalf("unexpected request fields: %#v", entry)
+	}
+	if strings.Contains(buf.String(), "abc") {
+		t.Fatalf("query string should not be logged: %s", buf.String())
+	}
+}
+
+func TestRecoveryLogsPanicAndReturns500(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := captureLogs(t)
+
+	router := gin.New()
+	router.Use(Recovery())
+	router.GET("/boom", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", resp.Code)
+	}
+	if !strings.Contains(buf.String(), `"panic":"boom"`) || !strings.Contains(buf.String(), `"stack"`) {
+		t.Fatalf("expected panic and stack in log, got %s", buf.String())
+	}
+}
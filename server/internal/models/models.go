@@ -33,18 +33,18 @@ type MonitorTask struct {
 	User             BiliUser        `json:"user" gorm:"foreignKey:UserID"` // 关联的B站用户
 	Name             string          `json:"name"`                          // 任务名称
 	Targets          []MonitorTarget `json:"targets" gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;"`
-	VideoCount       int             `json:"video_count" gorm:"default:5"`          // 监控最新多少条视频
-	CommentCount     int             `json:"comment_count" gorm:"default:50"`       // 监控每个视频的多少条评论
-	Keywords         string          `json:"keywords"`                              // 兼容的临时关键字，逗号或换行分隔
-	KeywordRuleIDs   string          `json:"keyword_rule_ids"`                      // 关联的关键字规则ID，逗号分隔；为空表示使用所有启用规则
-	Enabled          bool            `json:"enabled" gorm:"default:true"`           // 是否启用
-	Interval         int             `json:"interval" gorm:"default:300"`           // 监控间隔（秒）
-	ReportDelay      int             `json:"report_delay" gorm:"default:30"`        // 举报间隔（秒）
-	DailyReportLimit int             `json:"daily_report_limit" gorm:"default:100"` // 单账号每日成功举报上限
-	MaxRetries       int             `json:"max_retries" gorm:"default:3"`          // API最大重试次数
-	RetryInterval    int             `json:"retry_interval" gorm:"default:2"`       // API重试基础间隔（秒），使用指数退避
-	ProxyURL         string          `json:"proxy_url"`                             // 代理地址，如 http://proxy:port 或 socks5://proxy:port
-	LastCheck        time.Time       `json:"last_check"`                            // 上次检查时间
+	VideoCount       int             `json:"video_count" gorm:"default:5"`               // 监控最新多少条视频
+	CommentCount     int             `json:"comment_count" gorm:"default:50"`            // 监控每个视频的多少条评论
+	Keywords         string          `json:"keywords"`                                   // 兼容的临时关键字，逗号或换行分隔
+	RuleSets         []RuleSet       `json:"rule_sets" gorm:"many2many:task_rule_sets;"` // 关联的规则集；为空时只使用临时关键字
+	Enabled          bool            `json:"enabled" gorm:"default:true"`                // 是否启用
+	Interval         int             `json:"interval" gorm:"default:300"`                // 监控间隔（秒）
+	ReportDelay      int             `json:"report_delay" gorm:"default:30"`             // 举报间隔（秒）
+	DailyReportLimit int             `json:"daily_report_limit" gorm:"default:100"`      // 单账号每日成功举报上限
+	MaxRetries       int             `json:"max_retries" gorm:"default:3"`               // API最大重试次数
+	RetryInterval    int             `json:"retry_interval" gorm:"default:2"`            // API重试基础间隔（秒），使用指数退避
+	ProxyURL         string          `json:"proxy_url"`                                  // 代理地址，如 http://proxy:port 或 socks5://proxy:port
+	LastCheck        time.Time       `json:"last_check"`                                 // 上次检查时间
 	LastSuccessAt    *time.Time      `json:"last_success_at"`
 	LastStatus       string          `json:"last_status"`
 	LastError        string          `json:"last_error"`
@@ -58,6 +58,13 @@ type MonitorTask struct {
 	CheckedComments  int64           `json:"checked_comments"`
 	MatchedComments  int64           `json:"matched_comments"`
 	ReportCount      int64           `json:"report_count"`
+	ScoreThreshold   float64         `json:"score_threshold"` // 评论命中规则的得分合计达到该值才举报，0 表示任意命中即举报
+	NearDuplicate    bool            `json:"near_duplicate"`  // 是否检测跨视频的近似重复评论（复制粘贴刷屏）
+	// ClassifierThreshold 文本分类器给出的垃圾评论概率达到该值时作为一条规则命中，0 表示不使用分类器
+	ClassifierThreshold float64 `json:"classifier_threshold"`
+	SkipOwnerComments   bool    `json:"skip_owner_comments"`   // 跳过UP主本人的评论
+	SkipUpInteracted    bool    `json:"skip_up_interacted"`    // 跳过UP主点赞或回复过的评论
+	SkipFansMedalLevel  int     `json:"skip_fans_medal_level"` // 跳过佩戴该UP主粉丝勋章且等级不低于该值的评论者，0 表示不跳过
 }
 
 // MonitorTarget 单个监控任务下的UP主目标
@@ -83,14 +90,68 @@ type KeywordRule struct {
 	UpdatedAt     time.Time  `json:"updated_at"`
 	Name          string     `json:"name"`
 	Pattern       string     `json:"pattern"`
-	MatchType     string     `json:"match_type" gorm:"default:plain"`   // plain, regex
+	MatchType     string     `json:"match_type" gorm:"default:plain"`   // plain, regex, pinyin, expression, flood, blocklist
 	MatchLogic    string     `json:"match_logic" gorm:"default:single"` // single, or, and
 	CaseSensitive bool       `json:"case_sensitive"`
+	Homophone     bool       `json:"homophone"`               // 拼音规则是否启用近音等价表
+	Normalize     string     `json:"normalize"`               // 匹配前的归一化步骤，逗号分隔: invisible, nfkc, confusables, t2s, emoji
+	MaxGap        int        `json:"max_gap"`                 // 普通规则相邻字之间最多跳过的干扰字符数，0 表示不跳过
+	GapNoise      string     `json:"gap_noise"`               // 可跳过的干扰字符类别，逗号分隔: space, punct, emoji, invisible，留空为全部
+	Weight        float64    `json:"weight" gorm:"default:1"` // 规则权重，命中得分 = 权重 × 命中条件权重之和
+	Conditions    string     `json:"conditions"`              // 评论者附加条件，分号分隔，如 level <= 2; has_fans_medal = false
+	MustMatch     string     `json:"must_match"`              // 测试用例：必须命中的示例文本，每行一条
+	MustNotMatch  string     `json:"must_not_match"`          // 测试用例：不能命中的示例文本，每行一条
 	Enabled       bool       `json:"enabled" gorm:"default:true"`
 	Description   string     `json:"description"`
+	ActiveFrom    *time.Time `json:"active_from"`  // 生效时间，为空表示立即生效
+	ActiveUntil   *time.Time `json:"active_until"` // 失效时间，过期后规则被自动停用
+	ActiveDays    string     `json:"active_days"`  // 生效星期，逗号分隔的 1-7（周一至周日），留空为每天
+	ActiveHours   string     `json:"active_hours"` // 每天的生效时段，逗号分隔的 HH:MM-HH:MM，可跨午夜，留空为全天
+	Revision      int        `json:"revision"`     // 当前内容对应的修订号，见 KeywordRuleRevision
 	LastMatchedAt *time.Time `json:"last_matched_at"`
 }
 
+// KeywordRuleRevision 规则每次创建、修改、导入或回滚后的完整快照，写入后不再修改。
+// 删除规则时保留修订，举报记录仍可查到当时命中的规则内容。
+type KeywordRuleRevision struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RuleID        uint       `json:"rule_id" gorm:"uniqueIndex:idx_rule_revision"`
+	Revision      int        `json:"revision" gorm:"uniqueIndex:idx_rule_revision"`
+	Action        string     `json:"action"`        // create, update, import, rollback, baseline, expire
+	Author        string     `json:"author"`        // 操作的管理员用户名，升级时补建的基线修订和自动停用为空
+	RestoredFrom  int        `json:"restored_from"` // 回滚时恢复的修订号，其余为 0
+	Name          string     `json:"name"`
+	Pattern       string     `json:"pattern"`
+	MatchType     string     `json:"match_type"`
+	MatchLogic    string     `json:"match_logic"`
+	CaseSensitive bool       `json:"case_sensitive"`
+	Homophone     bool       `json:"homophone"`
+	Normalize     string     `json:"normalize"`
+	MaxGap        int        `json:"max_gap"`
+	GapNoise      string     `json:"gap_noise"`
+	Weight        float64    `json:"weight"`
+	Conditions    string     `json:"conditions"`
+	MustMatch     string     `json:"must_match"`
+	MustNotMatch  string     `json:"must_not_match"`
+	Enabled       bool       `json:"enabled"`
+	Description   string     `json:"description"`
+	ActiveFrom    *time.Time `json:"active_from"`
+	ActiveUntil   *time.Time `json:"active_until"`
+	ActiveDays    string     `json:"active_days"`
+	ActiveHours   string     `json:"active_hours"`
+}
+
+// RuleSet 命名规则集，一条规则可以属于多个规则集，任务通过规则集引用规则
+type RuleSet struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Name        string        `json:"name" gorm:"uniqueIndex"`
+	Description string        `json:"description"`
+	Rules       []KeywordRule `json:"rules" gorm:"many2many:rule_set_rules;"`
+}
+
 // WhitelistUser 白名单用户，命中后跳过举报
 type WhitelistUser struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
@@ -98,8 +159,39 @@ type WhitelistUser struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	UID       int64     `json:"uid" gorm:"index"`
 	Uname     string    `json:"uname" gorm:"index"`
-	Remark    string    `json:"remark"`
+	// UnameMatch 是用户名的匹配方式：exact 忽略大小写精确匹配，glob 为 * 和 ? 通配符，regex 为正则
+	UnameMatch string     `json:"uname_match" gorm:"default:exact"`
+	Remark     string     `json:"remark"`
+	Enabled    bool       `json:"enabled" gorm:"default:true"`
+	TaskID     uint       `json:"task_id" gorm:"index"`    // 只对该任务生效，0 表示全部任务
+	TargetUID  int64      `json:"target_uid" gorm:"index"` // 只对该 UP 主的视频生效，0 表示全部 UP 主
+	ExpiresAt  *time.Time `json:"expires_at"`              // 过期时间，为空表示长期有效
+}
+
+// WatchlistUser 重点关注用户，与白名单相反：其在监控视频下的评论按 Mode 更严格地处理
+type WatchlistUser struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UID       int64     `json:"uid" gorm:"uniqueIndex"`
+	Uname     string    `json:"uname"`
+	Reason    string    `json:"reason"`
+	Mode      string    `json:"mode" gorm:"default:notify_only"` // always_report, extra_rules, notify_only
+	RuleSetID uint      `json:"rule_set_id" gorm:"index"`        // extra_rules 模式额外使用的规则集
+	Source    string    `json:"source" gorm:"default:manual"`    // manual, auto
 	Enabled   bool      `json:"enabled" gorm:"default:true"`
+	// ReportCount 是自动加入时该用户的成功举报次数，手动添加为 0
+	ReportCount int64 `json:"report_count"`
+	// LastCommentAt 是在监控视频下发现的其最新评论的发布时间，仅用于展示
+	LastCommentAt *time.Time `json:"last_comment_at"`
+}
+
+// WatchlistNotice 记录已发送过 notify_only 通知的评论，评论被多次拉取或多个任务覆盖同一视频时只通知一次
+type WatchlistNotice struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UID       int64     `json:"uid" gorm:"index"`
+	RPID      int64     `json:"rpid" gorm:"uniqueIndex"`
 }
 
 // AppSetting 可视化配置项
@@ -124,25 +216,122 @@ type MonitorLog struct {
 
 // ReportRecord 举报记录
 type ReportRecord struct {
-	ID              uint        `json:"id" gorm:"primaryKey"`
-	CreatedAt       time.Time   `json:"created_at"`
-	UpdatedAt       time.Time   `json:"updated_at"`
-	TaskID          uint        `json:"task_id" gorm:"uniqueIndex:idx_task_comment"`
-	Task            MonitorTask `json:"task" gorm:"foreignKey:TaskID"`
-	TargetUID       int64       `json:"target_uid" gorm:"index"`
-	TargetUname     string      `json:"target_uname"`
-	AVID            int64       `json:"avid"`                                           // 视频AV号
-	BVID            string      `json:"bvid"`                                           // 视频BV号
-	VideoTitle      string      `json:"video_title"`                                    // 视频标题
-	CommentID       int64       `json:"comment_id" gorm:"uniqueIndex:idx_task_comment"` // 评论ID
-	CommentContent  string      `json:"comment_content"`                                // 评论内容
-	CommentUser     string      `json:"comment_user"`                                   // 评论用户
-	CommentUserID   int64       `json:"comment_user_id"`
-	KeywordRuleID   *uint       `json:"keyword_rule_id"`
-	KeywordRuleName string      `json:"keyword_rule_name"`
-	MatchedKeyword  string      `json:"matched_keyword"` // 匹配的关键字
-	MatchType       string      `json:"match_type"`
-	Reason          int         `json:"reason" gorm:"default:11"` // 举报理由：11=传谣类
-	Success         bool        `json:"success"`                  // 举报是否成功
-	Message         string      `json:"message"`                  // 举报结果消息
+	ID                  uint        `json:"id" gorm:"primaryKey"`
+	CreatedAt           time.Time   `json:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at"`
+	TaskID              uint        `json:"task_id" gorm:"uniqueIndex:idx_task_comment"`
+	Task                MonitorTask `json:"task" gorm:"foreignKey:TaskID"`
+	TargetUID           int64       `json:"target_uid" gorm:"index"`
+	TargetUname         string      `json:"target_uname"`
+	AVID                int64       `json:"avid"`                                           // 视频AV号
+	BVID                string      `json:"bvid"`                                           // 视频BV号
+	VideoTitle          string      `json:"video_title"`                                    // 视频标题
+	CommentID           int64       `json:"comment_id" gorm:"uniqueIndex:idx_task_comment"` // 评论ID
+	CommentContent      string      `json:"comment_content"`                                // 评论内容
+	CommentUser         string      `json:"comment_user"`                                   // 评论用户
+	CommentUserID       int64       `json:"comment_user_id" gorm:"index"`
+	KeywordRuleID       *uint       `json:"keyword_rule_id"`
+	KeywordRuleName     string      `json:"keyword_rule_name"`
+	KeywordRuleRevision int         `json:"keyword_rule_revision"` // 举报时主规则的修订号
+	MatchedKeyword      string      `json:"matched_keyword"`       // 匹配的关键字
+	MatchType           string      `json:"match_type"`
+	Score               float64     `json:"score"`                       // 全部命中规则的得分合计
+	MatchedRules        string      `json:"matched_rules"`               // 参与计分的规则明细，JSON 数组
+	Reason              int         `json:"reason" gorm:"default:11"`    // 举报理由：11=传谣类
+	Success             bool        `json:"success"`                     // 举报是否成功
+	Message             string      `json:"message"`                     // 举报结果消息
+	RemovalStatus       string      `json:"removal_status" gorm:"index"` // 举报成功后的复查结果：空=待复查，removed=评论已删除，kept=评论仍在，error=多次复查失败
+	RemovalCheckAt      *time.Time  `json:"removal_check_at"`            // 复查时间，复查失败时为最近一次尝试的时间
+	RemovalAttempts     int         `json:"removal_attempts"`            // 复查失败次数
+	ClusterID           *uint       `json:"cluster_id" gorm:"index"`     // 作为近似重复评论簇成员举报时的簇ID
+	Evidence            string      `json:"evidence"`                    // 刷屏规则命中时评论者的相关评论，JSON 数组
+	MatchSpans          string      `json:"match_spans"`                 // 各命中规则成立的条件、归一化步骤和命中片段在评论中的位置，JSON 数组
+}
+
+// RuleDailyStat 关键字规则按天汇总的计数，由监控服务累加
+type RuleDailyStat struct {
+	ID               uint      `json:"-" gorm:"primaryKey"`
+	UpdatedAt        time.Time `json:"-"`
+	RuleID           uint      `json:"rule_id" gorm:"uniqueIndex:idx_rule_day"`
+	Day              string    `json:"day" gorm:"uniqueIndex:idx_rule_day;size:10"` // 本地日期 2006-01-02
+	Matches          int64     `json:"matches"`                                     // 评论得分达到阈值时的命中次数
+	ReportsAttempted int64     `json:"reports_attempted"`                           // 参与的举报次数
+	ReportsSucceeded int64     `json:"reports_succeeded"`                           // 其中举报接口返回成功的次数
+	ConfirmedRemoved int64     `json:"confirmed_removed"`                           // 复查时评论已被删除
+	ReviewRejected   int64     `json:"review_rejected"`                             // 复查时评论仍在，视为审核未通过
+	WhitelistSkips   int64     `json:"whitelist_skips"`                             // 评论者在白名单中而跳过的命中
+}
+
+// RuleStatComment 记录已计入规则统计的评论，同一条评论每次运行都会被重新拉取，
+// 命中和白名单跳过次数按评论和规则只累加一次
+type RuleStatComment struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"-"`
+	RuleID    uint      `json:"rule_id" gorm:"uniqueIndex:idx_rule_stat_comment"`
+	RPID      int64     `json:"rpid" gorm:"uniqueIndex:idx_rule_stat_comment"`
+	Kind      string    `json:"kind" gorm:"uniqueIndex:idx_rule_stat_comment;size:16"` // match 或 whitelist_skip
+}
+
+// CommentCluster 近似重复评论簇：多条评论的 SimHash 指纹足够接近时归为一簇
+type CommentCluster struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	Fingerprint string           `json:"fingerprint" gorm:"size:16"`       // 首条评论的指纹，16 位十六进制
+	Sample      string           `json:"sample"`                           // 首条评论内容
+	Size        int64            `json:"size"`                             // 簇内评论总数
+	Status      string           `json:"status" gorm:"index;default:open"` // open, queued, reported, dismissed
+	FirstSeenAt time.Time        `json:"first_seen_at"`
+	LastSeenAt  time.Time        `json:"last_seen_at" gorm:"index"`
+	Comments    []ClusterComment `json:"comments,omitempty" gorm:"foreignKey:ClusterID"`
+}
+
+// ClusterComment 参与近似重复检测的评论，ClusterID 为 0 表示尚未与其他评论成簇
+type ClusterComment struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time `json:"created_at"`
+	ClusterID     uint      `json:"cluster_id" gorm:"index"`
+	TaskID        uint      `json:"task_id"`
+	TargetUID     int64     `json:"target_uid"`
+	TargetUname   string    `json:"target_uname"`
+	AVID          int64     `json:"avid"`
+	BVID          string    `json:"bvid"`
+	VideoTitle    string    `json:"video_title"`
+	CommentID     int64     `json:"comment_id" gorm:"uniqueIndex"`
+	CommentUser   string    `json:"comment_user"`
+	CommentUserID int64     `json:"comment_user_id" gorm:"index"`
+	Content       string    `json:"content"`
+	Fingerprint   string    `json:"fingerprint" gorm:"size:16"`
+	SeenAt        time.Time `json:"seen_at" gorm:"index"` // 评论发布时间，用于滑动窗口
+	ReportStatus  string    `json:"report_status"`        // 空=未举报，success, failed, skipped
+}
+
+// ClassifierModel 文本分类器的一次训练结果，指标为在留出集上按 0.5 阈值评估的结果
+type ClassifierModel struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time `json:"created_at"`
+	Active       bool      `json:"active" gorm:"index"` // 监控使用的模型，同时只有一个
+	NGram        int       `json:"ngram"`
+	TrainSamples int       `json:"train_samples"`
+	TestSamples  int       `json:"test_samples"`
+	SpamSamples  int       `json:"spam_samples"` // 训练集中的垃圾评论数
+	HamSamples   int       `json:"ham_samples"`  // 训练集中的正常评论数
+	Features     int       `json:"features"`
+	Holdout      int       `json:"holdout"` // 留出集比例（百分比）
+	Precision    float64   `json:"precision"`
+	Recall       float64   `json:"recall"`
+	F1           float64   `json:"f1"`
+	Accuracy     float64   `json:"accuracy"`
+	CreatedBy    string    `json:"created_by"`
+	Data         string    `json:"-"` // 序列化的模型参数，JSON
+}
+
+// ClassifierSample 审核人员标注的分类器训练样本
+type ClassifierSample struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	Content   string    `json:"content"`
+	Label     string    `json:"label" gorm:"index"`     // spam, ham
+	ReportID  *uint     `json:"report_id" gorm:"index"` // 从举报记录标注时的记录ID
+	CreatedBy string    `json:"created_by"`
 }
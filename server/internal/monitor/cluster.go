@@ -0,0 +1,178 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/copypasta"
+	"github.com/spiritlhl/goban/internal/logging"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/secure"
+)
+
+// observeDuplicate 把评论加入近似重复检测，所属簇达到阈值时返回合成规则的命中。
+func (s *MonitorService) observeDuplicate(ctx context.Context, run *taskRun, target models.MonitorTarget, video bili.VideoInfo, comment bili.CommentInfo) (rules.MatchResult, bool) {
+	row := models.ClusterComment{
+		TaskID:        run.task.ID,
+		TargetUID:     target.UID,
+		TargetUname:   target.Uname,
+		AVID:          video.AID,
+		BVID:          video.BVID,
+		VideoTitle:    video.Title,
+		CommentID:     comment.RPID,
+		CommentUser:   comment.Member.Uname,
+		CommentUserID: comment.Member.Mid,
+		Content:       comment.Content.Message,
+	}
+	if comment.CTime > 0 {
+		row.SeenAt = time.Unix(comment.CTime, 0)
+	}
+	result, ok, err := s.duplicates.Observe(tracedDB(ctx), row, run.duplicate, time.Now())
+	if err != nil {
+		taskLogger(run.task.ID).Warn("近似重复检测失败", logging.KeyRPID, comment.RPID, slog.Any(logging.KeyError, err))
+		return rules.MatchResult{}, false
+	}
+	if !ok || !result.Flagged {
+		return rules.MatchResult{}, false
+	}
+	return result.Match(), true
+}
+
+// markClusterMemberReported 把举报结果写回簇成员。
+func (s *MonitorService) markClusterMemberReported(ctx context.Context, report models.ReportRecord) {
+	status := copypasta.ReportFailed
+	if report.Success {
+		status = copypasta.ReportSuccess
+	}
+	if err := tracedDB(ctx).Model(&models.ClusterComment{}).
+		Where("cluster_id = ? AND comment_id = ?", *report.ClusterID, report.CommentID).
+		Update("report_status", status).Error; err != nil {
+		logging.For("copypasta").Warn("更新簇成员举报状态失败", logging.KeyRPID, report.CommentID, slog.Any(logging.KeyError, err))
+	}
+}
+
+// clusterReporter 是整簇举报时某个任务的账号客户端，backoff 表示该任务正处于风控退避中。
+type clusterReporter struct {
+	task    models.MonitorTask
+	client  *bili.BiliClient
+	backoff bool
+}
+
+// processClusterReports 逐条举报管理员提交整簇举报的簇成员。每条评论使用发现它的任务账号，
+// 举报间隔和每日上限与监控时相同；任务处于风控退避时该任务的成员留到下一轮。
+func (s *MonitorService) processClusterReports() {
+	ctx := s.context()
+	if ctx.Err() != nil {
+		return
+	}
+	// 举报需要等待间隔，上一轮未结束时跳过本轮
+	if !s.clusterMu.TryLock() {
+		return
+	}
+	defer s.clusterMu.Unlock()
+
+	var clusters []models.CommentCluster
+	if err := tracedDB(ctx).Where("status = ?", copypasta.StatusQueued).Order("updated_at ASC").Find(&clusters).Error; err != nil {
+		logging.For("copypasta").Error("查询待举报的评论簇失败", slog.Any(logging.KeyError, err))
+		return
+	}
+	for _, cluster := range clusters {
+		if !s.reportCluster(ctx, cluster) {
+			return
+		}
+	}
+}
+
+// reportCluster 举报簇内尚未举报的成员；返回 false 表示服务已停止。
+func (s *MonitorService) reportCluster(ctx context.Context, cluster models.CommentCluster) bool {
+	db := tracedDB(ctx)
+	var members []models.ClusterComment
+	if err := db.Where("cluster_id = ? AND report_status = ?", cluster.ID, "").Order("id ASC").Find(&members).Error; err != nil {
+		logging.For("copypasta").Error("查询簇成员失败", "cluster_id", cluster.ID, slog.Any(logging.KeyError, err))
+		return true
+	}
+
+	reporters := map[uint]*clusterReporter{}
+	pending := false
+	for _, member := range members {
+		if ctx.Err() != nil {
+			return false
+		}
+		reporter, ok := reporters[member.TaskID]
+		if !ok {
+			reporter = s.loadClusterReporter(ctx, member.TaskID)
+			reporters[member.TaskID] = reporter
+		}
+		if reporter == nil {
+			s.skipClusterMember(ctx, member)
+			continue
+		}
+		if reporter.backoff {
+			pending = true
+			continue
+		}
+
+		video := bili.VideoInfo{AID: member.AVID, BVID: member.BVID, Title: member.VideoTitle}
+		var comment bili.CommentInfo
+		comment.RPID = member.CommentID
+		comment.OID = member.AVID
+		comment.Mid = member.CommentUserID
+		comment.Content.Message = member.Content
+		comment.Member.Mid = member.CommentUserID
+		comment.Member.Uname = member.CommentUser
+		target := models.MonitorTarget{UID: member.TargetUID, Uname: member.TargetUname}
+		match := copypasta.ClusterMatch(cluster.ID, cluster.Size)
+
+		outcome := s.reportComment(ctx, reporter.task, target, video, comment, []rules.MatchResult{match}, reporter.client)
+		if outcome.stopTask {
+			if ctx.Err() != nil {
+				return false
+			}
+			reporter.backoff = true
+			pending = true
+			continue
+		}
+		// 已被单独举报或达到每日上限时 reportComment 不会写入举报记录
+		s.skipClusterMember(ctx, member)
+	}
+
+	if pending {
+		return true
+	}
+	if err := db.Model(&cluster).Update("status", copypasta.StatusReported).Error; err != nil {
+		logging.For("copypasta").Error("更新评论簇状态失败", "cluster_id", cluster.ID, slog.Any(logging.KeyError, err))
+		return true
+	}
+	logging.For("copypasta").Info("整簇举报完成", "cluster_id", cluster.ID, "members", len(members))
+	return true
+}
+
+// loadClusterReporter 加载任务和账号客户端；任务已删除或Cookie不可用时返回 nil。
+func (s *MonitorService) loadClusterReporter(ctx context.Context, taskID uint) *clusterReporter {
+	var task models.MonitorTask
+	if err := tracedDB(ctx).Preload("User").First(&task, taskID).Error; err != nil {
+		logging.For("copypasta").Warn("整簇举报的任务不存在", logging.KeyTaskID, taskID, slog.Any(logging.KeyError, err))
+		return nil
+	}
+	cookies, err := secure.DecryptString(task.User.Cookies)
+	if err != nil || task.User.CookieStatus == "invalid" {
+		s.addLog(ctx, task.ID, "warning", "账号Cookie不可用，跳过整簇举报")
+		return nil
+	}
+	reporter := &clusterReporter{task: task, client: newClientForTask(task, cookies)}
+	if task.BackoffUntil != nil && task.BackoffUntil.After(time.Now()) {
+		reporter.backoff = true
+		s.addLog(ctx, task.ID, "warning", fmt.Sprintf("任务处于风控退避中，整簇举报推迟到 %s 之后", task.BackoffUntil.Format("2006-01-02 15:04:05")))
+	}
+	return reporter
+}
+
+func (s *MonitorService) skipClusterMember(ctx context.Context, member models.ClusterComment) {
+	tracedDB(ctx).Model(&models.ClusterComment{}).
+		Where("id = ? AND report_status = ?", member.ID, "").
+		Update("report_status", copypasta.ReportSkipped)
+}
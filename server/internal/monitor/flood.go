@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+)
+
+// matchFlood 记录评论者的这条评论，并用其在全部监控目标下的近期评论判断任务的刷屏规则。
+func (s *MonitorService) matchFlood(run *taskRun, target models.MonitorTarget, video bili.VideoInfo, comment bili.CommentInfo, commenter *rules.Commenter) []rules.MatchResult {
+	now := time.Now()
+	current := rules.ActivityComment{
+		RPID:      comment.RPID,
+		AID:       video.AID,
+		BVID:      video.BVID,
+		TargetUID: target.UID,
+		Message:   comment.Content.Message,
+		At:        now,
+	}
+	if comment.CTime > 0 {
+		current.At = time.Unix(comment.CTime, 0)
+	}
+	mid := comment.Member.Mid
+	if mid == 0 {
+		mid = comment.Mid
+	}
+	history := s.activity.Record(mid, current, run.engine.FloodWindow(), now)
+	return run.engine.MatchFloodFor(current, history, commenter)
+}
@@ -5,20 +5,29 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"github.com/spiritlhl/goban/internal/activity"
 	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/classifier"
 	"github.com/spiritlhl/goban/internal/config"
+	"github.com/spiritlhl/goban/internal/copypasta"
 	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/logging"
 	"github.com/spiritlhl/goban/internal/models"
 	"github.com/spiritlhl/goban/internal/notify"
 	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/ruleset"
+	"github.com/spiritlhl/goban/internal/rulestats"
 	"github.com/spiritlhl/goban/internal/secure"
 	"github.com/spiritlhl/goban/internal/settings"
+	"github.com/spiritlhl/goban/internal/telemetry"
+	"github.com/spiritlhl/goban/internal/watchlist"
 	white "github.com/spiritlhl/goban/internal/whitelist"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
@@ -29,6 +38,10 @@ type MonitorService struct {
 	runningTasks  map[uint]bool
 	semaphore     chan struct{}
 	reportLimiter *ReportLimiter
+	duplicates    *copypasta.Detector
+	activity      *activity.Tracker
+	classifiers   *classifier.Cache
+	clusterMu     sync.Mutex
 	ctx           context.Context
 	cancel        context.CancelFunc
 	wg            sync.WaitGroup
@@ -52,6 +65,9 @@ func NewMonitorService() *MonitorService {
 		runningTasks:  map[uint]bool{},
 		semaphore:     make(chan struct{}, cfg.MaxConcurrentTasks),
 		reportLimiter: &ReportLimiter{},
+		duplicates:    copypasta.NewDetector(),
+		activity:      activity.NewTracker(),
+		classifiers:   classifier.NewCache(),
 	}
 }
 
@@ -65,14 +81,23 @@ func (s *MonitorService) Start() {
 	s.running = true
 	s.cron = cron.New(cron.WithSeconds())
 	if _, err := s.cron.AddFunc("@every 10s", s.checkTasks); err != nil {
-		log.Printf("[监控服务] 注册任务检查失败: %v", err)
+		serviceLogger().Error("注册任务检查失败", slog.Any(logging.KeyError, err))
 	}
 	if _, err := s.cron.AddFunc("@every 1m", s.checkCookiesDue); err != nil {
-		log.Printf("[监控服务] 注册Cookie检查失败: %v", err)
+		serviceLogger().Error("注册Cookie检查失败", slog.Any(logging.KeyError, err))
+	}
+	if _, err := s.cron.AddFunc("@every 10m", s.checkRemovalsDue); err != nil {
+		serviceLogger().Error("注册举报复查失败", slog.Any(logging.KeyError, err))
+	}
+	if _, err := s.cron.AddFunc("@every 1m", s.processClusterReports); err != nil {
+		serviceLogger().Error("注册整簇举报失败", slog.Any(logging.KeyError, err))
+	}
+	if _, err := s.cron.AddFunc("@every 1m", s.checkRuleExpiry); err != nil {
+		serviceLogger().Error("注册规则过期检查失败", slog.Any(logging.KeyError, err))
 	}
 	s.mu.Unlock()
 
-	log.Println("[监控服务] 启动")
+	serviceLogger().Info("监控服务启动")
 	s.cron.Run()
 }
 
@@ -95,14 +120,15 @@ func (s *MonitorService) Stop() {
 		<-ctx.Done()
 	}
 	s.wg.Wait()
-	log.Println("[监控服务] 停止")
+	serviceLogger().Info("监控服务停止")
 }
 
 func (s *MonitorService) checkTasks() {
-	db := database.GetDB()
+	ctx := s.context()
+	db := tracedDB(ctx)
 	var tasks []models.MonitorTask
 	if err := db.Where("enabled = ?", true).Preload("User").Preload("Targets").Find(&tasks).Error; err != nil {
-		log.Printf("[监控服务] 查询任务失败: %v", err)
+		serviceLogger().Error("查询任务失败", slog.Any(logging.KeyError, err))
 		return
 	}
 
@@ -117,12 +143,12 @@ func (s *MonitorService) checkTasks() {
 			nextRunAt = task.LastCheck.Add(time.Duration(interval) * time.Second)
 		}
 		if task.BackoffUntil != nil && task.BackoffUntil.After(now) {
-			s.updateTaskQueueState(task.ID, "backoff", task.BackoffReason, *task.BackoffUntil)
-			s.addLog(task.ID, "warning", fmt.Sprintf("任务处于退避队列，等待至 %s 后自动恢复", task.BackoffUntil.Format(time.RFC3339)))
+			s.updateTaskQueueState(ctx, task.ID, "backoff", task.BackoffReason, *task.BackoffUntil)
+			s.addLog(ctx, task.ID, "warning", fmt.Sprintf("任务处于退避队列，等待至 %s 后自动恢复", task.BackoffUntil.Format(time.RFC3339)))
 			continue
 		}
 		if !task.LastCheck.IsZero() && now.Sub(task.LastCheck) < time.Duration(interval)*time.Second {
-			s.updateNextRun(task.ID, nextRunAt)
+			s.updateNextRun(ctx, task.ID, nextRunAt)
 			continue
 		}
 		if !task.User.Login {
@@ -133,7 +159,7 @@ func (s *MonitorService) checkTasks() {
 				"next_run_at":      now.Add(time.Duration(interval) * time.Second),
 				"progress_message": "用户未登录，等待账号恢复",
 			})
-			s.addLog(task.ID, "error", "用户未登录，跳过监控")
+			s.addLog(ctx, task.ID, "error", "用户未登录，跳过监控")
 			continue
 		}
 
@@ -159,13 +185,47 @@ func (s *MonitorService) checkTasks() {
 	}
 }
 
+// taskRun 保存一次任务执行期间各UP主、视频共享的依赖和累计统计。
+type taskRun struct {
+	task      models.MonitorTask
+	client    *bili.BiliClient
+	engine    *rules.Engine
+	whitelist white.Matcher
+	// trust 是自动跳过 UP 主本人、UP 主互动过和高等级粉丝评论的设置
+	trust     white.TrustOptions
+	duplicate copypasta.Config
+	// classifier 是任务设置了分类器阈值时使用的文本分类器，未设置或没有可用模型时为 nil
+	classifier *classifier.Model
+	// watchlist 是重点关注用户，extraEngines 是 extra_rules 条目引用的规则集编译结果，按规则集ID索引
+	watchlist    watchlist.Matcher
+	extraEngines map[uint]*rules.Engine
+	checked      int64
+	matched      int64
+	reported     int64
+	lastErr      string
+}
+
+// targetRun 保存单个UP主目标在本次执行中的统计。
+type targetRun struct {
+	target   models.MonitorTarget
+	checked  int64
+	matched  int64
+	reported int64
+	err      string
+}
+
 func (s *MonitorService) monitorTask(ctx context.Context, taskID uint) {
-	db := database.GetDB()
+	ctx, span := telemetry.StartSpan(ctx, "monitor.run", attribute.Int64("task_id", int64(taskID)))
+	defer span.End()
+
+	db := tracedDB(ctx)
 	var task models.MonitorTask
 	if err := db.Preload("User").Preload("Targets").First(&task, taskID).Error; err != nil {
-		log.Printf("[监控任务 %d] 任务不存在: %v", taskID, err)
+		taskLogger(taskID).Warn("任务不存在", slog.Any(logging.KeyError, err))
+		telemetry.RecordError(span, err)
 		return
 	}
+	span.SetAttributes(attribute.Int("targets", len(task.Targets)))
 
 	startedAt := time.Now()
 	db.Model(&task).Updates(map[string]interface{}{
@@ -181,150 +241,247 @@ func (s *MonitorService) monitorTask(ctx context.Context, taskID uint) {
 	})
 
 	if len(task.Targets) == 0 {
-		s.finishTask(task.ID, "warning", "未配置监控UP主", 0, 0, 0)
-		s.addLog(task.ID, "warning", "未配置监控UP主，跳过")
+		s.finishTask(ctx, task.ID, "warning", "未配置监控UP主", 0, 0, 0)
+		s.addLog(ctx, task.ID, "warning", "未配置监控UP主，跳过")
 		return
 	}
 
 	cookies, err := secure.DecryptString(task.User.Cookies)
 	if err != nil {
-		s.finishTask(task.ID, "error", "Cookie解密失败: "+err.Error(), 0, 0, 0)
-		s.addLog(task.ID, "error", "Cookie解密失败: "+err.Error())
+		s.finishTask(ctx, task.ID, "error", "Cookie解密失败: "+err.Error(), 0, 0, 0)
+		s.addLog(ctx, task.ID, "error", "Cookie解密失败: "+err.Error())
+		telemetry.RecordError(span, err)
 		return
 	}
 
-	compiledRules, compileErrors := s.compiledRulesForTask(task)
+	compiledRules, compileErrors := ruleset.CompileForTask(tracedDB(ctx), task)
 	for _, compileErr := range compileErrors {
-		s.addLog(task.ID, "warning", "规则编译失败: "+compileErr.Error())
+		s.addLog(ctx, task.ID, "warning", "规则编译失败: "+compileErr.Error())
 	}
-	if len(compiledRules) == 0 {
-		s.finishTask(task.ID, "warning", "未设置可用关键字规则", 0, 0, 0)
-		s.addLog(task.ID, "warning", "未设置可用关键字规则，跳过监控")
+	if len(compiledRules) == 0 && !task.NearDuplicate && task.ClassifierThreshold <= 0 {
+		s.finishTask(ctx, task.ID, "warning", "未设置可用关键字规则", 0, 0, 0)
+		s.addLog(ctx, task.ID, "warning", "未设置可用关键字规则，跳过监控")
 		return
 	}
 
-	client := newClientForTask(task, cookies)
-	whitelistMatcher := s.loadWhitelistMatcher()
-
-	log.Printf("[监控任务 %d] 开始监控 %d 个UP主", task.ID, len(task.Targets))
-	s.addLog(task.ID, "info", fmt.Sprintf("开始监控 %d 个UP主", len(task.Targets)))
+	run := &taskRun{
+		task:      task,
+		client:    newClientForTask(task, cookies),
+		engine:    rules.NewEngine(compiledRules),
+		whitelist: s.loadWhitelistMatcher(ctx, task.ID),
+		trust:     white.TrustOptionsFor(task),
+	}
+	if task.NearDuplicate {
+		run.duplicate = copypasta.ConfigFromSettings()
+	}
+	if task.ClassifierThreshold > 0 {
+		run.classifier = s.loadClassifier(ctx, task.ID)
+	}
+	run.watchlist, run.extraEngines = s.loadWatchlist(ctx, task.ID)
 
-	var checked int64
-	var matched int64
-	var reported int64
-	var lastErr string
+	taskLogger(task.ID).Info("开始监控", "targets", len(task.Targets), logging.KeyAccountID, task.UserID)
+	s.addLog(ctx, task.ID, "info", fmt.Sprintf("开始监控 %d 个UP主", len(task.Targets)))
 
 	for _, target := range task.Targets {
-		s.updateTaskProgress(task.ID, int64(len(task.Targets)), checkedTargets(task.Targets, target.ID), fmt.Sprintf("正在处理UP主 %s(%d)", target.Uname, target.UID))
-		var targetChecked int64
-		var targetMatched int64
-		var targetReported int64
-		var targetErr string
-		s.updateTargetStatus(target.ID, "running", "", 0, 0, 0)
+		if !s.monitorTarget(ctx, run, target) {
+			return
+		}
+	}
+
+	status := "success"
+	if run.lastErr != "" {
+		status = "warning"
+	}
+	span.SetAttributes(
+		attribute.Int64("checked", run.checked),
+		attribute.Int64("matched", run.matched),
+		attribute.Int64("reported", run.reported),
+	)
+	s.finishTask(ctx, task.ID, status, run.lastErr, run.checked, run.matched, run.reported)
+	if run.lastErr != "" {
+		s.notifyMonitorError(ctx, task, run.lastErr)
+	}
+	s.addLog(ctx, task.ID, "info", fmt.Sprintf("监控完成：检测 %d 条，匹配 %d 条，成功举报 %d 条", run.checked, run.matched, run.reported))
+}
+
+// monitorTarget 处理单个UP主的最新视频；返回 false 表示任务已结束（取消或被迫停止）。
+func (s *MonitorService) monitorTarget(ctx context.Context, run *taskRun, target models.MonitorTarget) bool {
+	ctx, span := telemetry.StartSpan(ctx, "monitor.target",
+		attribute.Int64("task_id", int64(run.task.ID)),
+		attribute.Int64("target_uid", target.UID),
+	)
+	defer span.End()
+
+	task := run.task
+	tr := &targetRun{target: target}
+	s.updateTaskProgress(ctx, task.ID, int64(len(task.Targets)), checkedTargets(task.Targets, target.ID), fmt.Sprintf("正在处理UP主 %s(%d)", target.Uname, target.UID))
+	s.updateTargetStatus(ctx, target.ID, "running", "", 0, 0, 0)
+
+	if ctx.Err() != nil {
+		s.cancelRun(ctx, run, tr)
+		return false
+	}
+	videos, err := run.client.GetUserVideosContext(ctx, target.UID, task.VideoCount)
+	if err != nil {
+		run.lastErr = fmt.Sprintf("获取UP主 %s(%d) 视频失败: %v", target.Uname, target.UID, err)
+		tr.err = run.lastErr
+		taskLogger(task.ID).Warn("获取UP主视频失败", logging.KeyTargetUID, target.UID, slog.Any(logging.KeyError, err))
+		telemetry.RecordError(span, err)
+		s.addLog(ctx, task.ID, "error", run.lastErr)
+		s.updateTargetStatus(ctx, target.ID, "warning", tr.err, tr.checked, tr.matched, tr.reported)
+		return true
+	}
+
+	for videoIndex, video := range videos {
+		if !s.monitorVideo(ctx, run, tr, video, videoIndex, len(videos)) {
+			return false
+		}
+	}
+	targetStatus := "success"
+	if tr.err != "" {
+		targetStatus = "warning"
+	}
+	s.updateTargetStatus(ctx, target.ID, targetStatus, tr.err, tr.checked, tr.matched, tr.reported)
+	s.updateTaskProgress(ctx, task.ID, int64(len(task.Targets)), checkedTargets(task.Targets, target.ID)+1, fmt.Sprintf("UP主 %s 处理完成", target.Uname))
+	return true
+}
+
+// monitorVideo 拉取并匹配单个视频的评论；返回 false 表示任务已结束。
+func (s *MonitorService) monitorVideo(ctx context.Context, run *taskRun, tr *targetRun, video bili.VideoInfo, videoIndex, videoTotal int) bool {
+	if ctx.Err() != nil {
+		s.cancelRun(ctx, run, tr)
+		return false
+	}
+	ctx, span := telemetry.StartSpan(ctx, "monitor.video",
+		attribute.Int64("task_id", int64(run.task.ID)),
+		attribute.Int64("target_uid", tr.target.UID),
+		attribute.String("bvid", video.BVID),
+		attribute.Int64("aid", video.AID),
+	)
+	defer span.End()
+
+	task := run.task
+	s.updateTaskProgress(ctx, task.ID, int64(len(task.Targets)), checkedTargets(task.Targets, tr.target.ID), fmt.Sprintf("UP主 %s：读取视频 %d/%d 评论", tr.target.Uname, videoIndex+1, videoTotal))
+	comments, err := run.client.GetVideoCommentsContext(ctx, video.AID, task.CommentCount)
+	if err != nil {
+		run.lastErr = fmt.Sprintf("获取视频 %s 评论失败: %v", video.BVID, err)
+		tr.err = run.lastErr
+		taskLogger(task.ID).Warn("获取视频评论失败", logging.KeyTargetUID, tr.target.UID, logging.KeyBVID, video.BVID, slog.Any(logging.KeyError, err))
+		telemetry.RecordError(span, err)
+		s.addLog(ctx, task.ID, "error", run.lastErr)
+		return true
+	}
+	span.SetAttributes(attribute.Int("comments", len(comments)))
 
+	for _, comment := range comments {
 		if ctx.Err() != nil {
-			s.updateTargetStatus(target.ID, "warning", "任务已取消", targetChecked, targetMatched, targetReported)
-			s.finishTask(task.ID, "warning", "任务已取消", checked, matched, reported)
-			s.addLog(task.ID, "warning", "任务已取消")
-			return
+			s.cancelRun(ctx, run, tr)
+			return false
 		}
-		videos, err := client.GetUserVideosContext(ctx, target.UID, task.VideoCount)
-		if err != nil {
-			lastErr = fmt.Sprintf("获取UP主 %s(%d) 视频失败: %v", target.Uname, target.UID, err)
-			targetErr = lastErr
-			log.Printf("[监控任务 %d] %s", task.ID, lastErr)
-			s.addLog(task.ID, "error", lastErr)
-			s.updateTargetStatus(target.ID, "warning", targetErr, targetChecked, targetMatched, targetReported)
+		run.checked++
+		tr.checked++
+		commenter := rules.CommenterFromComment(comment)
+		matches := run.engine.MatchAllFor(comment.Content.Message, commenter)
+		if run.engine.HasFlood() {
+			matches = append(matches, s.matchFlood(run, tr.target, video, comment, commenter)...)
+		}
+		if run.whitelist.Contains(white.Scope{TaskID: task.ID, TargetUID: tr.target.UID}, comment.Member.Mid, comment.Member.Uname) {
+			// 白名单用户的评论照常匹配，只为统计规则因白名单被跳过的次数
+			s.recordCommentRuleStats(ctx, comment.RPID, rulestats.KindWhitelistSkip, matchRuleIDs(matches), rulestats.Counters{WhitelistSkips: 1})
 			continue
 		}
-
-		for videoIndex, video := range videos {
-			if ctx.Err() != nil {
-				s.updateTargetStatus(target.ID, "warning", "任务已取消", targetChecked, targetMatched, targetReported)
-				s.finishTask(task.ID, "warning", "任务已取消", checked, matched, reported)
-				s.addLog(task.ID, "warning", "任务已取消")
-				return
+		watched, isWatched := run.watchlist.Lookup(comment.Member.Mid)
+		// 重点关注用户只能由白名单豁免，不按可信评论跳过
+		if reason := run.trust.Trusted(tr.target.UID, comment); reason != "" && !isWatched {
+			// 可信评论与白名单一样跳过，命中的规则同样计入白名单跳过次数
+			if len(matches) > 0 {
+				taskLogger(task.ID).Debug("跳过可信评论", logging.KeyRPID, comment.RPID, "reason", reason)
 			}
-			s.updateTaskProgress(task.ID, int64(len(task.Targets)), checkedTargets(task.Targets, target.ID), fmt.Sprintf("UP主 %s：读取视频 %d/%d 评论", target.Uname, videoIndex+1, len(videos)))
-			comments, err := client.GetVideoCommentsContext(ctx, video.AID, task.CommentCount)
-			if err != nil {
-				lastErr = fmt.Sprintf("获取视频 %s 评论失败: %v", video.BVID, err)
-				targetErr = lastErr
-				log.Printf("[监控任务 %d] %s", task.ID, lastErr)
-				s.addLog(task.ID, "error", lastErr)
-				continue
+			s.recordCommentRuleStats(ctx, comment.RPID, rulestats.KindWhitelistSkip, matchRuleIDs(matches), rulestats.Counters{WhitelistSkips: 1})
+			continue
+		}
+		if task.NearDuplicate {
+			if match, ok := s.observeDuplicate(ctx, run, tr.target, video, comment); ok {
+				matches = append(matches, match)
 			}
-
-			for _, comment := range comments {
-				if ctx.Err() != nil {
-					s.updateTargetStatus(target.ID, "warning", "任务已取消", targetChecked, targetMatched, targetReported)
-					s.finishTask(task.ID, "warning", "任务已取消", checked, matched, reported)
-					s.addLog(task.ID, "warning", "任务已取消")
-					return
-				}
-				checked++
-				targetChecked++
-				if whitelistMatcher.Contains(comment.Member.Mid, comment.Member.Uname) {
-					continue
-				}
-
-				match := rules.MatchText(comment.Content.Message, compiledRules)
-				if match == nil {
-					continue
-				}
-				matched++
-				targetMatched++
-				s.markRuleMatched(match.RuleID)
-				s.addLog(task.ID, "warning", fmt.Sprintf("发现匹配评论，规则: %s", match.RuleName))
-
-				outcome := s.reportComment(ctx, task, target, video, comment, *match, client)
-				if outcome.success {
-					reported++
-					targetReported++
-				}
-				if outcome.stopTask {
-					targetErr = outcome.message
-					status := "error"
-					if outcome.status != "" {
-						status = outcome.status
-					}
-					s.updateTargetStatus(target.ID, status, targetErr, targetChecked, targetMatched, targetReported)
-					s.finishTask(task.ID, status, targetErr, checked, matched, reported)
-					s.addLog(task.ID, "error", targetErr)
-					return
-				}
+		}
+		if run.classifier != nil {
+			if probability, ok := run.classifier.Probability(comment.Content.Message); ok && probability >= task.ClassifierThreshold {
+				matches = append(matches, classifier.Match(probability))
 			}
 		}
-		targetStatus := "success"
-		if targetErr != "" {
-			targetStatus = "warning"
+		forced := false
+		if isWatched {
+			matches, forced = s.applyWatchlist(ctx, run, video, comment, commenter, watched, matches)
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		score := rules.TotalScore(matches)
+		if !forced && !rules.ReachesThreshold(score, task.ScoreThreshold) {
+			taskLogger(task.ID).Debug("评论得分未达到阈值", logging.KeyRPID, comment.RPID, "score", score, "threshold", task.ScoreThreshold)
+			continue
+		}
+		run.matched++
+		tr.matched++
+		for _, match := range matches {
+			s.markRuleMatched(ctx, match.RuleID)
+		}
+		s.recordCommentRuleStats(ctx, comment.RPID, rulestats.KindMatch, matchRuleIDs(matches), rulestats.Counters{Matches: 1})
+		if task.ScoreThreshold > 0 {
+			s.addLog(ctx, task.ID, "warning", fmt.Sprintf("发现匹配评论，规则: %s，得分 %g/%g", matches[0].RuleName, score, task.ScoreThreshold))
+		} else {
+			s.addLog(ctx, task.ID, "warning", fmt.Sprintf("发现匹配评论，规则: %s", matches[0].RuleName))
 		}
-		s.updateTargetStatus(target.ID, targetStatus, targetErr, targetChecked, targetMatched, targetReported)
-		s.updateTaskProgress(task.ID, int64(len(task.Targets)), checkedTargets(task.Targets, target.ID)+1, fmt.Sprintf("UP主 %s 处理完成", target.Uname))
-	}
 
-	status := "success"
-	if lastErr != "" {
-		status = "warning"
-	}
-	s.finishTask(task.ID, status, lastErr, checked, matched, reported)
-	if lastErr != "" {
-		s.notifyMonitorError(task, lastErr)
+		outcome := s.reportComment(ctx, task, tr.target, video, comment, matches, run.client)
+		if outcome.success {
+			run.reported++
+			tr.reported++
+		}
+		if outcome.stopTask {
+			tr.err = outcome.message
+			status := "error"
+			if outcome.status != "" {
+				status = outcome.status
+			}
+			s.updateTargetStatus(ctx, tr.target.ID, status, tr.err, tr.checked, tr.matched, tr.reported)
+			s.finishTask(ctx, task.ID, status, tr.err, run.checked, run.matched, run.reported)
+			s.addLog(ctx, task.ID, "error", tr.err)
+			return false
+		}
 	}
-	s.addLog(task.ID, "info", fmt.Sprintf("监控完成：检测 %d 条，匹配 %d 条，成功举报 %d 条", checked, matched, reported))
+	return true
+}
+
+// cancelRun 在上下文取消时记录当前进度并结束任务。
+func (s *MonitorService) cancelRun(ctx context.Context, run *taskRun, tr *targetRun) {
+	s.updateTargetStatus(ctx, tr.target.ID, "warning", "任务已取消", tr.checked, tr.matched, tr.reported)
+	s.finishTask(ctx, run.task.ID, "warning", "任务已取消", run.checked, run.matched, run.reported)
+	s.addLog(ctx, run.task.ID, "warning", "任务已取消")
 }
 
-func (s *MonitorService) reportComment(ctx context.Context, task models.MonitorTask, target models.MonitorTarget, video bili.VideoInfo, comment bili.CommentInfo, match rules.MatchResult, client *bili.BiliClient) reportOutcome {
-	db := database.GetDB()
+// reportComment 举报评论，matches 中第一条按规则顺序优先的命中作为主规则，全部命中计入得分明细。
+func (s *MonitorService) reportComment(ctx context.Context, task models.MonitorTask, target models.MonitorTarget, video bili.VideoInfo, comment bili.CommentInfo, matches []rules.MatchResult, client *bili.BiliClient) reportOutcome {
+	match := matches[0]
+	ctx, span := telemetry.StartSpan(ctx, "monitor.report",
+		attribute.Int64("task_id", int64(task.ID)),
+		attribute.String("bvid", video.BVID),
+		attribute.Int64("rpid", comment.RPID),
+		attribute.Int64("rule_id", int64(match.RuleID)),
+	)
+	defer span.End()
+
+	db := tracedDB(ctx)
 	var existingReport models.ReportRecord
 	if err := db.Where("task_id = ? AND comment_id = ?", task.ID, comment.RPID).First(&existingReport).Error; err == nil {
-		log.Printf("[监控任务 %d] 评论已举报过，跳过: %d", task.ID, comment.RPID)
+		taskLogger(task.ID).Debug("评论已举报过，跳过", logging.KeyBVID, video.BVID, logging.KeyRPID, comment.RPID)
 		return reportOutcome{}
 	}
-	if reached, count, limit := s.accountDailyReportLimitReached(task); reached {
+	if reached, count, limit := s.accountDailyReportLimitReached(ctx, task); reached {
 		message := fmt.Sprintf("账号今日成功举报已达上限 %d/%d，跳过评论 %d", count, limit, comment.RPID)
-		log.Printf("[监控任务 %d] %s", task.ID, message)
-		s.addLog(task.ID, "warning", message)
+		taskLogger(task.ID).Warn("账号今日成功举报已达上限", logging.KeyAccountID, task.UserID, "count", count, "limit", limit, logging.KeyRPID, comment.RPID)
+		s.addLog(ctx, task.ID, "warning", message)
 		return reportOutcome{}
 	}
 
@@ -334,67 +491,92 @@ func (s *MonitorService) reportComment(ctx context.Context, task models.MonitorT
 	}
 	if !s.reportLimiter.Wait(ctx, delay) {
 		message := fmt.Sprintf("举报评论 %d 前任务已取消", comment.RPID)
-		s.addLog(task.ID, "warning", message)
+		s.addLog(ctx, task.ID, "warning", message)
 		return reportOutcome{stopTask: true, status: "warning", message: message}
 	}
 
 	err := client.ReportCommentContext(ctx, video.AID, comment.RPID, 11)
 	report := models.ReportRecord{
-		TaskID:          task.ID,
-		TargetUID:       target.UID,
-		TargetUname:     target.Uname,
-		AVID:            video.AID,
-		BVID:            video.BVID,
-		VideoTitle:      video.Title,
-		CommentID:       comment.RPID,
-		CommentContent:  comment.Content.Message,
-		CommentUser:     comment.Member.Uname,
-		CommentUserID:   comment.Member.Mid,
-		MatchedKeyword:  match.Matched,
-		KeywordRuleName: match.RuleName,
-		MatchType:       match.MatchType,
-		Reason:          11,
-		Success:         err == nil,
+		TaskID:              task.ID,
+		TargetUID:           target.UID,
+		TargetUname:         target.Uname,
+		AVID:                video.AID,
+		BVID:                video.BVID,
+		VideoTitle:          video.Title,
+		CommentID:           comment.RPID,
+		CommentContent:      comment.Content.Message,
+		CommentUser:         comment.Member.Uname,
+		CommentUserID:       comment.Member.Mid,
+		MatchedKeyword:      match.Matched,
+		KeywordRuleName:     match.RuleName,
+		KeywordRuleRevision: match.Revision,
+		MatchType:           match.MatchType,
+		Score:               rules.TotalScore(matches),
+		MatchedRules:        rules.FormatContributions(matches),
+		Evidence:            rules.FormatEvidence(matches),
+		MatchSpans:          rules.FormatExplanations(matches),
+		Reason:              11,
+		Success:             err == nil,
 	}
 	if match.RuleID > 0 {
 		report.KeywordRuleID = &match.RuleID
 	}
+	for _, m := range matches {
+		if m.ClusterID > 0 {
+			clusterID := m.ClusterID
+			report.ClusterID = &clusterID
+			break
+		}
+	}
 
 	outcome := reportOutcome{}
 	if err != nil {
+		telemetry.RecordError(span, err)
 		report.Message = err.Error()
-		log.Printf("[监控任务 %d] 举报失败: %v", task.ID, err)
-		s.addLog(task.ID, "error", fmt.Sprintf("举报失败: %v", err))
+		taskLogger(task.ID).Warn("举报失败", logging.KeyTargetUID, target.UID, logging.KeyBVID, video.BVID, logging.KeyRPID, comment.RPID, logging.KeyRuleID, match.RuleID, slog.Any(logging.KeyError, err))
+		s.addLog(ctx, task.ID, "error", fmt.Sprintf("举报失败: %v", err))
 		if bili.IsRiskControlError(err) {
-			message := s.scheduleBackoff(task, err.Error())
+			message := s.scheduleBackoff(ctx, task, err.Error())
 			outcome.stopTask = true
 			outcome.status = "backoff"
 			outcome.message = message
-			s.addLog(task.ID, "error", message)
-			s.notifyMonitorError(task, message)
+			s.addLog(ctx, task.ID, "error", message)
+			s.notifyMonitorError(ctx, task, message)
 		}
 	} else {
 		report.Message = "举报成功"
-		log.Printf("[监控任务 %d] 举报成功: 评论ID %d", task.ID, comment.RPID)
-		s.addLog(task.ID, "info", fmt.Sprintf("举报成功: 评论ID %d", comment.RPID))
+		taskLogger(task.ID).Info("举报成功", logging.KeyTargetUID, target.UID, logging.KeyBVID, video.BVID, logging.KeyRPID, comment.RPID, logging.KeyRuleID, match.RuleID)
+		s.addLog(ctx, task.ID, "info", fmt.Sprintf("举报成功: 评论ID %d", comment.RPID))
 	}
 
+	attempt := rulestats.Counters{ReportsAttempted: 1}
+	if report.Success {
+		attempt.ReportsSucceeded = 1
+	}
+	s.recordRuleStats(ctx, time.Now(), matchRuleIDs(matches), attempt)
+
 	if err := db.Create(&report).Error; err != nil {
-		log.Printf("[监控任务 %d] 保存举报记录失败: %v", task.ID, err)
+		taskLogger(task.ID).Error("保存举报记录失败", logging.KeyRPID, comment.RPID, slog.Any(logging.KeyError, err))
 		return reportOutcome{stopTask: outcome.stopTask, message: outcome.message}
 	}
+	if report.ClusterID != nil {
+		s.markClusterMemberReported(ctx, report)
+	}
 	if report.Success {
 		outcome.success = true
+		s.promoteOffender(ctx, report)
+		// 通知在后台发送，保留追踪上下文但不随任务取消而中断
+		notifyCtx := context.WithoutCancel(ctx)
 		go func(record models.ReportRecord) {
-			if err := notify.NewSender().SendReport(record); err != nil {
-				log.Printf("[Webhook] 发送失败: %v", err)
+			if err := notify.NewSender().SendReportContext(notifyCtx, record); err != nil {
+				logging.For("webhook").Warn("举报通知发送失败", logging.KeyTaskID, record.TaskID, logging.KeyRPID, record.CommentID, slog.Any(logging.KeyError, err))
 			}
 		}(report)
 	}
 	return outcome
 }
 
-func (s *MonitorService) accountDailyReportLimitReached(task models.MonitorTask) (bool, int64, int) {
+func (s *MonitorService) accountDailyReportLimitReached(ctx context.Context, task models.MonitorTask) (bool, int64, int) {
 	limit := task.DailyReportLimit
 	if limit <= 0 {
 		limit = settings.GetInt("default_daily_report_limit", 100)
@@ -406,19 +588,19 @@ func (s *MonitorService) accountDailyReportLimitReached(task models.MonitorTask)
 	now := time.Now()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	var count int64
-	if err := database.GetDB().
+	if err := tracedDB(ctx).
 		Model(&models.ReportRecord{}).
 		Joins("JOIN monitor_tasks ON monitor_tasks.id = report_records.task_id").
 		Where("monitor_tasks.user_id = ? AND report_records.success = ? AND report_records.created_at >= ?", task.UserID, true, startOfDay).
 		Count(&count).Error; err != nil {
-		log.Printf("[监控任务 %d] 查询每日举报上限失败: %v", task.ID, err)
+		taskLogger(task.ID).Error("查询每日举报上限失败", logging.KeyAccountID, task.UserID, slog.Any(logging.KeyError, err))
 		return false, 0, limit
 	}
 	return count >= int64(limit), count, limit
 }
 
-func (s *MonitorService) scheduleBackoff(task models.MonitorTask, reason string) string {
-	db := database.GetDB()
+func (s *MonitorService) scheduleBackoff(ctx context.Context, task models.MonitorTask, reason string) string {
+	db := tracedDB(ctx)
 	var latest models.MonitorTask
 	attempt := task.BackoffAttempt + 1
 	if err := db.Select("id", "backoff_attempt").First(&latest, task.ID).Error; err == nil {
@@ -436,7 +618,7 @@ func (s *MonitorService) scheduleBackoff(task models.MonitorTask, reason string)
 		"next_run_at":      until,
 		"progress_message": message,
 	}).Error; err != nil {
-		log.Printf("[监控任务 %d] 设置退避队列失败: %v", task.ID, err)
+		taskLogger(task.ID).Error("设置退避队列失败", slog.Any(logging.KeyError, err))
 	}
 	return message
 }
@@ -482,10 +664,10 @@ func (s *MonitorService) checkCookiesDue() {
 	}
 	refreshBefore := time.Now().Add(time.Duration(refreshInterval) * time.Second)
 
-	db := database.GetDB()
+	db := tracedDB(ctx)
 	var users []models.BiliUser
 	if err := db.Where("login = ? AND ((last_cookie_check IS NULL OR last_cookie_check < ?) OR expire_time < ?)", true, cutoff, refreshBefore).Limit(10).Find(&users).Error; err != nil {
-		log.Printf("[Cookie检查] 查询用户失败: %v", err)
+		logging.For("cookie_check").Error("查询用户失败", slog.Any(logging.KeyError, err))
 		return
 	}
 
@@ -505,7 +687,7 @@ func (s *MonitorService) checkCookiesDue() {
 			updates["cookie_message"] = "Cookie解密失败: " + err.Error()
 			db.Model(&user).Updates(updates)
 			if previousStatus != "invalid" {
-				s.notifyCookieInvalid(user, updates["cookie_message"].(string))
+				s.notifyCookieInvalid(ctx, user, updates["cookie_message"].(string))
 			}
 			continue
 		}
@@ -526,75 +708,106 @@ func (s *MonitorService) checkCookiesDue() {
 		}
 		db.Model(&user).Updates(updates)
 		if updates["cookie_status"] == "invalid" && previousStatus != "invalid" {
-			s.notifyCookieInvalid(user, updates["cookie_message"].(string))
-			s.markUserTasksCookieInvalid(user.ID, updates["cookie_message"].(string))
+			s.notifyCookieInvalid(ctx, user, updates["cookie_message"].(string))
+			s.markUserTasksCookieInvalid(ctx, user.ID, updates["cookie_message"].(string))
 		}
 	}
 }
 
-func (s *MonitorService) notifyCookieInvalid(user models.BiliUser, message string) {
+func (s *MonitorService) notifyCookieInvalid(ctx context.Context, user models.BiliUser, message string) {
+	ctx = context.WithoutCancel(ctx)
 	go func() {
-		if err := notify.NewSender().SendCookieInvalid(user, message); err != nil {
-			log.Printf("[Webhook] Cookie失效通知发送失败: %v", err)
+		if err := notify.NewSender().SendCookieInvalidContext(ctx, user, message); err != nil {
+			logging.For("webhook").Warn("Cookie失效通知发送失败", logging.KeyAccountID, user.ID, slog.Any(logging.KeyError, err))
 		}
 	}()
 }
 
-func (s *MonitorService) notifyMonitorError(task models.MonitorTask, message string) {
+func (s *MonitorService) notifyMonitorError(ctx context.Context, task models.MonitorTask, message string) {
+	ctx = context.WithoutCancel(ctx)
 	go func() {
-		if err := notify.NewSender().SendMonitorError(task, message); err != nil {
-			log.Printf("[Webhook] 监控异常通知发送失败: %v", err)
+		if err := notify.NewSender().SendMonitorErrorContext(ctx, task, message); err != nil {
+			logging.For("webhook").Warn("监控异常通知发送失败", logging.KeyTaskID, task.ID, slog.Any(logging.KeyError, err))
 		}
 	}()
 }
 
-func (s *MonitorService) markUserTasksCookieInvalid(userID uint, message string) {
+func (s *MonitorService) markUserTasksCookieInvalid(ctx context.Context, userID uint, message string) {
 	if userID == 0 {
 		return
 	}
-	if err := database.GetDB().Model(&models.MonitorTask{}).
+	if err := tracedDB(ctx).Model(&models.MonitorTask{}).
 		Where("user_id = ? AND enabled = ?", userID, true).
 		Updates(map[string]interface{}{
 			"last_status":      "error",
 			"last_error":       "账号Cookie不可用: " + message,
 			"progress_message": "账号Cookie不可用，等待重新登录或恢复",
 		}).Error; err != nil {
-		log.Printf("[Cookie检查] 更新关联任务状态失败: %v", err)
+		logging.For("cookie_check").Error("更新关联任务状态失败", logging.KeyAccountID, userID, slog.Any(logging.KeyError, err))
 	}
 }
 
-func (s *MonitorService) compiledRulesForTask(task models.MonitorTask) ([]rules.CompiledRule, []error) {
-	db := database.GetDB()
-	var rows []models.KeywordRule
-	ruleIDs := rules.ParseRuleIDs(task.KeywordRuleIDs)
-	query := db.Where("enabled = ?", true)
-	if len(ruleIDs) > 0 {
-		query = query.Where("id IN ?", ruleIDs)
-	}
-	if err := query.Order("created_at ASC").Find(&rows).Error; err != nil {
-		return nil, []error{err}
+// loadWhitelistMatcher 加载对全部任务和对该任务生效的白名单，UP 主范围在匹配时判断。
+func (s *MonitorService) loadWhitelistMatcher(ctx context.Context, taskID uint) white.Matcher {
+	var rows []models.WhitelistUser
+	if err := tracedDB(ctx).Where("enabled = ? AND task_id IN ?", true, []uint{0, taskID}).Find(&rows).Error; err != nil {
+		logging.For("whitelist").Error("加载白名单失败", logging.KeyTaskID, taskID, slog.Any(logging.KeyError, err))
+		return white.NewMatcher(nil, time.Now())
 	}
-	return rules.CompileMany(rows, task.Keywords)
+	return white.NewMatcher(rows, time.Now())
 }
 
-func (s *MonitorService) loadWhitelistMatcher() white.Matcher {
-	var rows []models.WhitelistUser
-	if err := database.GetDB().Where("enabled = ?", true).Find(&rows).Error; err != nil {
-		log.Printf("[白名单] 加载失败: %v", err)
-		return white.NewMatcher(nil)
+// loadClassifier 返回正在使用的文本分类器，没有训练好的模型时记录警告并返回 nil。
+func (s *MonitorService) loadClassifier(ctx context.Context, taskID uint) *classifier.Model {
+	model, err := s.classifiers.Active(tracedDB(ctx))
+	if err != nil {
+		logging.For("classifier").Error("加载文本分类器失败", logging.KeyTaskID, taskID, slog.Any(logging.KeyError, err))
+		s.addLog(ctx, taskID, "warning", "加载文本分类器失败，本轮不使用分类器: "+err.Error())
+		return nil
+	}
+	if model == nil {
+		s.addLog(ctx, taskID, "warning", "尚未训练文本分类器，本轮不使用分类器")
 	}
-	return white.NewMatcher(rows)
+	return model
 }
 
-func (s *MonitorService) markRuleMatched(ruleID uint) {
+func (s *MonitorService) markRuleMatched(ctx context.Context, ruleID uint) {
 	if ruleID == 0 {
 		return
 	}
 	now := time.Now()
-	database.GetDB().Model(&models.KeywordRule{}).Where("id = ?", ruleID).Update("last_matched_at", now)
+	tracedDB(ctx).Model(&models.KeywordRule{}).Where("id = ?", ruleID).Update("last_matched_at", now)
+}
+
+// recordRuleStats 累加规则的每日统计，失败只记日志，不影响监控流程。
+func (s *MonitorService) recordRuleStats(ctx context.Context, at time.Time, ruleIDs []uint, delta rulestats.Counters) {
+	if len(ruleIDs) == 0 {
+		return
+	}
+	if err := rulestats.Add(tracedDB(ctx), at, ruleIDs, delta); err != nil {
+		logging.For("rule_stats").Warn("更新规则统计失败", slog.Any(logging.KeyError, err))
+	}
+}
+
+// recordCommentRuleStats 按评论去重累加命中或白名单跳过次数，同一条评论重复拉取时不再计数。
+func (s *MonitorService) recordCommentRuleStats(ctx context.Context, rpid int64, kind string, ruleIDs []uint, delta rulestats.Counters) {
+	if len(ruleIDs) == 0 {
+		return
+	}
+	if err := rulestats.AddOnce(tracedDB(ctx), time.Now(), rpid, kind, ruleIDs, delta); err != nil {
+		logging.For("rule_stats").Warn("更新规则统计失败", slog.Any(logging.KeyError, err))
+	}
+}
+
+func matchRuleIDs(matches []rules.MatchResult) []uint {
+	ids := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.RuleID)
+	}
+	return ids
 }
 
-func (s *MonitorService) finishTask(taskID uint, status, lastErr string, checked, matched, reported int64) {
+func (s *MonitorService) finishTask(ctx context.Context, taskID uint, status, lastErr string, checked, matched, reported int64) {
 	updates := map[string]interface{}{
 		"last_status":      status,
 		"last_error":       lastErr,
@@ -606,7 +819,7 @@ func (s *MonitorService) finishTask(taskID uint, status, lastErr string, checked
 	if status == "backoff" {
 		updates["progress_message"] = lastErr
 	} else {
-		updates["next_run_at"] = s.nextRunAt(taskID, time.Now())
+		updates["next_run_at"] = s.nextRunAt(ctx, taskID, time.Now())
 	}
 	if status == "success" || status == "warning" {
 		now := time.Now()
@@ -615,13 +828,13 @@ func (s *MonitorService) finishTask(taskID uint, status, lastErr string, checked
 		updates["backoff_reason"] = ""
 		updates["backoff_attempt"] = 0
 	}
-	database.GetDB().Model(&models.MonitorTask{}).Where("id = ?", taskID).Updates(updates)
+	tracedDB(ctx).Model(&models.MonitorTask{}).Where("id = ?", taskID).Updates(updates)
 }
 
-func (s *MonitorService) nextRunAt(taskID uint, from time.Time) time.Time {
+func (s *MonitorService) nextRunAt(ctx context.Context, taskID uint, from time.Time) time.Time {
 	interval := settings.GetInt("default_interval", 300)
 	var task models.MonitorTask
-	if err := database.GetDB().Select("id", "interval").First(&task, taskID).Error; err == nil && task.Interval > 0 {
+	if err := tracedDB(ctx).Select("id", "interval").First(&task, taskID).Error; err == nil && task.Interval > 0 {
 		interval = task.Interval
 	}
 	if interval < 30 {
@@ -630,12 +843,12 @@ func (s *MonitorService) nextRunAt(taskID uint, from time.Time) time.Time {
 	return from.Add(time.Duration(interval) * time.Second)
 }
 
-func (s *MonitorService) updateNextRun(taskID uint, nextRunAt time.Time) {
-	database.GetDB().Model(&models.MonitorTask{}).Where("id = ?", taskID).Update("next_run_at", nextRunAt)
+func (s *MonitorService) updateNextRun(ctx context.Context, taskID uint, nextRunAt time.Time) {
+	tracedDB(ctx).Model(&models.MonitorTask{}).Where("id = ?", taskID).Update("next_run_at", nextRunAt)
 }
 
-func (s *MonitorService) updateTaskQueueState(taskID uint, status, reason string, nextRunAt time.Time) {
-	database.GetDB().Model(&models.MonitorTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+func (s *MonitorService) updateTaskQueueState(ctx context.Context, taskID uint, status, reason string, nextRunAt time.Time) {
+	tracedDB(ctx).Model(&models.MonitorTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
 		"last_status":      status,
 		"last_error":       reason,
 		"next_run_at":      nextRunAt,
@@ -643,7 +856,7 @@ func (s *MonitorService) updateTaskQueueState(taskID uint, status, reason string
 	})
 }
 
-func (s *MonitorService) updateTaskProgress(taskID uint, total, done int64, message string) {
+func (s *MonitorService) updateTaskProgress(ctx context.Context, taskID uint, total, done int64, message string) {
 	if total < 0 {
 		total = 0
 	}
@@ -653,14 +866,14 @@ func (s *MonitorService) updateTaskProgress(taskID uint, total, done int64, mess
 	if total > 0 && done > total {
 		done = total
 	}
-	database.GetDB().Model(&models.MonitorTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+	tracedDB(ctx).Model(&models.MonitorTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
 		"progress_total":   total,
 		"progress_done":    done,
 		"progress_message": message,
 	})
 }
 
-func (s *MonitorService) updateTargetStatus(targetID uint, status, lastErr string, checked, matched, reported int64) {
+func (s *MonitorService) updateTargetStatus(ctx context.Context, targetID uint, status, lastErr string, checked, matched, reported int64) {
 	if targetID == 0 {
 		return
 	}
@@ -672,11 +885,11 @@ func (s *MonitorService) updateTargetStatus(targetID uint, status, lastErr strin
 		"matched_comments": gorm.Expr("matched_comments + ?", matched),
 		"report_count":     gorm.Expr("report_count + ?", reported),
 	}
-	database.GetDB().Model(&models.MonitorTarget{}).Where("id = ?", targetID).Updates(updates)
+	tracedDB(ctx).Model(&models.MonitorTarget{}).Where("id = ?", targetID).Updates(updates)
 }
 
-func (s *MonitorService) addLog(taskID uint, level, message string) {
-	db := database.GetDB()
+func (s *MonitorService) addLog(ctx context.Context, taskID uint, level, message string) {
+	db := tracedDB(ctx)
 	now := time.Now()
 	digest := logDigest(taskID, level, message)
 	window := settings.GetInt("log_dedupe_window_seconds", 300)
@@ -689,7 +902,7 @@ func (s *MonitorService) addLog(taskID uint, level, message string) {
 				"repeat_count": gorm.Expr("repeat_count + 1"),
 				"last_seen_at": now,
 			}).Error; err != nil {
-				log.Printf("[监控服务] 更新日志重复次数失败: %v", err)
+				taskLogger(taskID).Error("更新日志重复次数失败", slog.Any(logging.KeyError, err))
 			}
 			return
 		}
@@ -703,8 +916,26 @@ func (s *MonitorService) addLog(taskID uint, level, message string) {
 		LastSeenAt:  &now,
 	}
 	if err := db.Create(&logEntry).Error; err != nil {
-		log.Printf("[监控服务] 保存日志失败: %v", err)
+		taskLogger(taskID).Error("保存日志失败", slog.Any(logging.KeyError, err))
+	}
+}
+
+// serviceLogger 返回监控服务的结构化日志记录器。
+func serviceLogger() *slog.Logger {
+	return logging.For("monitor")
+}
+
+func taskLogger(taskID uint) *slog.Logger {
+	return serviceLogger().With(logging.KeyTaskID, taskID)
+}
+
+// tracedDB 返回携带追踪上下文的数据库会话。任务取消后仍需写回状态和日志，
+// 因此只保留 span 信息而剥离取消信号。
+func tracedDB(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return database.GetDB()
 	}
+	return database.GetDB().WithContext(context.WithoutCancel(ctx))
 }
 
 func checkedTargets(targets []models.MonitorTarget, currentID uint) int64 {
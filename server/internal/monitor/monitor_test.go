@@ -2,8 +2,14 @@ package monitor
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+	"gorm.io/gorm"
 )
 
 func TestReportLimiterWaitReturnsFalseWhenContextCancelled(t *testing.T) {
@@ -21,6 +27,10 @@ func TestReportLimiterWaitReturnsFalseWhenContextCancelled(t *testing.T) {
 }
 
 func TestMonitorServiceBeginTaskRespectsStop(t *testing.T) {
+	// NewMonitorService 会加载并缓存配置，未设置时默认在 ./data 下生成密钥文件
+	t.Setenv("DB_PATH", filepath.Join(t.TempDir(), "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
 	service := NewMonitorService()
 	service.ctx, service.cancel = context.WithCancel(context.Background())
 	service.running = true
@@ -44,3 +54,103 @@ func TestMonitorServiceBeginTaskRespectsStop(t *testing.T) {
 		t.Fatal("stopped service should not start tasks")
 	}
 }
+
+func TestExpireRulesDisablesExpiredRules(t *testing.T) {
+	// 同一包中的其他测试已缓存配置，DB_PATH 不再生效，因此单独打开临时数据库
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goban.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.KeywordRule{}, &models.KeywordRuleRevision{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute).UTC(), now.Add(time.Hour)
+	expiring := models.KeywordRule{Name: "活动", Pattern: "抽奖", Enabled: true, ActiveUntil: &past}
+	running := models.KeywordRule{Name: "直播", Pattern: "直播", Enabled: true, ActiveUntil: &future}
+	for _, row := range []*models.KeywordRule{&expiring, &running} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create rule failed: %v", err)
+		}
+	}
+
+	expired, err := expireRules(db, now)
+	if err != nil {
+		t.Fatalf("expireRules failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != expiring.ID {
+		t.Fatalf("expected only the expired rule to be disabled, got %+v", expired)
+	}
+	var reloaded models.KeywordRule
+	db.First(&reloaded, expiring.ID)
+	if reloaded.Enabled || reloaded.Revision != 1 {
+		t.Fatalf("expected disabled rule with a new revision, got %+v", reloaded)
+	}
+	rev, err := rulerev.Get(db, expiring.ID, 1)
+	if err != nil || rev.Action != rulerev.ActionExpire || rev.Enabled {
+		t.Fatalf("unexpected revision %+v (%v)", rev, err)
+	}
+	var untouched models.KeywordRule
+	db.First(&untouched, running.ID)
+	if !untouched.Enabled {
+		t.Fatal("expected the running rule to stay enabled")
+	}
+
+	if again, err := expireRules(db, now); err != nil || len(again) != 0 {
+		t.Fatalf("expected nothing left to expire, got %+v (%v)", again, err)
+	}
+}
+
+func TestRemovalCheckFailuresDoNotStarveNewerReports(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goban.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.BiliUser{}, &models.MonitorTask{}, &models.ReportRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Now()
+	delay := time.Hour
+	var failing []models.ReportRecord
+	for i := 0; i < removalCheckBatch; i++ {
+		failing = append(failing, models.ReportRecord{CommentID: int64(i + 1), Success: true, CreatedAt: now.Add(-48*time.Hour + time.Duration(i)*time.Second)})
+	}
+	if err := db.Create(&failing).Error; err != nil {
+		t.Fatalf("create reports: %v", err)
+	}
+	newer := models.ReportRecord{CommentID: 100, Success: true, CreatedAt: now.Add(-2 * time.Hour)}
+	if err := db.Create(&newer).Error; err != nil {
+		t.Fatalf("create report: %v", err)
+	}
+
+	due, err := dueRemovalChecks(db, now, delay)
+	if err != nil || len(due) != removalCheckBatch || due[0].ID != failing[0].ID {
+		t.Fatalf("expected the oldest reports first, got %d (%v)", len(due), err)
+	}
+	for _, record := range due {
+		recordRemovalCheckFailure(db, record)
+	}
+	due, err = dueRemovalChecks(db, now, delay)
+	if err != nil || len(due) == 0 || due[0].ID != newer.ID {
+		t.Fatalf("expected the newer report ahead of failed ones, got %+v (%v)", due, err)
+	}
+
+	// 达到失败上限后标记为 error，不再参与复查
+	record := failing[0]
+	for i := 1; i < removalCheckMaxAttempts; i++ {
+		db.First(&record, record.ID)
+		recordRemovalCheckFailure(db, record)
+	}
+	db.First(&record, record.ID)
+	if record.RemovalStatus != RemovalError || record.RemovalAttempts != removalCheckMaxAttempts || record.RemovalCheckAt == nil {
+		t.Fatalf("expected record marked as error, got %+v", record)
+	}
+	due, _ = dueRemovalChecks(db, now, delay)
+	for _, r := range due {
+		if r.ID == record.ID {
+			t.Fatal("record that exhausted its attempts should not be checked again")
+		}
+	}
+}
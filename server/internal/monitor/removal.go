@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/logging"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulestats"
+	"github.com/spiritlhl/goban/internal/secure"
+	"github.com/spiritlhl/goban/internal/settings"
+	"gorm.io/gorm"
+)
+
+// 举报复查的范围：每轮最多复查的记录数、两次请求的间隔、只复查最近多少天内的举报，
+// 以及连续失败多少次后放弃复查。
+const (
+	removalCheckBatch       = 20
+	removalCheckInterval    = 2 * time.Second
+	removalCheckMaxAge      = 30 * 24 * time.Hour
+	removalCheckMaxAttempts = 5
+)
+
+// 举报记录的复查结果。
+const (
+	RemovalRemoved = "removed"
+	RemovalKept    = "kept"
+	RemovalError   = "error"
+)
+
+// checkRemovalsDue 复查举报成功一段时间后的评论是否已被删除，结果计入规则统计：
+// 已删除记为 confirmed_removed，仍在记为 review_rejected。
+// 同时清理超出复查范围的规则统计去重记录。
+func (s *MonitorService) checkRemovalsDue() {
+	ctx := s.context()
+	if ctx.Err() != nil {
+		return
+	}
+	db := tracedDB(ctx)
+	if pruned, err := rulestats.PruneComments(db, time.Now().Add(-removalCheckMaxAge)); err != nil {
+		logging.For("removal_check").Error("清理规则统计去重记录失败", slog.Any(logging.KeyError, err))
+	} else if pruned > 0 {
+		logging.For("removal_check").Info("已清理规则统计去重记录", "count", pruned)
+	}
+
+	delay := settings.GetInt("removal_check_delay_seconds", 86400)
+	if delay <= 0 {
+		return
+	}
+	records, err := dueRemovalChecks(db, time.Now(), time.Duration(delay)*time.Second)
+	if err != nil {
+		logging.For("removal_check").Error("查询待复查举报失败", slog.Any(logging.KeyError, err))
+		return
+	}
+
+	clients := map[uint]*bili.BiliClient{}
+	for idx, record := range records {
+		if idx > 0 && !sleepContext(ctx, removalCheckInterval) {
+			return
+		}
+		client, ok := clients[record.TaskID]
+		if !ok {
+			client = removalCheckClient(record.Task)
+			clients[record.TaskID] = client
+		}
+		exists, err := client.CommentExistsContext(ctx, record.AVID, record.CommentID)
+		if err != nil {
+			logging.For("removal_check").Warn("复查评论失败", logging.KeyTaskID, record.TaskID, logging.KeyRPID, record.CommentID, slog.Any(logging.KeyError, err))
+			if bili.IsRiskControlError(err) {
+				return
+			}
+			recordRemovalCheckFailure(db, record)
+			continue
+		}
+		status := RemovalKept
+		delta := rulestats.Counters{ReviewRejected: 1}
+		if !exists {
+			status = RemovalRemoved
+			delta = rulestats.Counters{ConfirmedRemoved: 1}
+		}
+		checkedAt := time.Now()
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"removal_status":   status,
+			"removal_check_at": checkedAt,
+		}).Error; err != nil {
+			logging.For("removal_check").Error("保存复查结果失败", logging.KeyRPID, record.CommentID, slog.Any(logging.KeyError, err))
+			recordRemovalCheckFailure(db, record)
+			continue
+		}
+		// 计入举报当天，便于和当天的举报次数对照
+		s.recordRuleStats(ctx, record.CreatedAt, rulestats.ReportRuleIDs(record), delta)
+	}
+}
+
+// dueRemovalChecks 返回本轮待复查的举报。失败次数少的优先，
+// 反复失败的记录排在后面，不会占满每轮的名额而饿死较新的举报。
+func dueRemovalChecks(db *gorm.DB, now time.Time, delay time.Duration) ([]models.ReportRecord, error) {
+	var records []models.ReportRecord
+	err := db.Preload("Task.User").
+		Where("success = ? AND removal_status = ? AND created_at < ? AND created_at > ?", true, "", now.Add(-delay), now.Add(-removalCheckMaxAge)).
+		Order("removal_attempts ASC").Order("created_at ASC").Limit(removalCheckBatch).Find(&records).Error
+	return records, err
+}
+
+// recordRemovalCheckFailure 记录一次复查失败，达到次数上限后标记为 error 不再复查。
+func recordRemovalCheckFailure(db *gorm.DB, record models.ReportRecord) {
+	updates := map[string]interface{}{
+		"removal_attempts": record.RemovalAttempts + 1,
+		"removal_check_at": time.Now(),
+	}
+	if record.RemovalAttempts+1 >= removalCheckMaxAttempts {
+		updates["removal_status"] = RemovalError
+	}
+	if err := db.Model(&models.ReportRecord{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+		logging.For("removal_check").Error("保存复查失败次数失败", logging.KeyRPID, record.CommentID, slog.Any(logging.KeyError, err))
+	}
+}
+
+// removalCheckClient 优先使用任务账号复查，任务已删除或Cookie不可用时以游客身份查询。
+func removalCheckClient(task models.MonitorTask) *bili.BiliClient {
+	if task.ID != 0 && task.User.ID != 0 {
+		if cookies, err := secure.DecryptString(task.User.Cookies); err == nil {
+			return newClientForTask(task, cookies)
+		}
+	}
+	return bili.NewBiliClient("", 0)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/logging"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+	"gorm.io/gorm"
+)
+
+// checkRuleExpiry 停用已过失效时间的规则。未过期但不在生效时段内的规则保持启用，
+// 由编译规则时按生效时间跳过。
+func (s *MonitorService) checkRuleExpiry() {
+	ctx := s.context()
+	if ctx.Err() != nil {
+		return
+	}
+	expired, err := expireRules(tracedDB(ctx), time.Now())
+	for _, row := range expired {
+		logging.For("rule_schedule").Info("规则已过失效时间，自动停用", logging.KeyRuleID, row.ID, "name", row.Name, "active_until", row.ActiveUntil)
+	}
+	if err != nil {
+		logging.For("rule_schedule").Error("停用过期规则失败", slog.Any(logging.KeyError, err))
+	}
+}
+
+// expireRules 停用 now 时已过失效时间的启用规则并为每条规则记录一份 expire 修订，返回被停用的规则。
+func expireRules(db *gorm.DB, now time.Time) ([]models.KeywordRule, error) {
+	// 失效时间可能带有不同的时区，在内存中比较而不是按字符串比较
+	var rows []models.KeywordRule
+	if err := db.Where("enabled = ? AND active_until IS NOT NULL", true).Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	expired := make([]models.KeywordRule, 0)
+	for i := range rows {
+		row := rows[i]
+		if now.Before(*row.ActiveUntil) {
+			continue
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			// 只在规则仍处于启用状态时停用，避免覆盖同时进行的手动修改
+			result := tx.Model(&models.KeywordRule{}).Where("id = ? AND enabled = ?", row.ID, true).Update("enabled", false)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			row.Enabled = false
+			_, err := rulerev.Record(tx, &row, rulerev.ActionExpire, "", 0)
+			return err
+		}); err != nil {
+			return expired, err
+		}
+		if !row.Enabled {
+			expired = append(expired, row)
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/logging"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/notify"
+	"github.com/spiritlhl/goban/internal/rules"
+	"github.com/spiritlhl/goban/internal/ruleset"
+	"github.com/spiritlhl/goban/internal/settings"
+	"github.com/spiritlhl/goban/internal/watchlist"
+)
+
+// loadWatchlist 加载启用的重点关注用户，并编译 extra_rules 条目引用的规则集。
+// 加载失败时返回空匹配器，不影响任务执行。
+func (s *MonitorService) loadWatchlist(ctx context.Context, taskID uint) (watchlist.Matcher, map[uint]*rules.Engine) {
+	db := tracedDB(ctx)
+	var rows []models.WatchlistUser
+	if err := db.Where("enabled = ?", true).Find(&rows).Error; err != nil {
+		logging.For("watchlist").Error("加载重点关注用户失败", logging.KeyTaskID, taskID, slog.Any(logging.KeyError, err))
+		return watchlist.NewMatcher(nil), nil
+	}
+	matcher := watchlist.NewMatcher(rows)
+	engines := map[uint]*rules.Engine{}
+	for _, setID := range matcher.RuleSetIDs() {
+		setRules, err := ruleset.RulesForSets(db, []uint{setID})
+		if err != nil {
+			logging.For("watchlist").Error("加载重点关注规则集失败", logging.KeyTaskID, taskID, "rule_set_id", setID, slog.Any(logging.KeyError, err))
+			continue
+		}
+		compiled, compileErrors := rules.CompileMany(setRules, "")
+		for _, compileErr := range compileErrors {
+			s.addLog(ctx, taskID, "warning", "重点关注规则集编译失败: "+compileErr.Error())
+		}
+		engines[setID] = rules.NewEngine(compiled)
+	}
+	return matcher, engines
+}
+
+// applyWatchlist 按重点关注用户的处理方式调整评论的命中，返回调整后的命中和是否不按得分阈值直接举报。
+func (s *MonitorService) applyWatchlist(ctx context.Context, run *taskRun, video bili.VideoInfo, comment bili.CommentInfo, commenter *rules.Commenter, user models.WatchlistUser, matches []rules.MatchResult) ([]rules.MatchResult, bool) {
+	db := tracedDB(ctx)
+	at := time.Unix(comment.CTime, 0)
+	db.Model(&models.WatchlistUser{}).Where("id = ? AND (last_comment_at IS NULL OR last_comment_at < ?)", user.ID, at).Update("last_comment_at", at)
+
+	switch user.Mode {
+	case watchlist.ModeAlwaysReport:
+		return append(matches, watchlist.Match(user)), true
+	case watchlist.ModeExtraRules:
+		if engine := run.extraEngines[user.RuleSetID]; engine != nil {
+			matches = mergeMatches(matches, engine.MatchAllFor(comment.Content.Message, commenter))
+		}
+	case watchlist.ModeNotifyOnly:
+		task := run.task
+		// 加入重点关注之前发布的评论不算新评论
+		if at.Before(user.CreatedAt) {
+			break
+		}
+		fresh, err := watchlist.MarkNotified(db, user.UID, comment.RPID)
+		if err != nil {
+			logging.For("watchlist").Error("记录重点关注通知失败", logging.KeyTaskID, task.ID, logging.KeyRPID, comment.RPID, slog.Any(logging.KeyError, err))
+			break
+		}
+		if !fresh {
+			break
+		}
+		taskLogger(task.ID).Info("重点关注用户发表评论", logging.KeyBVID, video.BVID, logging.KeyRPID, comment.RPID, "uid", user.UID)
+		s.addLog(ctx, task.ID, "info", fmt.Sprintf("重点关注用户 %s (%d) 在视频 %s 发表评论: %s", comment.Member.Uname, user.UID, video.BVID, comment.Content.Message))
+		// 通知在后台发送，保留追踪上下文但不随任务取消而中断
+		notifyCtx := context.WithoutCancel(ctx)
+		go func() {
+			if err := notify.NewSender().SendWatchlistCommentContext(notifyCtx, user, task, video.BVID, comment.RPID, comment.Content.Message); err != nil {
+				logging.For("webhook").Warn("重点关注通知发送失败", logging.KeyTaskID, task.ID, logging.KeyRPID, comment.RPID, slog.Any(logging.KeyError, err))
+			}
+		}()
+	}
+	return matches, false
+}
+
+// mergeMatches 把额外规则集的命中追加到任务规则命中之后，两者共有的规则只保留一次。
+func mergeMatches(matches, extra []rules.MatchResult) []rules.MatchResult {
+	seen := map[uint]bool{}
+	for _, match := range matches {
+		if match.RuleID > 0 {
+			seen[match.RuleID] = true
+		}
+	}
+	for _, match := range extra {
+		if match.RuleID > 0 && seen[match.RuleID] {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// promoteOffender 在成功举报后检查评论者的成功举报次数，达到设置的阈值时自动加入重点关注。
+func (s *MonitorService) promoteOffender(ctx context.Context, report models.ReportRecord) {
+	threshold := settings.GetInt("watchlist_auto_threshold", 5)
+	mode := settings.Get("watchlist_auto_mode", watchlist.ModeNotifyOnly)
+	if watchlist.ValidateAutoMode(mode) != nil {
+		mode = watchlist.ModeNotifyOnly
+	}
+	row, created, err := watchlist.Promote(tracedDB(ctx), report.CommentUserID, report.CommentUser, threshold, mode)
+	if err != nil {
+		logging.For("watchlist").Error("自动加入重点关注失败", logging.KeyTaskID, report.TaskID, "uid", report.CommentUserID, slog.Any(logging.KeyError, err))
+		return
+	}
+	if created {
+		logging.For("watchlist").Info("评论者成功举报次数达到阈值，自动加入重点关注", logging.KeyTaskID, report.TaskID, "uid", row.UID, "reports", row.ReportCount, "mode", row.Mode)
+		s.addLog(ctx, report.TaskID, "info", fmt.Sprintf("评论者 %s (%d) 已被成功举报 %d 次，自动加入重点关注", row.Uname, row.UID, row.ReportCount))
+	}
+}
@@ -2,6 +2,7 @@ package notify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/spiritlhl/goban/internal/models"
 	"github.com/spiritlhl/goban/internal/settings"
+	"github.com/spiritlhl/goban/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Sender struct {
@@ -27,11 +30,19 @@ func NewSender() *Sender {
 }
 
 func (s *Sender) SendReport(record models.ReportRecord) error {
-	return s.send(formatReportMessage(record))
+	return s.SendReportContext(context.Background(), record)
+}
+
+func (s *Sender) SendReportContext(ctx context.Context, record models.ReportRecord) error {
+	return s.send(ctx, "report", formatReportMessage(record))
 }
 
 func (s *Sender) SendCookieInvalid(user models.BiliUser, reason string) error {
-	return s.send(fmt.Sprintf(
+	return s.SendCookieInvalidContext(context.Background(), user, reason)
+}
+
+func (s *Sender) SendCookieInvalidContext(ctx context.Context, user models.BiliUser, reason string) error {
+	return s.send(ctx, "cookie_invalid", fmt.Sprintf(
 		"[goban] Cookie已失效\nUP主: %s (%d)\n原因: %s",
 		user.Uname,
 		user.UID,
@@ -40,7 +51,11 @@ func (s *Sender) SendCookieInvalid(user models.BiliUser, reason string) error {
 }
 
 func (s *Sender) SendMonitorError(task models.MonitorTask, reason string) error {
-	return s.send(fmt.Sprintf(
+	return s.SendMonitorErrorContext(context.Background(), task, reason)
+}
+
+func (s *Sender) SendMonitorErrorContext(ctx context.Context, task models.MonitorTask, reason string) error {
+	return s.send(ctx, "monitor_error", fmt.Sprintf(
 		"[goban] 监控异常\n任务: %s (#%d)\n原因: %s",
 		task.Name,
 		task.ID,
@@ -48,25 +63,47 @@ func (s *Sender) SendMonitorError(task models.MonitorTask, reason string) error
 	))
 }
 
-func (s *Sender) send(message string) error {
+// SendWatchlistCommentContext 通知重点关注用户在监控视频下发表了新评论。
+func (s *Sender) SendWatchlistCommentContext(ctx context.Context, user models.WatchlistUser, task models.MonitorTask, bvid string, commentID int64, content string) error {
+	return s.send(ctx, "watchlist", fmt.Sprintf(
+		"[goban] 重点关注用户发表评论\n用户: %s (%d)\n关注原因: %s\n任务: %s (#%d)\n视频: %s\n评论内容: %s\n评论ID: %d",
+		user.Uname,
+		user.UID,
+		user.Reason,
+		task.Name,
+		task.ID,
+		bvid,
+		truncate(content, 160),
+		commentID,
+	))
+}
+
+func (s *Sender) send(ctx context.Context, kind, message string) error {
 	if !settings.GetBool("webhook_enabled", false) {
 		return nil
 	}
 	webhookType := strings.ToLower(settings.Get("webhook_type", "none"))
 
+	ctx, span := telemetry.StartSpan(ctx, "webhook.send",
+		attribute.String("webhook.type", webhookType),
+		attribute.String("webhook.kind", kind),
+	)
+	defer span.End()
+
+	var err error
 	switch webhookType {
 	case "telegram":
-		return s.sendTelegram(message)
+		err = s.sendTelegram(ctx, message)
 	case "feishu":
-		return s.sendFeishu(message)
+		err = s.sendFeishu(ctx, message)
 	case "dingtalk":
-		return s.sendDingTalk(message)
-	default:
-		return nil
+		err = s.sendDingTalk(ctx, message)
 	}
+	telemetry.RecordError(span, err)
+	return err
 }
 
-func (s *Sender) sendTelegram(message string) error {
+func (s *Sender) sendTelegram(ctx context.Context, message string) error {
 	token := settings.Get("telegram_bot_token", "")
 	chatID := settings.Get("telegram_chat_id", "")
 	if token == "" || chatID == "" {
@@ -77,10 +114,10 @@ func (s *Sender) sendTelegram(message string) error {
 		"chat_id": chatID,
 		"text":    message,
 	}
-	return s.postJSON(fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token), body)
+	return s.postJSON(ctx, fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token), body)
 }
 
-func (s *Sender) sendFeishu(message string) error {
+func (s *Sender) sendFeishu(ctx context.Context, message string) error {
 	url := settings.Get("feishu_webhook_url", "")
 	if url == "" {
 		return fmt.Errorf("飞书 Webhook URL 未配置")
@@ -92,10 +129,10 @@ func (s *Sender) sendFeishu(message string) error {
 			"text": message,
 		},
 	}
-	return s.postJSON(url, body)
+	return s.postJSON(ctx, url, body)
 }
 
-func (s *Sender) sendDingTalk(message string) error {
+func (s *Sender) sendDingTalk(ctx context.Context, message string) error {
 	url := settings.Get("dingtalk_webhook_url", "")
 	if url == "" {
 		return fmt.Errorf("钉钉 Webhook URL 未配置")
@@ -107,10 +144,13 @@ func (s *Sender) sendDingTalk(message string) error {
 			"content": message,
 		},
 	}
-	return s.postJSON(url, body)
+	return s.postJSON(ctx, url, body)
 }
 
-func (s *Sender) postJSON(url string, body interface{}) error {
+func (s *Sender) postJSON(ctx context.Context, url string, body interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	payload, err := json.Marshal(body)
 	if err != nil {
 		return err
@@ -118,7 +158,7 @@ func (s *Sender) postJSON(url string, body interface{}) error {
 
 	var lastErr error
 	for attempt := 0; attempt < 2; attempt++ {
-		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 		if err != nil {
 			return err
 		}
@@ -135,7 +175,13 @@ func (s *Sender) postJSON(url string, body interface{}) error {
 			lastErr = fmt.Errorf("Webhook 返回 HTTP %d", resp.StatusCode)
 		}
 		if attempt == 0 {
-			time.Sleep(time.Second)
+			timer := time.NewTimer(time.Second)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
 		}
 	}
 	return lastErr
@@ -1,6 +1,7 @@
 package notify
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -24,7 +25,7 @@ func TestPostJSONRetriesTransientFailure(t *testing.T) {
 
 	sender := &Sender{client: server.Client()}
 	started := time.Now()
-	if err := sender.postJSON(server.URL, map[string]string{"text": "hello"}); err != nil {
+	if err := sender.postJSON(context.Background(), server.URL, map[string]string{"text": "hello"}); err != nil {
 		t.Fatalf("expected retry to recover, got %v", err)
 	}
 	if attempts != 2 {
@@ -42,6 +42,7 @@ func SetupRoutes(router *gin.Engine) {
 				tasks.PUT("/:id", controllers.UpdateMonitorTask)
 				tasks.DELETE("/:id", controllers.DeleteMonitorTask)
 				tasks.GET("/:id/test", controllers.TestMonitorTask)
+				tasks.GET("/:id/rules", controllers.GetTaskRules)
 			}
 
 			// 关键字规则管理
@@ -50,19 +51,76 @@ func SetupRoutes(router *gin.Engine) {
 				keywords.GET("/list", controllers.ListKeywordRules)
 				keywords.POST("/create", controllers.CreateKeywordRule)
 				keywords.POST("/preview", controllers.PreviewKeywordRules)
+				keywords.GET("/tests", controllers.RunKeywordRuleTests)
+				keywords.GET("/stats", controllers.GetKeywordRuleStats)
+				keywords.POST("/import", controllers.ImportKeywordRules)
+				keywords.GET("/export", controllers.ExportKeywordRules)
 				keywords.PUT("/:id", controllers.UpdateKeywordRule)
+				keywords.GET("/:id/revisions", controllers.ListKeywordRuleRevisions)
+				keywords.GET("/:id/revisions/:revision", controllers.GetKeywordRuleRevision)
+				keywords.GET("/:id/diff", controllers.DiffKeywordRuleRevisions)
+				keywords.POST("/:id/rollback", controllers.RollbackKeywordRule)
 				keywords.DELETE("/:id", controllers.DeleteKeywordRule)
 			}
 
+			// 规则集管理
+			ruleSets := auth.Group("/rule-sets")
+			{
+				ruleSets.GET("/list", controllers.ListRuleSets)
+				ruleSets.POST("/create", controllers.CreateRuleSet)
+				ruleSets.PUT("/:id", controllers.UpdateRuleSet)
+				ruleSets.DELETE("/:id", controllers.DeleteRuleSet)
+			}
+
 			// 白名单管理
 			whitelist := auth.Group("/whitelist")
 			{
 				whitelist.GET("/list", controllers.ListWhitelistUsers)
 				whitelist.POST("/create", controllers.CreateWhitelistUser)
+				whitelist.POST("/import", controllers.ImportWhitelistUsers)
+				whitelist.GET("/export", controllers.ExportWhitelistUsers)
 				whitelist.PUT("/:id", controllers.UpdateWhitelistUser)
 				whitelist.DELETE("/:id", controllers.DeleteWhitelistUser)
 			}
 
+			// 重点关注用户
+			watchlist := auth.Group("/watchlist")
+			{
+				watchlist.GET("/list", controllers.ListWatchlistUsers)
+				watchlist.POST("/create", controllers.CreateWatchlistUser)
+				watchlist.PUT("/:id", controllers.UpdateWatchlistUser)
+				watchlist.DELETE("/:id", controllers.DeleteWatchlistUser)
+			}
+
+			// 评论者档案和排行
+			commenters := auth.Group("/commenters")
+			{
+				commenters.GET("/top", controllers.ListTopCommenters)
+				commenters.GET("/:uid", controllers.GetCommenterProfile)
+			}
+
+			// 近似重复评论簇
+			clusters := auth.Group("/clusters")
+			{
+				clusters.GET("/list", controllers.ListCommentClusters)
+				clusters.GET("/:id", controllers.GetCommentCluster)
+				clusters.POST("/:id/report", controllers.ReportCommentCluster)
+				clusters.POST("/:id/dismiss", controllers.DismissCommentCluster)
+			}
+
+			// 文本分类器
+			classifier := auth.Group("/classifier")
+			{
+				classifier.GET("/models", controllers.ListClassifierModels)
+				classifier.POST("/train", controllers.TrainClassifier)
+				classifier.POST("/evaluate", controllers.EvaluateClassifier)
+				classifier.POST("/models/:id/activate", controllers.ActivateClassifierModel)
+				classifier.DELETE("/models/:id", controllers.DeleteClassifierModel)
+				classifier.GET("/samples", controllers.ListClassifierSamples)
+				classifier.POST("/samples", controllers.CreateClassifierSample)
+				classifier.DELETE("/samples/:id", controllers.DeleteClassifierSample)
+			}
+
 			// 系统配置和状态
 			auth.GET("/settings", controllers.GetSettings)
 			auth.PUT("/settings", controllers.UpdateSettings)
@@ -0,0 +1,154 @@
+package ruleio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+)
+
+// 同名规则的冲突处理策略。
+const (
+	PolicySkip      = "skip"
+	PolicyOverwrite = "overwrite"
+	PolicyRename    = "rename"
+)
+
+// 导入条目与现有规则的比对结果。
+const (
+	StatusNew       = "new"       // 没有同名规则
+	StatusChanged   = "changed"   // 存在同名规则但内容不同
+	StatusDuplicate = "duplicate" // 与同名规则完全相同，或在本次导入中重复出现
+	StatusInvalid   = "invalid"   // 未通过校验
+)
+
+// 导入条目最终执行的操作。
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionSkip   = "skip"
+)
+
+// ParsePolicy 规范化冲突策略，默认跳过同名规则。
+func ParsePolicy(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", PolicySkip:
+		return PolicySkip, nil
+	case PolicyOverwrite:
+		return PolicyOverwrite, nil
+	case PolicyRename:
+		return PolicyRename, nil
+	default:
+		return "", fmt.Errorf("不支持的冲突策略: %s，可选 skip、overwrite、rename", value)
+	}
+}
+
+// Candidate 是一条已校验的导入规则，Err 非空表示校验失败。
+type Candidate struct {
+	Rule models.KeywordRule
+	Err  error
+}
+
+// PlanItem 是单条导入规则的比对结果和将执行的操作。
+type PlanItem struct {
+	Index      int                `json:"index"`
+	Name       string             `json:"name"`
+	Pattern    string             `json:"pattern"`
+	Status     string             `json:"status"`
+	Action     string             `json:"action"`
+	ExistingID uint               `json:"existing_id,omitempty"`
+	RenamedTo  string             `json:"renamed_to,omitempty"`
+	Changes    []string           `json:"changes,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Rule       models.KeywordRule `json:"-"`
+}
+
+// Summary 统计各比对结果的数量。
+type Summary struct {
+	New       int `json:"new"`
+	Changed   int `json:"changed"`
+	Duplicate int `json:"duplicate"`
+	Invalid   int `json:"invalid"`
+}
+
+// BuildPlan 按规则名称把导入规则与现有规则比对，并按冲突策略决定每条规则的操作。
+// 返回的 PlanItem.Rule 是要写入的规则；更新时已带上现有规则的 ID。
+func BuildPlan(candidates []Candidate, existing []models.KeywordRule, policy string) ([]PlanItem, Summary) {
+	byName := make(map[string]models.KeywordRule, len(existing))
+	taken := make(map[string]bool, len(existing)+len(candidates))
+	for _, row := range existing {
+		byName[row.Name] = row
+		taken[row.Name] = true
+	}
+	seen := map[string]bool{}
+	items := make([]PlanItem, 0, len(candidates))
+	var summary Summary
+
+	for idx, candidate := range candidates {
+		rule := candidate.Rule
+		item := PlanItem{Index: idx, Name: rule.Name, Pattern: rule.Pattern, Action: ActionSkip, Rule: rule}
+		switch {
+		case candidate.Err != nil:
+			item.Status = StatusInvalid
+			item.Error = candidate.Err.Error()
+			summary.Invalid++
+		case seen[rule.Name]:
+			item.Status = StatusDuplicate
+			item.Error = "与本次导入中的前一条规则同名"
+			summary.Duplicate++
+		default:
+			seen[rule.Name] = true
+			current, exists := byName[rule.Name]
+			switch {
+			case !exists:
+				item.Status = StatusNew
+				item.Action = ActionCreate
+				taken[rule.Name] = true
+				summary.New++
+			default:
+				item.ExistingID = current.ID
+				item.Changes = Diff(current, rule)
+				if len(item.Changes) == 0 {
+					item.Status = StatusDuplicate
+					summary.Duplicate++
+					break
+				}
+				item.Status = StatusChanged
+				summary.Changed++
+				switch policy {
+				case PolicyOverwrite:
+					item.Action = ActionUpdate
+					item.Rule.ID = current.ID
+					item.Rule.CreatedAt = current.CreatedAt
+					item.Rule.LastMatchedAt = current.LastMatchedAt
+				case PolicyRename:
+					item.Action = ActionCreate
+					item.RenamedTo = uniqueName(rule.Name, taken)
+					item.Rule.Name = item.RenamedTo
+					taken[item.RenamedTo] = true
+				}
+			}
+		}
+		items = append(items, item)
+	}
+	return items, summary
+}
+
+// Diff 返回导入规则与现有规则不同的字段名。
+func Diff(current, incoming models.KeywordRule) []string {
+	var fields []string
+	for _, change := range rulerev.Changes(current, incoming) {
+		fields = append(fields, change.Field)
+	}
+	return fields
+}
+
+func uniqueName(name string, taken map[string]bool) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package ruleio 负责关键字规则的批量导入导出：JSON、YAML 和纯文本词表三种格式，
+// 以及导入前与现有规则的差异比对。
+package ruleio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的导入导出格式。
+const (
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatWordlist = "wordlist"
+)
+
+// Rule 是导入导出使用的规则结构，包含 KeywordRule 的全部可编辑字段。
+type Rule struct {
+	Name          string     `json:"name" yaml:"name"`
+	Pattern       string     `json:"pattern" yaml:"pattern"`
+	MatchType     string     `json:"match_type,omitempty" yaml:"match_type,omitempty"`
+	MatchLogic    string     `json:"match_logic,omitempty" yaml:"match_logic,omitempty"`
+	CaseSensitive bool       `json:"case_sensitive,omitempty" yaml:"case_sensitive,omitempty"`
+	Homophone     bool       `json:"homophone,omitempty" yaml:"homophone,omitempty"`
+	Normalize     string     `json:"normalize,omitempty" yaml:"normalize,omitempty"`
+	MaxGap        int        `json:"max_gap,omitempty" yaml:"max_gap,omitempty"`
+	GapNoise      string     `json:"gap_noise,omitempty" yaml:"gap_noise,omitempty"`
+	Weight        float64    `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Conditions    string     `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	MustMatch     string     `json:"must_match,omitempty" yaml:"must_match,omitempty"`
+	MustNotMatch  string     `json:"must_not_match,omitempty" yaml:"must_not_match,omitempty"`
+	Enabled       *bool      `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Description   string     `json:"description,omitempty" yaml:"description,omitempty"`
+	ActiveFrom    *time.Time `json:"active_from,omitempty" yaml:"active_from,omitempty"`
+	ActiveUntil   *time.Time `json:"active_until,omitempty" yaml:"active_until,omitempty"`
+	ActiveDays    string     `json:"active_days,omitempty" yaml:"active_days,omitempty"`
+	ActiveHours   string     `json:"active_hours,omitempty" yaml:"active_hours,omitempty"`
+}
+
+// document 是导出文件的顶层结构；导入时也接受直接的规则数组。
+type document struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// ParseFormat 规范化格式名，yml 和 txt 视为 yaml 和 wordlist。
+func ParseFormat(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	case FormatWordlist, "txt", "text":
+		return FormatWordlist, nil
+	default:
+		return "", fmt.Errorf("不支持的格式: %s，可选 json、yaml、wordlist", value)
+	}
+}
+
+// Decode 解析导入内容。词表每行一个词，映射为普通单条规则，# 开头的行为注释。
+func Decode(data []byte, format string) ([]Rule, error) {
+	switch format {
+	case FormatWordlist:
+		return decodeWordlist(data)
+	case FormatYAML:
+		var doc document
+		if err := yaml.Unmarshal(data, &doc); err == nil && doc.Rules != nil {
+			return doc.Rules, nil
+		}
+		var items []Rule
+		if err := yaml.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("YAML 解析失败: %w", err)
+		}
+		return items, nil
+	default:
+		trimmed := bytes.TrimSpace(data)
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			var items []Rule
+			if err := json.Unmarshal(trimmed, &items); err != nil {
+				return nil, fmt.Errorf("JSON 解析失败: %w", err)
+			}
+			return items, nil
+		}
+		var doc document
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, fmt.Errorf("JSON 解析失败: %w", err)
+		}
+		return doc.Rules, nil
+	}
+}
+
+func decodeWordlist(data []byte) ([]Rule, error) {
+	items := make([]Rule, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		word := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		items = append(items, Rule{Name: word, Pattern: word, MatchType: rules.MatchTypePlain, MatchLogic: rules.MatchLogicSingle})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("词表读取失败: %w", err)
+	}
+	return items, nil
+}
+
+// Encode 导出规则。词表只能表达普通单条规则，其他规则在词表格式中被跳过，返回值 skipped 为跳过的数量。
+func Encode(rows []models.KeywordRule, format string) (data []byte, skipped int, err error) {
+	switch format {
+	case FormatWordlist:
+		var buf bytes.Buffer
+		buf.WriteString("# goban 关键字词表，每行一个普通关键词\n")
+		for _, row := range rows {
+			if !IsWord(row) {
+				skipped++
+				continue
+			}
+			buf.WriteString(strings.TrimSpace(row.Pattern))
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), skipped, nil
+	case FormatYAML:
+		data, err = yaml.Marshal(document{Rules: FromModels(rows)})
+		return data, 0, err
+	default:
+		data, err = json.MarshalIndent(document{Rules: FromModels(rows)}, "", "  ")
+		return data, 0, err
+	}
+}
+
+// IsWord 判断规则能否写入词表：启用的普通单条规则，没有归一化、间隔、条件、生效时间和自定义权重，且匹配内容只有一行。
+func IsWord(row models.KeywordRule) bool {
+	pattern := strings.TrimSpace(row.Pattern)
+	return row.Enabled && (row.MatchType == "" || row.MatchType == rules.MatchTypePlain) &&
+		(row.MatchLogic == "" || row.MatchLogic == rules.MatchLogicSingle) &&
+		pattern != "" && !strings.ContainsAny(pattern, "\r\n") && !strings.HasPrefix(pattern, "#") &&
+		!row.CaseSensitive && row.Normalize == "" && row.MaxGap == 0 && row.Conditions == "" &&
+		row.MustMatch == "" && row.MustNotMatch == "" &&
+		row.ActiveFrom == nil && row.ActiveUntil == nil && row.ActiveDays == "" && row.ActiveHours == "" &&
+		(row.Weight == 0 || row.Weight == rules.DefaultWeight)
+}
+
+// FromModels 把数据库规则转换为导出结构。
+func FromModels(rows []models.KeywordRule) []Rule {
+	items := make([]Rule, 0, len(rows))
+	for _, row := range rows {
+		enabled := row.Enabled
+		items = append(items, Rule{
+			Name:          row.Name,
+			Pattern:       row.Pattern,
+			MatchType:     row.MatchType,
+			MatchLogic:    row.MatchLogic,
+			CaseSensitive: row.CaseSensitive,
+			Homophone:     row.Homophone,
+			Normalize:     row.Normalize,
+			MaxGap:        row.MaxGap,
+			GapNoise:      row.GapNoise,
+			Weight:        row.Weight,
+			Conditions:    row.Conditions,
+			MustMatch:     row.MustMatch,
+			MustNotMatch:  row.MustNotMatch,
+			Enabled:       &enabled,
+			Description:   row.Description,
+			ActiveFrom:    row.ActiveFrom,
+			ActiveUntil:   row.ActiveUntil,
+			ActiveDays:    row.ActiveDays,
+			ActiveHours:   row.ActiveHours,
+		})
+	}
+	return items
+}
@@ -0,0 +1,115 @@
+package ruleio
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestDecodeAcceptsDocumentsArraysAndWordlists(t *testing.T) {
+	cases := []struct {
+		format string
+		input  string
+	}{
+		{FormatJSON, `{"rules":[{"name":"广告","pattern":"加群","match_type":"plain"},{"name":"正则","pattern":"v\\d+","match_type":"regex"}]}`},
+		{FormatJSON, `[{"name":"广告","pattern":"加群"},{"name":"正则","pattern":"v\\d+","match_type":"regex","id":3}]`},
+		{FormatYAML, "rules:\n  - name: 广告\n    pattern: 加群\n  - name: 正则\n    pattern: 'v\\d+'\n    match_type: regex\n"},
+		{FormatYAML, "- name: 广告\n  pattern: 加群\n- name: 正则\n  pattern: 'v\\d+'\n"},
+		{FormatWordlist, "\ufeff# 注释\n加群\n\n  v信  \n"},
+	}
+	for _, tc := range cases {
+		items, err := Decode([]byte(tc.input), tc.format)
+		if err != nil {
+			t.Fatalf("Decode(%s) error: %v", tc.format, err)
+		}
+		if len(items) != 2 || items[0].Pattern != "加群" {
+			t.Fatalf("Decode(%s) = %+v", tc.format, items)
+		}
+	}
+
+	if _, err := Decode([]byte(`{"rules":`), FormatJSON); err == nil {
+		t.Fatalf("broken JSON should fail")
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Fatalf("unknown format should fail")
+	}
+}
+
+func TestEncodeRoundTripsAndWordlistSkipsComplexRules(t *testing.T) {
+	rows := []models.KeywordRule{
+		{Name: "广告", Pattern: "加群", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true},
+		{Name: "正则", Pattern: `v\d+`, MatchType: "regex", MatchLogic: "single", Weight: 2, Conditions: "level <= 2", Enabled: false},
+	}
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		data, skipped, err := Encode(rows, format)
+		if err != nil || skipped != 0 {
+			t.Fatalf("Encode(%s) = %d skipped, %v", format, skipped, err)
+		}
+		items, err := Decode(data, format)
+		if err != nil {
+			t.Fatalf("Decode(%s) error: %v", format, err)
+		}
+		if len(items) != 2 || items[1].Weight != 2 || items[1].Conditions != "level <= 2" || items[1].Enabled == nil || *items[1].Enabled {
+			t.Fatalf("round trip through %s lost fields: %+v", format, items)
+		}
+	}
+
+	data, skipped, err := Encode(rows, FormatWordlist)
+	if err != nil || skipped != 1 {
+		t.Fatalf("wordlist export = %d skipped, %v", skipped, err)
+	}
+	if !strings.HasSuffix(string(data), "\n加群\n") {
+		t.Fatalf("wordlist export = %q", data)
+	}
+}
+
+func TestBuildPlanAppliesConflictPolicies(t *testing.T) {
+	existing := []models.KeywordRule{
+		{ID: 1, Name: "广告", Pattern: "加群", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true},
+		{ID: 2, Name: "引流", Pattern: "v信", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true},
+	}
+	incoming := []Candidate{
+		{Rule: models.KeywordRule{Name: "广告", Pattern: "加群", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true}},
+		{Rule: models.KeywordRule{Name: "引流", Pattern: "vx", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true}},
+		{Rule: models.KeywordRule{Name: "新规则", Pattern: "代写", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true}},
+		{Rule: models.KeywordRule{Name: "新规则", Pattern: "代考", MatchType: "plain", MatchLogic: "single", Weight: 1, Enabled: true}},
+		{Rule: models.KeywordRule{Name: "坏", Pattern: "("}, Err: errString("正则格式错误")},
+	}
+
+	wantStatus := []string{StatusDuplicate, StatusChanged, StatusNew, StatusDuplicate, StatusInvalid}
+	wantAction := map[string][]string{
+		PolicySkip:      {ActionSkip, ActionSkip, ActionCreate, ActionSkip, ActionSkip},
+		PolicyOverwrite: {ActionSkip, ActionUpdate, ActionCreate, ActionSkip, ActionSkip},
+		PolicyRename:    {ActionSkip, ActionCreate, ActionCreate, ActionSkip, ActionSkip},
+	}
+	for policy, actions := range wantAction {
+		items, summary := BuildPlan(incoming, existing, policy)
+		if summary != (Summary{New: 1, Changed: 1, Duplicate: 2, Invalid: 1}) {
+			t.Fatalf("%s summary = %+v", policy, summary)
+		}
+		for i, item := range items {
+			if item.Status != wantStatus[i] || item.Action != actions[i] {
+				t.Fatalf("%s item %d = %s/%s, want %s/%s", policy, i, item.Status, item.Action, wantStatus[i], actions[i])
+			}
+		}
+		changed := items[1]
+		if len(changed.Changes) != 1 || changed.Changes[0] != "pattern" || changed.ExistingID != 2 {
+			t.Fatalf("%s changed item = %+v", policy, changed)
+		}
+		switch policy {
+		case PolicyOverwrite:
+			if changed.Rule.ID != 2 {
+				t.Fatalf("overwrite should target the existing rule, got id %d", changed.Rule.ID)
+			}
+		case PolicyRename:
+			if changed.Rule.ID != 0 || changed.RenamedTo != "引流 (2)" || changed.Rule.Name != "引流 (2)" {
+				t.Fatalf("rename should create a new rule, got %+v", changed)
+			}
+		}
+	}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
@@ -0,0 +1,198 @@
+// Package rulerev 维护关键字规则的不可变修订：每次创建、修改、导入或回滚都保存一份完整快照，
+// 并提供修订之间的字段差异和回滚所需的内容恢复。
+package rulerev
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"gorm.io/gorm"
+)
+
+// 修订的来源。
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionImport   = "import"
+	ActionRollback = "rollback"
+	ActionBaseline = "baseline" // 升级时为已有规则补建的第一份修订
+	ActionExpire   = "expire"   // 规则过了失效时间被自动停用
+)
+
+// ErrNotFound 表示规则没有该修订。
+var ErrNotFound = errors.New("规则修订不存在")
+
+// Change 是两份规则内容之间一个字段的差异。
+type Change struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Changes 按字段比较两份规则内容，只比较可编辑字段，忽略 ID、时间和修订号。
+func Changes(from, to models.KeywordRule) []Change {
+	var changes []Change
+	add := func(field string, a, b interface{}) {
+		if a != b {
+			changes = append(changes, Change{Field: field, From: a, To: b})
+		}
+	}
+	add("name", from.Name, to.Name)
+	add("pattern", from.Pattern, to.Pattern)
+	add("match_type", from.MatchType, to.MatchType)
+	add("match_logic", from.MatchLogic, to.MatchLogic)
+	add("case_sensitive", from.CaseSensitive, to.CaseSensitive)
+	add("homophone", from.Homophone, to.Homophone)
+	add("normalize", from.Normalize, to.Normalize)
+	add("max_gap", from.MaxGap, to.MaxGap)
+	add("gap_noise", from.GapNoise, to.GapNoise)
+	add("weight", from.Weight, to.Weight)
+	add("conditions", from.Conditions, to.Conditions)
+	add("must_match", from.MustMatch, to.MustMatch)
+	add("must_not_match", from.MustNotMatch, to.MustNotMatch)
+	add("enabled", from.Enabled, to.Enabled)
+	add("description", from.Description, to.Description)
+	add("active_from", formatTime(from.ActiveFrom), formatTime(to.ActiveFrom))
+	add("active_until", formatTime(from.ActiveUntil), formatTime(to.ActiveUntil))
+	add("active_days", from.ActiveDays, to.ActiveDays)
+	add("active_hours", from.ActiveHours, to.ActiveHours)
+	return changes
+}
+
+// formatTime 把可空时间转换为可比较的值，为空时返回 nil。
+func formatTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Content 把修订还原为规则内容，ID 和修订号取自修订本身。
+func Content(rev models.KeywordRuleRevision) models.KeywordRule {
+	return models.KeywordRule{
+		ID:            rev.RuleID,
+		Name:          rev.Name,
+		Pattern:       rev.Pattern,
+		MatchType:     rev.MatchType,
+		MatchLogic:    rev.MatchLogic,
+		CaseSensitive: rev.CaseSensitive,
+		Homophone:     rev.Homophone,
+		Normalize:     rev.Normalize,
+		MaxGap:        rev.MaxGap,
+		GapNoise:      rev.GapNoise,
+		Weight:        rev.Weight,
+		Conditions:    rev.Conditions,
+		MustMatch:     rev.MustMatch,
+		MustNotMatch:  rev.MustNotMatch,
+		Enabled:       rev.Enabled,
+		Description:   rev.Description,
+		ActiveFrom:    rev.ActiveFrom,
+		ActiveUntil:   rev.ActiveUntil,
+		ActiveDays:    rev.ActiveDays,
+		ActiveHours:   rev.ActiveHours,
+		Revision:      rev.Revision,
+	}
+}
+
+// Restore 用修订内容覆盖规则的可编辑字段，保留规则的 ID、创建时间和最近命中时间。
+// restoreEnabled 为 false 时保留规则当前的启用状态，避免回滚内容时重新启用已停用或已到期的规则。
+func Restore(row *models.KeywordRule, rev models.KeywordRuleRevision, restoreEnabled bool) {
+	content := Content(rev)
+	content.ID = row.ID
+	content.CreatedAt = row.CreatedAt
+	content.UpdatedAt = row.UpdatedAt
+	content.LastMatchedAt = row.LastMatchedAt
+	content.Revision = row.Revision
+	if !restoreEnabled {
+		content.Enabled = row.Enabled
+	}
+	*row = content
+}
+
+// Record 为规则当前内容写入下一份修订，并把规则的 revision 更新为新修订号。
+// 调用方应在与保存规则相同的事务中调用。
+func Record(tx *gorm.DB, row *models.KeywordRule, action, author string, restoredFrom int) (models.KeywordRuleRevision, error) {
+	if row.ID == 0 {
+		return models.KeywordRuleRevision{}, fmt.Errorf("规则尚未保存，无法记录修订")
+	}
+	var latest int
+	if err := tx.Model(&models.KeywordRuleRevision{}).Where("rule_id = ?", row.ID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error; err != nil {
+		return models.KeywordRuleRevision{}, err
+	}
+	rev := snapshot(*row)
+	rev.Revision = latest + 1
+	rev.Action = action
+	rev.Author = author
+	rev.RestoredFrom = restoredFrom
+	if err := tx.Create(&rev).Error; err != nil {
+		return models.KeywordRuleRevision{}, err
+	}
+	if err := tx.Model(&models.KeywordRule{}).Where("id = ?", row.ID).UpdateColumn("revision", rev.Revision).Error; err != nil {
+		return models.KeywordRuleRevision{}, err
+	}
+	row.Revision = rev.Revision
+	return rev, nil
+}
+
+// List 按修订号从新到旧返回规则的全部修订。
+func List(db *gorm.DB, ruleID uint) ([]models.KeywordRuleRevision, error) {
+	var revs []models.KeywordRuleRevision
+	err := db.Where("rule_id = ?", ruleID).Order("revision DESC").Find(&revs).Error
+	return revs, err
+}
+
+// Get 读取规则的某个修订。
+func Get(db *gorm.DB, ruleID uint, revision int) (models.KeywordRuleRevision, error) {
+	var rev models.KeywordRuleRevision
+	err := db.Where("rule_id = ? AND revision = ?", ruleID, revision).First(&rev).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rev, ErrNotFound
+	}
+	return rev, err
+}
+
+// Backfill 为还没有任何修订的规则补建基线修订，重复调用不会重复创建。
+func Backfill(db *gorm.DB) error {
+	var rows []models.KeywordRule
+	if err := db.Where("id NOT IN (?)", db.Model(&models.KeywordRuleRevision{}).Select("rule_id")).
+		Order("id ASC").Find(&rows).Error; err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := Record(tx, &rows[i], ActionBaseline, "", 0)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func snapshot(row models.KeywordRule) models.KeywordRuleRevision {
+	return models.KeywordRuleRevision{
+		RuleID:        row.ID,
+		Name:          row.Name,
+		Pattern:       row.Pattern,
+		MatchType:     row.MatchType,
+		MatchLogic:    row.MatchLogic,
+		CaseSensitive: row.CaseSensitive,
+		Homophone:     row.Homophone,
+		Normalize:     row.Normalize,
+		MaxGap:        row.MaxGap,
+		GapNoise:      row.GapNoise,
+		Weight:        row.Weight,
+		Conditions:    row.Conditions,
+		MustMatch:     row.MustMatch,
+		MustNotMatch:  row.MustNotMatch,
+		Enabled:       row.Enabled,
+		Description:   row.Description,
+		ActiveFrom:    row.ActiveFrom,
+		ActiveUntil:   row.ActiveUntil,
+		ActiveDays:    row.ActiveDays,
+		ActiveHours:   row.ActiveHours,
+	}
+}
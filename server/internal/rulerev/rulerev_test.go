@@ -0,0 +1,141 @@
+package rulerev_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rulerev"
+)
+
+func TestRecordNumbersRevisionsAndRestore(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	row := models.KeywordRule{Name: "引流", Pattern: "加群", MatchType: "plain", MatchLogic: "single", Enabled: true}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	first, err := rulerev.Record(db, &row, rulerev.ActionCreate, "admin", 0)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if first.Revision != 1 || row.Revision != 1 || first.Author != "admin" {
+		t.Fatalf("unexpected first revision: %+v (rule revision %d)", first, row.Revision)
+	}
+
+	row.Pattern = "加群,私聊"
+	row.MatchLogic = "or"
+	row.CaseSensitive = true
+	if err := db.Save(&row).Error; err != nil {
+		t.Fatalf("save rule: %v", err)
+	}
+	if _, err := rulerev.Record(db, &row, rulerev.ActionUpdate, "admin", 0); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	var stored models.KeywordRule
+	db.First(&stored, row.ID)
+	if stored.Revision != 2 {
+		t.Fatalf("expected stored revision 2, got %d", stored.Revision)
+	}
+
+	changes := rulerev.Changes(rulerev.Content(first), stored)
+	fields := map[string]rulerev.Change{}
+	for _, change := range changes {
+		fields[change.Field] = change
+	}
+	if len(changes) != 3 || fields["pattern"].From != "加群" || fields["pattern"].To != "加群,私聊" || fields["case_sensitive"].To != true {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	// 修订 1 之后规则被停用，只回滚内容时保持停用
+	stored.Enabled = false
+	rulerev.Restore(&stored, first, false)
+	if stored.Pattern != "加群" || stored.MatchLogic != "single" || stored.CaseSensitive || stored.ID != row.ID || stored.Revision != 2 {
+		t.Fatalf("unexpected restored rule: %+v", stored)
+	}
+	if stored.Enabled {
+		t.Fatal("rollback without restore_enabled should keep the rule disabled")
+	}
+	withEnabled := stored
+	rulerev.Restore(&withEnabled, first, true)
+	if !withEnabled.Enabled {
+		t.Fatal("rollback with restore_enabled should restore the revision's enabled state")
+	}
+	rollback, err := rulerev.Record(db, &stored, rulerev.ActionRollback, "admin", first.Revision)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if rollback.Revision != 3 || rollback.RestoredFrom != 1 || rollback.Pattern != "加群" || rollback.Enabled {
+		t.Fatalf("unexpected rollback revision: %+v", rollback)
+	}
+
+	revs, err := rulerev.List(db, row.ID)
+	if err != nil || len(revs) != 3 || revs[0].Revision != 3 {
+		t.Fatalf("unexpected revision list: %+v, %v", revs, err)
+	}
+	if _, err := rulerev.Get(db, row.ID, 9); err != rulerev.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBackfillCreatesBaselineOnce(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	rows := []models.KeywordRule{
+		{Name: "旧规则", Pattern: "广告", Enabled: true},
+		{Name: "已有修订", Pattern: "私聊", Enabled: true},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create rules: %v", err)
+	}
+	if _, err := rulerev.Record(db, &rows[1], rulerev.ActionCreate, "admin", 0); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := rulerev.Backfill(db); err != nil {
+			t.Fatalf("Backfill failed: %v", err)
+		}
+	}
+	legacy, err := rulerev.List(db, rows[0].ID)
+	if err != nil || len(legacy) != 1 || legacy[0].Action != rulerev.ActionBaseline || legacy[0].Pattern != "广告" {
+		t.Fatalf("unexpected baseline revisions: %+v, %v", legacy, err)
+	}
+	existing, err := rulerev.List(db, rows[1].ID)
+	if err != nil || len(existing) != 1 || existing[0].Action != rulerev.ActionCreate {
+		t.Fatalf("expected existing revisions to be untouched: %+v, %v", existing, err)
+	}
+}
+
+func TestChangesComparesScheduleByValue(t *testing.T) {
+	until := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	same := until.In(time.FixedZone("CST", 8*3600))
+	from := models.KeywordRule{Name: "活动", Pattern: "抽奖", ActiveUntil: &until, ActiveDays: "6,7"}
+	to := from
+	to.ActiveUntil = &same
+	if changes := rulerev.Changes(from, to); len(changes) != 0 {
+		t.Fatalf("expected the same instant in another zone to be unchanged, got %+v", changes)
+	}
+	to.ActiveUntil = nil
+	to.ActiveHours = "20:00-23:00"
+	changes := rulerev.Changes(from, to)
+	if len(changes) != 2 || changes[0].Field != "active_until" || changes[0].To != nil || changes[1].Field != "active_hours" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MatchTypeBlocklist 从评论中提取链接和联系方式，与匹配内容中的域名、联系方式名单比较。
+const MatchTypeBlocklist = "blocklist"
+
+var (
+	blocklistSeparators = regexp.MustCompile(`[,，;；\n]+`)
+	blocklistDomain     = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,}$`)
+	blocklistQQ         = regexp.MustCompile(`^[1-9]\d{4,10}$`)
+	blocklistWeChat     = regexp.MustCompile(`^(?:[a-z][a-z0-9_-]{5,19}|1[3-9]\d{9})$`)
+	blocklistPhone      = regexp.MustCompile(`^1[3-9]\d{9}$`)
+)
+
+// blocklistEntry 是名单中的一项，kind 为实体类型或 * （任意链接和联系方式），value 为 * 时匹配该类型的任意实体。
+type blocklistEntry struct {
+	kind  string
+	value string
+}
+
+// parseBlocklist 解析名单，各项以逗号、分号或换行分隔：
+// 域名（可带 domain: 前缀，同时匹配子域名）、qq:号码、wechat:微信号、phone:手机号，值为 * 时匹配任意该类实体，单独的 * 匹配任意链接和联系方式。
+func parseBlocklist(pattern string) ([]blocklistEntry, error) {
+	var entries []blocklistEntry
+	for _, item := range blocklistSeparators.Split(pattern, -1) {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if item == "*" {
+			entries = append(entries, blocklistEntry{kind: "*"})
+			continue
+		}
+		kind, value, ok := strings.Cut(item, ":")
+		if !ok || strings.HasPrefix(value, "//") {
+			kind, value = "domain", item
+		}
+		kind, value = strings.TrimSpace(kind), strings.TrimSpace(value)
+		entry, err := parseBlocklistEntry(kind, value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("名单不能为空")
+	}
+	return entries, nil
+}
+
+func parseBlocklistEntry(kind, value string) (blocklistEntry, error) {
+	var valid *regexp.Regexp
+	switch kind {
+	case "domain", EntityURL:
+		kind = EntityURL
+		if value != "*" {
+			value = strings.TrimPrefix(strings.TrimPrefix(value, "https://"), "http://")
+			value, _, _ = strings.Cut(value, "/")
+			value = strings.TrimPrefix(value, "*.")
+		}
+		valid = blocklistDomain
+	case EntityQQ:
+		valid = blocklistQQ
+	case EntityWeChat, "wx":
+		kind = EntityWeChat
+		valid = blocklistWeChat
+	case EntityPhone:
+		valid = blocklistPhone
+	default:
+		return blocklistEntry{}, fmt.Errorf("名单类型无效: %s，应为 domain、qq、wechat 或 phone", kind)
+	}
+	if value != "*" && !valid.MatchString(value) {
+		return blocklistEntry{}, fmt.Errorf("名单项无效: %s:%s", kind, value)
+	}
+	return blocklistEntry{kind: kind, value: value}, nil
+}
+
+func (e blocklistEntry) matches(entity Entity) bool {
+	if e.kind == "*" {
+		return true
+	}
+	if e.kind != entity.Kind {
+		return false
+	}
+	if e.value == "*" {
+		return true
+	}
+	if e.kind == EntityURL {
+		return entity.Domain == e.value || strings.HasSuffix(entity.Domain, "."+e.value)
+	}
+	return entity.Value == e.value
+}
+
+// String 返回名单项的写法，如 example.com、qq:12345678、wechat:*。
+func (e blocklistEntry) String() string {
+	switch {
+	case e.kind == "*":
+		return "*"
+	case e.kind == EntityURL && e.value != "*":
+		return e.value
+	default:
+		return e.kind + ":" + e.value
+	}
+}
+
+// matchBlocklist 返回第一个在名单中的实体，作为命中内容写入举报记录。
+func (r CompiledRule) matchBlocklist(text string) string {
+	for _, entity := range Extract(text) {
+		for _, entry := range r.blocklist {
+			if entry.matches(entity) {
+				return entity.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spiritlhl/goban/internal/bili"
+)
+
+// 规则附加条件可用的评论者字段。
+const (
+	ConditionLevel          = "level"
+	ConditionUID            = "uid"
+	ConditionVip            = "vip"
+	ConditionVipType        = "vip_type"
+	ConditionHasFansMedal   = "has_fans_medal"
+	ConditionFansMedalLevel = "fans_medal_level"
+	ConditionSex            = "sex"
+	ConditionHasPendant     = "has_pendant"
+)
+
+// conditionAccountAgeDays 是曾经开放的注册天数条件。评论接口不返回注册时间，
+// 这类条件永远无法成立，因此在有数据来源之前拒绝保存。
+const conditionAccountAgeDays = "account_age_days"
+
+type conditionKind uint8
+
+const (
+	conditionInt conditionKind = iota
+	conditionBool
+	conditionString
+)
+
+var conditionFields = map[string]conditionKind{
+	ConditionLevel:          conditionInt,
+	ConditionUID:            conditionInt,
+	ConditionVip:            conditionBool,
+	ConditionVipType:        conditionInt,
+	ConditionHasFansMedal:   conditionBool,
+	ConditionFansMedalLevel: conditionInt,
+	ConditionSex:            conditionString,
+	ConditionHasPendant:     conditionBool,
+}
+
+var conditionPattern = regexp.MustCompile(`^([a-z_]+)\s*(==|!=|<=|>=|=|<|>)\s*(.+)$`)
+
+// Commenter 是评论者资料，规则的附加条件基于它求值。
+type Commenter struct {
+	UID            int64  `json:"uid"`
+	Level          int    `json:"level"`
+	Vip            bool   `json:"vip"`
+	VipType        int    `json:"vip_type"`
+	HasFansMedal   bool   `json:"has_fans_medal"`
+	FansMedalLevel int    `json:"fans_medal_level"`
+	Sex            string `json:"sex"`
+	HasPendant     bool   `json:"has_pendant"`
+}
+
+// CommenterFromComment 从评论接口返回的资料构造 Commenter。
+func CommenterFromComment(comment bili.CommentInfo) *Commenter {
+	member := comment.Member
+	uid := member.Mid
+	if uid == 0 {
+		uid = comment.Mid
+	}
+	return &Commenter{
+		UID:            uid,
+		Level:          member.Level(),
+		Vip:            member.IsVip(),
+		VipType:        member.Vip.VipType,
+		HasFansMedal:   member.FansDetail != nil && member.FansDetail.Level > 0,
+		FansMedalLevel: member.FansMedalLevel(),
+		Sex:            member.Sex,
+		HasPendant:     member.Pendant.PID > 0,
+	}
+}
+
+// Condition 是一条附加条件，如 level <= 2。
+type Condition struct {
+	Field string
+	Op    string
+	Value string
+	num   int64
+	flag  bool
+}
+
+// ParseConditions 解析分号、逗号或换行分隔的附加条件，全部满足才算命中。
+func ParseConditions(raw string) ([]Condition, error) {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ';' || r == '；' || r == ',' || r == '，' || r == '\n' || r == '\r'
+	})
+	conditions := make([]Condition, 0, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		condition, err := parseCondition(field)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+func parseCondition(text string) (Condition, error) {
+	parts := conditionPattern.FindStringSubmatch(text)
+	if parts == nil {
+		return Condition{}, fmt.Errorf("附加条件格式错误: %s，应为 字段 运算符 值，如 level <= 2", text)
+	}
+	condition := Condition{Field: parts[1], Op: parts[2], Value: strings.TrimSpace(parts[3])}
+	if condition.Op == "==" {
+		condition.Op = "="
+	}
+	if condition.Field == conditionAccountAgeDays {
+		return Condition{}, fmt.Errorf("暂不支持条件字段 %s：评论接口不返回注册时间", condition.Field)
+	}
+	kind, ok := conditionFields[condition.Field]
+	if !ok {
+		return Condition{}, fmt.Errorf("未知的条件字段: %s", condition.Field)
+	}
+	switch kind {
+	case conditionInt:
+		value, err := strconv.ParseInt(condition.Value, 10, 64)
+		if err != nil {
+			return Condition{}, fmt.Errorf("条件 %s 的值必须是整数", text)
+		}
+		condition.num = value
+	case conditionBool:
+		value, err := strconv.ParseBool(condition.Value)
+		if err != nil {
+			return Condition{}, fmt.Errorf("条件 %s 的值必须是 true 或 false", text)
+		}
+		condition.flag = value
+		fallthrough
+	case conditionString:
+		if condition.Op != "=" && condition.Op != "!=" {
+			return Condition{}, fmt.Errorf("条件 %s 只能使用 = 或 !=", text)
+		}
+	}
+	return condition, nil
+}
+
+// FormatConditions 把条件格式化为存储用的字符串。
+func FormatConditions(conditions []Condition) string {
+	parts := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		parts = append(parts, condition.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Field, c.Op, c.Value)
+}
+
+// Holds 判断评论者是否满足条件。
+func (c Condition) Holds(commenter Commenter) bool {
+	switch c.Field {
+	case ConditionLevel:
+		return compareInt(int64(commenter.Level), c.Op, c.num)
+	case ConditionUID:
+		return compareInt(commenter.UID, c.Op, c.num)
+	case ConditionVipType:
+		return compareInt(int64(commenter.VipType), c.Op, c.num)
+	case ConditionFansMedalLevel:
+		return compareInt(int64(commenter.FansMedalLevel), c.Op, c.num)
+	case ConditionVip:
+		return (commenter.Vip == c.flag) == (c.Op == "=")
+	case ConditionHasFansMedal:
+		return (commenter.HasFansMedal == c.flag) == (c.Op == "=")
+	case ConditionHasPendant:
+		return (commenter.HasPendant == c.flag) == (c.Op == "=")
+	case ConditionSex:
+		return (commenter.Sex == c.Value) == (c.Op == "=")
+	}
+	return false
+}
+
+func compareInt(left int64, op string, right int64) bool {
+	switch op {
+	case "=":
+		return left == right
+	case "!=":
+		return left != right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	}
+	return false
+}
+
+// conditionsHold 判断规则的附加条件；commenter 为 nil（如纯文本预览）时不检查条件。
+func (r CompiledRule) conditionsHold(commenter *Commenter) bool {
+	if commenter == nil {
+		return true
+	}
+	for _, condition := range r.conditions {
+		if !condition.Holds(*commenter) {
+			return false
+		}
+	}
+	return true
+}
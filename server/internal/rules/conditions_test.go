@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestParseConditions(t *testing.T) {
+	conditions, err := ParseConditions("level<=2；has_fans_medal == false, uid > 3000000000\nsex != 保密")
+	if err != nil {
+		t.Fatalf("ParseConditions failed: %v", err)
+	}
+	if got := FormatConditions(conditions); got != "level <= 2; has_fans_medal = false; uid > 3000000000; sex != 保密" {
+		t.Fatalf("FormatConditions = %q", got)
+	}
+
+	for _, raw := range []string{"level", "age < 3", "level <= two", "vip > true", "has_pendant = maybe", "sex < 男", "account_age_days < 30"} {
+		if _, err := ParseConditions(raw); err == nil {
+			t.Fatalf("ParseConditions(%q) should fail", raw)
+		}
+	}
+}
+
+func TestConditionsHold(t *testing.T) {
+	fresh := Commenter{UID: 3500000000, Level: 1, Sex: "保密"}
+	regular := Commenter{UID: 1200, Level: 6, Vip: true, VipType: 2, HasFansMedal: true, FansMedalLevel: 21, HasPendant: true, Sex: "男"}
+
+	cases := []struct {
+		raw            string
+		fresh, regular bool
+	}{
+		{"level <= 2", true, false},
+		{"uid > 3000000000", true, false},
+		{"has_fans_medal = false", true, false},
+		{"fans_medal_level >= 20", false, true},
+		{"vip = true; vip_type = 2", false, true},
+		{"has_pendant != true", true, false},
+		{"sex = 男", false, true},
+		{"level <= 2; has_fans_medal = false", true, false},
+	}
+	for _, tc := range cases {
+		conditions, err := ParseConditions(tc.raw)
+		if err != nil {
+			t.Fatalf("ParseConditions(%q) failed: %v", tc.raw, err)
+		}
+		rule := CompiledRule{conditions: conditions}
+		if got := rule.conditionsHold(&fresh); got != tc.fresh {
+			t.Fatalf("%q on fresh account = %v, want %v", tc.raw, got, tc.fresh)
+		}
+		if got := rule.conditionsHold(&regular); got != tc.regular {
+			t.Fatalf("%q on regular account = %v, want %v", tc.raw, got, tc.regular)
+		}
+	}
+}
+
+func TestMatchWithCommenterConditions(t *testing.T) {
+	compiled := mustCompileRules(t, []models.KeywordRule{
+		{Pattern: "yyds", MatchType: MatchTypePlain, Conditions: "level <= 2"},
+		{Pattern: "加微信", MatchType: MatchTypePlain},
+	}, "")
+	engine := NewEngine(compiled)
+	fresh := &Commenter{Level: 0}
+	regular := &Commenter{Level: 5}
+
+	for name, match := range map[string]func(string, *Commenter) []MatchResult{
+		"linear": func(text string, c *Commenter) []MatchResult { return MatchAllFor(text, c, compiled) },
+		"engine": engine.MatchAllFor,
+	} {
+		if got := match("yyds 加微信", fresh); len(got) != 2 {
+			t.Fatalf("%s: fresh account should hit both rules, got %v", name, got)
+		}
+		if got := match("yyds 加微信", regular); len(got) != 1 || got[0].RuleID != 2 {
+			t.Fatalf("%s: regular account should only hit unconditional rule, got %v", name, got)
+		}
+		if got := match("yyds", nil); len(got) != 1 {
+			t.Fatalf("%s: text-only matching should skip conditions, got %v", name, got)
+		}
+	}
+}
+
+func TestCommenterFromComment(t *testing.T) {
+	var comment bili.CommentInfo
+	payload := `{"mid":7,"member":{"mid":"7","sex":"女","level_info":{"current_level":3},
+		"vip":{"vipType":1,"vipStatus":1},"pendant":{"pid":0},"fans_detail":{"level":4}}}`
+	if err := json.Unmarshal([]byte(payload), &comment); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	got := CommenterFromComment(comment)
+	if got.UID != 7 || got.Level != 3 || !got.Vip || !got.HasFansMedal || got.FansMedalLevel != 4 || got.HasPendant || got.Sex != "女" {
+		t.Fatalf("unexpected commenter: %#v", got)
+	}
+}
@@ -0,0 +1,321 @@
+package rules
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/width"
+)
+
+// Engine 把一组规则里的全部普通条件编译进 Aho-Corasick 自动机，评论只需扫描一遍
+// 即可知道哪些条件出现过；正则、拼音和模糊间隔规则仍逐条匹配。
+// 结果与 MatchText / MatchAll 完全一致：按规则顺序、保留单条/任一/全部逻辑和大小写设置。
+// Engine 构建后只读，可在多个 goroutine 间共享。
+type Engine struct {
+	rules  []CompiledRule
+	plans  []enginePlan
+	groups []acGroup
+	terms  int
+	floods []int
+}
+
+// enginePlan 记录规则由哪个自动机负责以及其各条件的全局编号，group 为 -1 表示逐条匹配。
+type enginePlan struct {
+	group   int
+	termIDs []int
+}
+
+// acGroup 对应一种文本预处理方式（归一化步骤 + 大小写），同组条件共用一个自动机。
+type acGroup struct {
+	normalizer    Normalizer
+	caseSensitive bool
+	automaton     *acAutomaton
+}
+
+func NewEngine(compiled []CompiledRule) *Engine {
+	e := &Engine{
+		rules: compiled,
+		plans: make([]enginePlan, len(compiled)),
+	}
+	groupIndex := map[string]int{}
+	patterns := map[int][]string{}
+	ids := map[int][]int{}
+
+	for idx, rule := range compiled {
+		if rule.MatchType == MatchTypeFlood {
+			e.floods = append(e.floods, idx)
+		}
+		if rule.MatchType != MatchTypePlain || rule.MaxGap > 0 {
+			e.plans[idx] = enginePlan{group: -1}
+			continue
+		}
+		key := FormatNormalizeSteps(rule.Normalize)
+		if rule.CaseSensitive {
+			key += "|cs"
+		}
+		group, ok := groupIndex[key]
+		if !ok {
+			group = len(e.groups)
+			groupIndex[key] = group
+			e.groups = append(e.groups, acGroup{normalizer: rule.normalizer, caseSensitive: rule.CaseSensitive})
+		}
+
+		terms := rule.plainTerms()
+		plan := enginePlan{group: group, termIDs: make([]int, len(terms))}
+		for i, term := range terms {
+			plan.termIDs[i] = e.terms
+			patterns[group] = append(patterns[group], e.groups[group].prepare(term))
+			ids[group] = append(ids[group], e.terms)
+			e.terms++
+		}
+		e.plans[idx] = plan
+	}
+
+	for group := range e.groups {
+		e.groups[group].automaton = newACAutomaton(patterns[group], ids[group])
+	}
+	return e
+}
+
+// prepare 与 matchPlain 的处理一致：归一化、全角折叠，大小写不敏感时转小写。
+func (g acGroup) prepare(value string) string {
+	value = width.Fold.String(g.normalizer.Apply(value))
+	if !g.caseSensitive {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+// MatchText 返回第一条命中的规则，语义同包级 MatchText。
+func (e *Engine) MatchText(text string) *MatchResult {
+	return e.MatchTextFor(text, nil)
+}
+
+// MatchTextFor 同 MatchText，并检查规则对评论者的附加条件。
+func (e *Engine) MatchTextFor(text string, commenter *Commenter) *MatchResult {
+	hits := e.scan(text)
+	for idx, rule := range e.rules {
+		if !rule.conditionsHold(commenter) {
+			continue
+		}
+		if matched, normalization := e.matchRule(idx, text, hits); matched != "" {
+			result := rule.result(text, matched, normalization, e.score(idx, text, hits))
+			return &result
+		}
+	}
+	return nil
+}
+
+// MatchAll 返回全部命中的规则，语义同包级 MatchAll。
+func (e *Engine) MatchAll(text string) []MatchResult {
+	return e.MatchAllFor(text, nil)
+}
+
+// MatchAllFor 同 MatchAll，并检查规则对评论者的附加条件。
+func (e *Engine) MatchAllFor(text string, commenter *Commenter) []MatchResult {
+	hits := e.scan(text)
+	matches := make([]MatchResult, 0)
+	for idx, rule := range e.rules {
+		if !rule.conditionsHold(commenter) {
+			continue
+		}
+		if matched, normalization := e.matchRule(idx, text, hits); matched != "" {
+			matches = append(matches, rule.result(text, matched, normalization, e.score(idx, text, hits)))
+		}
+	}
+	return matches
+}
+
+// HasFlood 报告是否有刷屏规则，没有时无需记录评论者活动。
+func (e *Engine) HasFlood() bool {
+	return len(e.floods) > 0
+}
+
+// FloodWindow 返回刷屏规则中最长的时间窗口，评论者活动至少要保留这么久。
+func (e *Engine) FloodWindow() time.Duration {
+	var window time.Duration
+	for _, idx := range e.floods {
+		window = max(window, e.rules[idx].flood.Window)
+	}
+	return window
+}
+
+// MatchFloodFor 用评论者的近期评论 history（应包含 current）判断刷屏规则，
+// 并检查规则对评论者的附加条件。命中结果的 Evidence 为窗口内的相关评论。
+func (e *Engine) MatchFloodFor(current ActivityComment, history []ActivityComment, commenter *Commenter) []MatchResult {
+	var matches []MatchResult
+	for _, idx := range e.floods {
+		rule := e.rules[idx]
+		if !rule.conditionsHold(commenter) {
+			continue
+		}
+		if matched, evidence := rule.matchFlood(current, history); matched != "" {
+			result := rule.result(current.Message, matched, "", rule.weight())
+			result.Evidence = evidence
+			matches = append(matches, result)
+		}
+	}
+	return matches
+}
+
+func (e *Engine) scan(text string) []bool {
+	hits := make([]bool, e.terms)
+	for _, group := range e.groups {
+		group.automaton.scan(group.prepare(text), hits)
+	}
+	return hits
+}
+
+func (e *Engine) matchRule(idx int, text string, hits []bool) (string, string) {
+	rule := e.rules[idx]
+	plan := e.plans[idx]
+	if plan.group < 0 {
+		return rule.match(text)
+	}
+	terms := rule.plainTerms()
+	var matches []string
+	for i, term := range terms {
+		matched := hits[plan.termIDs[i]]
+		if !matched && rule.MatchLogic == MatchLogicAll {
+			return "", ""
+		}
+		if matched {
+			if rule.MatchLogic != MatchLogicAll {
+				return term, ""
+			}
+			matches = append(matches, term)
+		}
+	}
+	return strings.Join(matches, ", "), ""
+}
+
+// score 与 CompiledRule.score 一致，自动机负责的条件直接查扫描结果。
+func (e *Engine) score(idx int, text string, hits []bool) float64 {
+	rule := e.rules[idx]
+	plan := e.plans[idx]
+	if plan.group < 0 {
+		return rule.score(text)
+	}
+	return rule.scoreWith(func(term int) bool { return hits[plan.termIDs[term]] })
+}
+
+func (r CompiledRule) plainTerms() []string {
+	if len(r.terms) > 0 {
+		return r.terms
+	}
+	return ruleTerms(r.Pattern, r.MatchLogic)
+}
+
+// acAutomaton 是按字节构建的 Aho-Corasick 自动机。子节点用 (节点<<8|字节) 作键存放在
+// 一个 map 中，万级条件下比每个节点一张 256 项的跳转表节省得多。
+type acAutomaton struct {
+	children map[uint64]int32
+	fail     []int32
+	// output 是恰好在该节点结束的条件编号；dict 指向沿失败链最近的、带输出的节点。
+	output [][]int
+	dict   []int32
+}
+
+func newACAutomaton(patterns []string, ids []int) *acAutomaton {
+	a := &acAutomaton{
+		children: map[uint64]int32{},
+		fail:     []int32{0},
+		output:   [][]int{nil},
+		dict:     []int32{-1},
+	}
+	for i, pattern := range patterns {
+		if pattern == "" {
+			// 与 matchPlain 一致，归一化后为空的条件永远不会命中。
+			continue
+		}
+		node := int32(0)
+		for j := 0; j < len(pattern); j++ {
+			key := edge(node, pattern[j])
+			child, ok := a.children[key]
+			if !ok {
+				child = int32(len(a.fail))
+				a.children[key] = child
+				a.fail = append(a.fail, 0)
+				a.output = append(a.output, nil)
+				a.dict = append(a.dict, -1)
+			}
+			node = child
+		}
+		a.output[node] = append(a.output[node], ids[i])
+	}
+	a.link()
+	return a
+}
+
+func edge(node int32, b byte) uint64 {
+	return uint64(node)<<8 | uint64(b)
+}
+
+// link 按广度优先计算失败指针和输出链接。
+func (a *acAutomaton) link() {
+	type acEdge struct {
+		b     byte
+		child int32
+	}
+	byParent := make([][]acEdge, len(a.fail))
+	for key, child := range a.children {
+		parent := int32(key >> 8)
+		byParent[parent] = append(byParent[parent], acEdge{b: byte(key), child: child})
+	}
+
+	queue := make([]int32, 0, len(a.fail))
+	for _, next := range byParent[0] {
+		a.fail[next.child] = 0
+		queue = append(queue, next.child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range byParent[node] {
+			fallback := a.fail[node]
+			for {
+				if target, ok := a.children[edge(fallback, next.b)]; ok {
+					a.fail[next.child] = target
+					break
+				}
+				if fallback == 0 {
+					a.fail[next.child] = 0
+					break
+				}
+				fallback = a.fail[fallback]
+			}
+			suffix := a.fail[next.child]
+			if len(a.output[suffix]) > 0 {
+				a.dict[next.child] = suffix
+			} else {
+				a.dict[next.child] = a.dict[suffix]
+			}
+			queue = append(queue, next.child)
+		}
+	}
+}
+
+func (a *acAutomaton) scan(text string, hits []bool) {
+	if len(a.fail) == 1 {
+		return
+	}
+	node := int32(0)
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		for {
+			if child, ok := a.children[edge(node, b)]; ok {
+				node = child
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = a.fail[node]
+		}
+		for out := node; out > 0; out = a.dict[out] {
+			for _, id := range a.output[out] {
+				hits[id] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+const benchTermCount = 10000
+
+func benchTerms() []string {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []rune("的一是在不了有和人这中大为上个国我以要他时来用们生到作地于出就分对成会可主发年动同工也能下过子说产种面而方后多定行学法所民得经十三之进着等部度家电力里如水化高自二理起小物现实加量都两体制机当使点从业本去把性好应开它合还因由其些然前外天政四日那社义事平形相全表间样与关各重新线内数正心反你明看原又么利比或但质气第向道命此变条只没结解问意建月公无系军很情者最立代想已通并提直题党程展五果料象员革位入常文总次品式活设及管特件长求老头基资边流路级少图山统接知较将组见计别她手角期根论运农指几九区强放决西被干做必战先回则任取据处队南给色光门即保治北造百规热领七海口东导器压志世金增争济阶油思术极交受联什认六共权收证改清己美再采转更单风切打白教速花带安场身车例真务具万每目至达走积示议声报斗完类八离华名确才科张信马节话米整空元况今集温传土许步群广石记需段研界拉林律叫且究观越织装影算低持音众书布复容儿须际商非验连断深难近矿千周委素技备半办青省列习响约支般史感劳便团往酸历市克何除消构府称太准精值号率族维划选标写存候毛亲快效斯院查江型眼王按格养易置派层片始却专状育厂京识适属圆包火住调满县局照参红细引听该铁价严")
+	seen := map[string]bool{}
+	terms := make([]string, 0, benchTermCount)
+	for len(terms) < benchTermCount {
+		n := 2 + rng.Intn(4)
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteRune(alphabet[rng.Intn(len(alphabet))])
+		}
+		if term := b.String(); !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// benchComments 返回一条不命中的普通评论和一条只命中最后一个条件的评论。
+func benchComments(terms []string) (miss, late string) {
+	miss = strings.Repeat("这个视频做得真不错，支持一下UP主！", 4)
+	return miss, miss + terms[len(terms)-1]
+}
+
+func benchSingleRules(b *testing.B) []CompiledRule {
+	terms := benchTerms()
+	rows := make([]models.KeywordRule, len(terms))
+	for i, term := range terms {
+		rows[i] = models.KeywordRule{Pattern: term, MatchType: MatchTypePlain, Enabled: true}
+	}
+	compiled, errs := CompileMany(rows, "")
+	if len(errs) > 0 {
+		b.Fatalf("CompileMany failed: %v", errs[0])
+	}
+	return compiled
+}
+
+func benchAnyRule(b *testing.B) []CompiledRule {
+	compiled, errs := CompileMany([]models.KeywordRule{{
+		Pattern:    strings.Join(benchTerms(), "\n"),
+		MatchType:  MatchTypePlain,
+		MatchLogic: MatchLogicAny,
+		Enabled:    true,
+	}}, "")
+	if len(errs) > 0 {
+		b.Fatalf("CompileMany failed: %v", errs[0])
+	}
+	return compiled
+}
+
+func benchmarkLinear(b *testing.B, compiled []CompiledRule, text string) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		MatchText(text, compiled)
+	}
+}
+
+func benchmarkEngine(b *testing.B, compiled []CompiledRule, text string) {
+	engine := NewEngine(compiled)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.MatchText(text)
+	}
+}
+
+func BenchmarkMatch10kSingleRules(b *testing.B) {
+	compiled := benchSingleRules(b)
+	miss, late := benchComments(benchTerms())
+	b.Run("linear/miss", func(b *testing.B) { benchmarkLinear(b, compiled, miss) })
+	b.Run("engine/miss", func(b *testing.B) { benchmarkEngine(b, compiled, miss) })
+	b.Run("linear/late", func(b *testing.B) { benchmarkLinear(b, compiled, late) })
+	b.Run("engine/late", func(b *testing.B) { benchmarkEngine(b, compiled, late) })
+}
+
+func BenchmarkMatch10kAnyTerms(b *testing.B) {
+	compiled := benchAnyRule(b)
+	miss, late := benchComments(benchTerms())
+	b.Run("linear/miss", func(b *testing.B) { benchmarkLinear(b, compiled, miss) })
+	b.Run("engine/miss", func(b *testing.B) { benchmarkEngine(b, compiled, miss) })
+	b.Run("linear/late", func(b *testing.B) { benchmarkLinear(b, compiled, late) })
+	b.Run("engine/late", func(b *testing.B) { benchmarkEngine(b, compiled, late) })
+}
+
+func BenchmarkNewEngine10k(b *testing.B) {
+	compiled := benchSingleRules(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewEngine(compiled)
+	}
+}
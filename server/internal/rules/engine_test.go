@@ -0,0 +1,115 @@
+package rules
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func mustCompileRules(t testing.TB, rows []models.KeywordRule, adHoc string) []CompiledRule {
+	t.Helper()
+	for i := range rows {
+		rows[i].Enabled = true
+		if rows[i].Name == "" {
+			rows[i].Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		rows[i].ID = uint(i + 1)
+	}
+	compiled, errs := CompileMany(rows, adHoc)
+	if len(errs) > 0 {
+		t.Fatalf("CompileMany failed: %v", errs)
+	}
+	return compiled
+}
+
+func TestEngineMatchesLinearSemantics(t *testing.T) {
+	compiled := mustCompileRules(t, []models.KeywordRule{
+		{Pattern: "Video", MatchType: MatchTypePlain, CaseSensitive: true},
+		{Pattern: "video", MatchType: MatchTypePlain},
+		{Pattern: "加微信,领红包", MatchType: MatchTypePlain, MatchLogic: MatchLogicAll},
+		{Pattern: "红包;福利", MatchType: MatchTypePlain, MatchLogic: MatchLogicAny},
+		{Pattern: `V信\d+`, MatchType: MatchTypeRegex},
+		{Pattern: "傻逼", MatchType: MatchTypePinyin},
+		{Pattern: "代刷", MatchType: MatchTypePlain, MaxGap: 2},
+		{Pattern: "領取", MatchType: MatchTypePlain, Normalize: "t2s"},
+		{Pattern: "he", MatchType: MatchTypePlain},
+		{Pattern: "she", MatchType: MatchTypePlain},
+		{Pattern: "hers", MatchType: MatchTypePlain},
+	}, "广告\n推广")
+	engine := NewEngine(compiled)
+
+	texts := []string{
+		"",
+		"这个ｖｉｄｅｏ不错",
+		"这个Video不错",
+		"加微信领红包",
+		"加微信",
+		"福利来了 V信123",
+		"你个沙比",
+		"代.刷",
+		"点击领取",
+		"ushers",
+		"广告位招租",
+		"完全无关的评论",
+	}
+	for _, text := range texts {
+		if got, want := engine.MatchAll(text), MatchAll(text, compiled); !reflect.DeepEqual(got, want) {
+			t.Fatalf("MatchAll(%q) mismatch:\nengine=%#v\nlinear=%#v", text, got, want)
+		}
+		if got, want := engine.MatchText(text), MatchText(text, compiled); !reflect.DeepEqual(got, want) {
+			t.Fatalf("MatchText(%q) mismatch:\nengine=%#v\nlinear=%#v", text, got, want)
+		}
+	}
+}
+
+func TestEngineRespectsRuleOrder(t *testing.T) {
+	compiled := mustCompileRules(t, []models.KeywordRule{
+		{Pattern: `\d{6,}`, MatchType: MatchTypeRegex},
+		{Pattern: "qq", MatchType: MatchTypePlain},
+	}, "")
+	got := NewEngine(compiled).MatchText("加qq 1234567")
+	if got == nil || got.RuleID != 1 {
+		t.Fatalf("expected first rule to win, got %#v", got)
+	}
+}
+
+func TestEngineRandomizedAgainstLinear(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	alphabet := []rune("abAB微信加群代刷ｑＱ")
+	word := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteRune(alphabet[rng.Intn(len(alphabet))])
+		}
+		return b.String()
+	}
+	logics := []string{MatchLogicSingle, MatchLogicAny, MatchLogicAll}
+	for round := 0; round < 50; round++ {
+		rows := make([]models.KeywordRule, 0, 12)
+		for i := 0; i < 12; i++ {
+			logic := logics[rng.Intn(len(logics))]
+			pattern := word(1 + rng.Intn(3))
+			if logic != MatchLogicSingle {
+				pattern += "," + word(1+rng.Intn(3)) + "群"
+			}
+			rows = append(rows, models.KeywordRule{
+				Pattern:       pattern,
+				MatchType:     MatchTypePlain,
+				MatchLogic:    logic,
+				CaseSensitive: rng.Intn(2) == 0,
+			})
+		}
+		compiled := mustCompileRules(t, rows, "")
+		engine := NewEngine(compiled)
+		for i := 0; i < 20; i++ {
+			text := word(rng.Intn(30))
+			if got, want := engine.MatchAll(text), MatchAll(text, compiled); !reflect.DeepEqual(got, want) {
+				t.Fatalf("round %d MatchAll(%q) mismatch:\nengine=%#v\nlinear=%#v", round, text, got, want)
+			}
+		}
+	}
+}
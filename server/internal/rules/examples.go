@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+// 规则测试用例的数量和长度上限。
+const (
+	MaxExamples      = 50
+	MaxExampleLength = 500
+)
+
+// 测试用例的期望结果。
+const (
+	ExpectMatch   = "match"
+	ExpectNoMatch = "no_match"
+)
+
+// ExampleFailure 是一条未通过的测试用例。
+type ExampleFailure struct {
+	Text    string `json:"text"`
+	Expect  string `json:"expect"`
+	Matched string `json:"matched,omitempty"`
+}
+
+func (f ExampleFailure) String() string {
+	if f.Expect == ExpectMatch {
+		return fmt.Sprintf("应命中「%s」但未命中", f.Text)
+	}
+	return fmt.Sprintf("不应命中「%s」却命中了「%s」", f.Text, f.Matched)
+}
+
+// ExampleReport 是一条规则全部测试用例的运行结果。
+type ExampleReport struct {
+	Cases    int              `json:"cases"`
+	Failures []ExampleFailure `json:"failures"`
+}
+
+// Passed 表示全部用例通过。
+func (r ExampleReport) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Error 把未通过的用例合并为一条错误，全部通过时返回 nil。
+func (r ExampleReport) Error() error {
+	if r.Passed() {
+		return nil
+	}
+	parts := make([]string, 0, len(r.Failures))
+	for _, failure := range r.Failures {
+		parts = append(parts, failure.String())
+	}
+	return fmt.Errorf("规则未通过测试用例: %s", strings.Join(parts, "；"))
+}
+
+// ParseExamples 按行拆分测试用例，去掉空行和重复行。用例本身可能含逗号，因此只按换行分隔。
+func ParseExamples(raw string) ([]string, error) {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	examples := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || containsString(examples, line) {
+			continue
+		}
+		if len([]rune(line)) > MaxExampleLength {
+			return nil, fmt.Errorf("测试用例不能超过 %d 个字符", MaxExampleLength)
+		}
+		examples = append(examples, line)
+	}
+	if len(examples) > MaxExamples {
+		return nil, fmt.Errorf("每类测试用例最多 %d 条", MaxExamples)
+	}
+	return examples, nil
+}
+
+// FormatExamples 把测试用例格式化为存储用的字符串。
+func FormatExamples(examples []string) string {
+	return strings.Join(examples, "\n")
+}
+
+// RunExamples 用规则自身的匹配逻辑运行测试用例。用例只有文本，不检查评论者条件。
+func RunExamples(rule CompiledRule, mustMatch, mustNotMatch []string) ExampleReport {
+	report := ExampleReport{Cases: len(mustMatch) + len(mustNotMatch), Failures: []ExampleFailure{}}
+	for _, text := range mustMatch {
+		if matched, _ := rule.match(text); matched == "" {
+			report.Failures = append(report.Failures, ExampleFailure{Text: text, Expect: ExpectMatch})
+		}
+	}
+	for _, text := range mustNotMatch {
+		if matched, _ := rule.match(text); matched != "" {
+			report.Failures = append(report.Failures, ExampleFailure{Text: text, Expect: ExpectNoMatch, Matched: matched})
+		}
+	}
+	return report
+}
+
+// TestRule 编译规则并运行它保存的测试用例。
+func TestRule(row models.KeywordRule) (ExampleReport, error) {
+	mustMatch, err := ParseExamples(row.MustMatch)
+	if err != nil {
+		return ExampleReport{}, err
+	}
+	mustNotMatch, err := ParseExamples(row.MustNotMatch)
+	if err != nil {
+		return ExampleReport{}, err
+	}
+	compiled, err := Compile(row)
+	if err != nil {
+		return ExampleReport{}, err
+	}
+	if compiled.MatchType == MatchTypeFlood && len(mustMatch) > 0 {
+		return ExampleReport{}, fmt.Errorf("刷屏规则按评论者的近期活动判断，不支持应命中的测试用例")
+	}
+	return RunExamples(compiled, mustMatch, mustNotMatch), nil
+}
@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestParseExamplesSplitsLinesOnly(t *testing.T) {
+	examples, err := ParseExamples("加群, 领红包\r\n\n  v信123  \n加群, 领红包")
+	if err != nil {
+		t.Fatalf("ParseExamples error: %v", err)
+	}
+	if len(examples) != 2 || examples[0] != "加群, 领红包" || examples[1] != "v信123" {
+		t.Fatalf("ParseExamples = %q", examples)
+	}
+	if FormatExamples(examples) != "加群, 领红包\nv信123" {
+		t.Fatalf("FormatExamples = %q", FormatExamples(examples))
+	}
+
+	if _, err := ParseExamples(strings.Repeat("长", MaxExampleLength+1)); err == nil {
+		t.Fatalf("overlong example should be rejected")
+	}
+	many := make([]string, MaxExamples+1)
+	for i := range many {
+		many[i] = strings.Repeat("a", i+1)
+	}
+	if _, err := ParseExamples(strings.Join(many, "\n")); err == nil {
+		t.Fatalf("too many examples should be rejected")
+	}
+}
+
+func TestTestRuleReportsFailures(t *testing.T) {
+	row := models.KeywordRule{
+		Name:         "引流",
+		Pattern:      "加群 OR 私聊",
+		MatchType:    MatchTypeExpression,
+		Normalize:    "invisible",
+		MustMatch:    "快来加\u200b群\n私聊我\n关注我",
+		MustNotMatch: "群里见\n加群",
+	}
+	report, err := TestRule(row)
+	if err != nil {
+		t.Fatalf("TestRule error: %v", err)
+	}
+	if report.Cases != 5 || report.Passed() {
+		t.Fatalf("report = %+v, want 5 cases with failures", report)
+	}
+	if len(report.Failures) != 2 {
+		t.Fatalf("failures = %+v", report.Failures)
+	}
+	if report.Failures[0].Expect != ExpectMatch || report.Failures[0].Text != "关注我" {
+		t.Fatalf("first failure = %+v", report.Failures[0])
+	}
+	if report.Failures[1].Expect != ExpectNoMatch || report.Failures[1].Matched != "加群" {
+		t.Fatalf("second failure = %+v", report.Failures[1])
+	}
+	if err := report.Error(); err == nil || !strings.Contains(err.Error(), "应命中「关注我」") {
+		t.Fatalf("report error = %v", err)
+	}
+
+	row.MustMatch = "加群"
+	row.MustNotMatch = "群里见"
+	report, err = TestRule(row)
+	if err != nil || !report.Passed() || report.Error() != nil {
+		t.Fatalf("passing examples = %+v, %v", report, err)
+	}
+
+	row.Pattern = "加群 AND"
+	if _, err := TestRule(row); err == nil {
+		t.Fatalf("invalid rule should return a compile error")
+	}
+}
@@ -0,0 +1,339 @@
+package rules
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// 除规则配置的归一化步骤外，匹配时隐含执行的文本处理，写入命中说明的 steps。
+const (
+	StepWidth     = "width"     // 全角字符折叠为半角
+	StepLowercase = "lowercase" // 不区分大小写时转为小写
+	StepExtract   = "extract"   // 还原中文数字、点号变体和逐字空格后提取链接和联系方式
+)
+
+// MaxSpans 是每条规则最多记录的命中片段数，同一条件在长评论中反复出现时只标出前几处。
+const MaxSpans = 20
+
+// Span 是命中片段在评论原文中的位置，区间左闭右开。
+type Span struct {
+	Start     int    `json:"start"` // 字节偏移
+	End       int    `json:"end"`
+	RuneStart int    `json:"rune_start"` // 字符偏移，前端按字符高亮
+	RuneEnd   int    `json:"rune_end"`
+	Text      string `json:"text"` // 原文片段
+	Term      string `json:"term"` // 命中的条件
+	// Group 是正则捕获组编号，0 表示整个匹配。
+	Group int `json:"group,omitempty"`
+}
+
+// Explanation 是一条命中规则的说明，写入举报记录。
+type Explanation struct {
+	RuleID   uint     `json:"rule_id"`
+	RuleName string   `json:"rule_name"`
+	Clause   string   `json:"clause,omitempty"`
+	Steps    []string `json:"steps,omitempty"`
+	Spans    []Span   `json:"spans,omitempty"`
+}
+
+// Explanations 返回各命中规则的说明，近似重复等没有条件和片段的合成命中不包含在内。
+func Explanations(matches []MatchResult) []Explanation {
+	items := make([]Explanation, 0, len(matches))
+	for _, match := range matches {
+		if match.Clause == "" && len(match.Spans) == 0 {
+			continue
+		}
+		items = append(items, Explanation{
+			RuleID:   match.RuleID,
+			RuleName: match.RuleName,
+			Clause:   match.Clause,
+			Steps:    match.Steps,
+			Spans:    match.Spans,
+		})
+	}
+	return items
+}
+
+// FormatExplanations 把命中说明编码为 JSON，没有说明时返回空字符串。
+func FormatExplanations(matches []MatchResult) string {
+	items := Explanations(matches)
+	if len(items) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// spanCollector 收集命中片段：prepared 是实际参与比较的文本，apply 把原文变换为 prepared，
+// 片段先在 prepared 中定位再映射回原文。
+type spanCollector struct {
+	original string
+	apply    func(string) string
+	spans    []Span
+}
+
+func newSpanCollector(original string, apply func(string) string) *spanCollector {
+	if apply != nil && apply(original) == original {
+		// 变换没有改变文本时偏移可以直接使用
+		apply = nil
+	}
+	return &spanCollector{original: original, apply: apply}
+}
+
+func (c *spanCollector) full() bool {
+	return len(c.spans) >= MaxSpans
+}
+
+func (c *spanCollector) add(start, end int, term string, group int) {
+	if c.full() || start >= end {
+		return
+	}
+	if c.apply != nil {
+		start, end = originalSpan(c.original, start, end, c.apply)
+	}
+	c.addOriginal(start, end, term, group)
+}
+
+// addOriginal 添加已经是原文偏移的片段。
+func (c *spanCollector) addOriginal(start, end int, term string, group int) {
+	if c.full() || start < 0 || end > len(c.original) || start >= end {
+		return
+	}
+	for _, span := range c.spans {
+		if span.Start == start && span.End == end && span.Group == group {
+			return
+		}
+	}
+	runeStart := utf8.RuneCountInString(c.original[:start])
+	c.spans = append(c.spans, Span{
+		Start:     start,
+		End:       end,
+		RuneStart: runeStart,
+		RuneEnd:   runeStart + utf8.RuneCountInString(c.original[start:end]),
+		Text:      c.original[start:end],
+		Term:      term,
+		Group:     group,
+	})
+}
+
+func (c *spanCollector) result() []Span {
+	sort.SliceStable(c.spans, func(i, j int) bool {
+		if c.spans[i].Start != c.spans[j].Start {
+			return c.spans[i].Start < c.spans[j].Start
+		}
+		return c.spans[i].Group < c.spans[j].Group
+	})
+	return c.spans
+}
+
+// explain 补充命中说明：实际成立的条件、执行的归一化步骤和命中片段在原文中的位置。
+func (r CompiledRule) explain(text string, result *MatchResult) {
+	var fired []string
+	var spans []Span
+	switch r.MatchType {
+	case MatchTypeFlood:
+		result.Clause = r.Pattern
+		return
+	case MatchTypeBlocklist:
+		result.Steps = []string{NormalizeInvisible, NormalizeNFKC, NormalizeConfusables, StepExtract}
+		fired, spans = r.blocklistSpans(text)
+	case MatchTypeRegex:
+		result.Steps = r.textSteps(false)
+		fired, spans = r.regexSpans(text)
+	case MatchTypePinyin:
+		result.Steps = r.textSteps(true)
+		fired, spans = r.pinyinSpans(text)
+	case MatchTypeExpression:
+		result.Steps = r.textSteps(true)
+		var ctx *exprContext
+		ctx, spans = r.expressionSpans(text)
+		if ctx != nil {
+			result.Clause = r.expression.clause(ctx)
+		}
+	default:
+		result.Steps = r.textSteps(true)
+		if r.MaxGap > 0 {
+			fired, spans = r.gapSpans(text)
+		} else {
+			fired, spans = r.plainSpans(text)
+		}
+	}
+	result.Spans = spans
+	if result.Clause == "" && len(fired) > 0 {
+		if r.MatchLogic == MatchLogicAll {
+			result.Clause = strings.Join(fired, " AND ")
+		} else {
+			result.Clause = fired[0]
+		}
+	}
+}
+
+// textSteps 返回比较前对评论执行的步骤：规则配置的归一化，fold 时再加全角折叠和（不区分大小写时）转小写。
+func (r CompiledRule) textSteps(fold bool) []string {
+	steps := append([]string(nil), r.Normalize...)
+	if fold {
+		steps = append(steps, StepWidth)
+		if !r.CaseSensitive {
+			steps = append(steps, StepLowercase)
+		}
+	}
+	return steps
+}
+
+// prepare 与 matchPlain 和表达式的处理一致：归一化、全角折叠，不区分大小写时转小写。
+func (r CompiledRule) prepare(value string) string {
+	value = width.Fold.String(r.normalizer.Apply(value))
+	if !r.CaseSensitive {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+// indexAll 在 collector 中记录 needle 在 prepared 中的全部出现位置，返回是否出现过。
+func (c *spanCollector) indexAll(prepared, needle, term string) bool {
+	found := false
+	for offset := 0; needle != "" && offset < len(prepared) && !c.full(); {
+		idx := strings.Index(prepared[offset:], needle)
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		c.add(start, start+len(needle), term, 0)
+		found = true
+		offset = start + len(needle)
+	}
+	return found
+}
+
+func (r CompiledRule) plainSpans(text string) ([]string, []Span) {
+	collector := newSpanCollector(text, r.prepare)
+	prepared := r.prepare(text)
+	var fired []string
+	for _, term := range r.plainTerms() {
+		needle := r.prepare(term)
+		if needle != "" && strings.Contains(prepared, needle) {
+			fired = append(fired, term)
+			collector.indexAll(prepared, needle, term)
+		}
+	}
+	return fired, collector.result()
+}
+
+func (r CompiledRule) gapSpans(text string) ([]string, []Span) {
+	collector := newSpanCollector(text, nil)
+	normalized := r.normalizer.Apply(text)
+	var fired []string
+	for _, term := range r.plainTerms() {
+		folded := make([]rune, 0, len(term))
+		for _, c := range r.normalizer.Apply(term) {
+			folded = append(folded, r.foldRune(c))
+		}
+		found := false
+		for offset := 0; offset < len(normalized) && !collector.full(); {
+			start, end, _, ok := r.findWithGaps(normalized[offset:], folded)
+			if !ok {
+				break
+			}
+			found = true
+			start, end = offset+start, offset+end
+			from, to := r.normalizer.OriginalSpan(text, start, end)
+			collector.addOriginal(from, to, term, 0)
+			offset = end
+		}
+		if found {
+			fired = append(fired, term)
+		}
+	}
+	return fired, collector.result()
+}
+
+func (r CompiledRule) regexSpans(text string) ([]string, []Span) {
+	collector := newSpanCollector(text, r.normalizer.Apply)
+	normalized := r.normalizer.Apply(text)
+	var fired []string
+	for idx, re := range r.regexes {
+		if re == nil || re.FindString(normalized) == "" {
+			continue
+		}
+		fired = append(fired, r.terms[idx])
+		for _, loc := range re.FindAllStringSubmatchIndex(normalized, MaxSpans) {
+			for group := 0; group*2+1 < len(loc); group++ {
+				if loc[group*2] >= 0 {
+					collector.add(loc[group*2], loc[group*2+1], r.terms[idx], group)
+				}
+			}
+		}
+	}
+	return fired, collector.result()
+}
+
+func (r CompiledRule) pinyinSpans(text string) ([]string, []Span) {
+	normalized := r.normalizer.Apply(text)
+	folded := strings.ToLower(width.Fold.String(normalized))
+	exact := newSpanCollector(text, func(value string) string {
+		return strings.ToLower(width.Fold.String(r.normalizer.Apply(value)))
+	})
+	units := pinyinUnits(normalized)
+	var fuzzyUnits []pinyinUnit
+	if r.Homophone {
+		fuzzyUnits = withHomophones(units)
+	}
+	var fired []string
+	for idx, term := range r.pinyinTerms {
+		if exact.indexAll(folded, strings.ToLower(width.Fold.String(r.normalizer.Apply(r.terms[idx]))), r.terms[idx]) {
+			fired = append(fired, r.terms[idx])
+			continue
+		}
+		from, to, _, ok := alignPinyin(term.units, units)
+		if !ok && r.Homophone {
+			from, to, _, ok = alignPinyin(term.fuzzy, fuzzyUnits)
+		}
+		if !ok || to <= from {
+			continue
+		}
+		fired = append(fired, r.terms[idx])
+		start, end := r.normalizer.OriginalSpan(text, units[from].start, units[to-1].end)
+		exact.addOriginal(start, end, r.terms[idx], 0)
+	}
+	return fired, exact.result()
+}
+
+func (r CompiledRule) expressionSpans(text string) (*exprContext, []Span) {
+	ctx, ok := r.evalExpression(r.normalizer.Apply(text))
+	if !ok {
+		return nil, nil
+	}
+	collector := newSpanCollector(text, r.prepare)
+	for _, item := range ctx.evidence {
+		term := item.text
+		if item.source != nil {
+			term = item.source.String()
+		}
+		collector.add(item.from, item.to, term, 0)
+	}
+	return ctx, collector.result()
+}
+
+func (r CompiledRule) blocklistSpans(text string) ([]string, []Span) {
+	collector := newSpanCollector(text, prepareForExtract)
+	var fired []string
+	for _, entity := range Extract(text) {
+		for _, entry := range r.blocklist {
+			if entry.matches(entity) {
+				if !containsString(fired, entry.String()) {
+					fired = append(fired, entry.String())
+				}
+				collector.add(entity.offset, entity.end, entity.String(), 0)
+				break
+			}
+		}
+	}
+	return fired, collector.result()
+}
@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func explainOne(t *testing.T, rule models.KeywordRule, text string) MatchResult {
+	t.Helper()
+	rule.Enabled = true
+	compiled, err := Compile(rule)
+	if err != nil {
+		t.Fatalf("Compile(%q) failed: %v", rule.Pattern, err)
+	}
+	match := NewEngine([]CompiledRule{compiled}).MatchText(text)
+	if match == nil {
+		t.Fatalf("expected %q to match %q", rule.Pattern, text)
+	}
+	if direct := MatchText(text, []CompiledRule{compiled}); !reflect.DeepEqual(direct, match) {
+		t.Fatalf("engine and MatchText disagree:\n%+v\n%+v", match, direct)
+	}
+	return *match
+}
+
+func spanTexts(spans []Span) []string {
+	texts := make([]string, 0, len(spans))
+	for _, span := range spans {
+		texts = append(texts, span.Text)
+	}
+	return texts
+}
+
+func TestExplainPlainMapsSpansToOriginal(t *testing.T) {
+	text := "快加ＶＸ领取福利，再说一遍加vx"
+	match := explainOne(t, models.KeywordRule{Pattern: "vx,领取", MatchLogic: MatchLogicAll}, text)
+	if match.Clause != "vx AND 领取" {
+		t.Fatalf("Clause = %q", match.Clause)
+	}
+	if got := spanTexts(match.Spans); !reflect.DeepEqual(got, []string{"ＶＸ", "领取", "vx"}) {
+		t.Fatalf("spans = %v", got)
+	}
+	first := match.Spans[0]
+	if text[first.Start:first.End] != "ＶＸ" || first.RuneStart != 2 || first.RuneEnd != 4 {
+		t.Fatalf("unexpected offsets %+v", first)
+	}
+	if !reflect.DeepEqual(match.Steps, []string{StepWidth, StepLowercase}) {
+		t.Fatalf("Steps = %v", match.Steps)
+	}
+
+	normalized := explainOne(t, models.KeywordRule{Pattern: "视频", Normalize: "invisible,t2s"}, "看這個視​頻")
+	if got := spanTexts(normalized.Spans); !reflect.DeepEqual(got, []string{"視​頻"}) {
+		t.Fatalf("normalized spans = %q", got)
+	}
+	if !reflect.DeepEqual(normalized.Steps, []string{NormalizeInvisible, NormalizeT2S, StepWidth, StepLowercase}) {
+		t.Fatalf("normalized steps = %v", normalized.Steps)
+	}
+}
+
+func TestExplainRegexGroups(t *testing.T) {
+	match := explainOne(t, models.KeywordRule{Pattern: `加(?:群|q)(\d{5,})`, MatchType: MatchTypeRegex}, "速度加群12345678")
+	if len(match.Spans) != 2 {
+		t.Fatalf("expected whole match and group, got %+v", match.Spans)
+	}
+	if match.Spans[0].Text != "加群12345678" || match.Spans[0].Group != 0 {
+		t.Fatalf("unexpected whole match %+v", match.Spans[0])
+	}
+	if match.Spans[1].Text != "12345678" || match.Spans[1].Group != 1 || match.Spans[1].RuneStart != 4 {
+		t.Fatalf("unexpected group span %+v", match.Spans[1])
+	}
+	if match.Clause != `加(?:群|q)(\d{5,})` || len(match.Steps) != 0 {
+		t.Fatalf("unexpected clause %q / steps %v", match.Clause, match.Steps)
+	}
+}
+
+func TestExplainExpressionClause(t *testing.T) {
+	match := explainOne(t, models.KeywordRule{Pattern: `(免费 OR "领 福利") AND NOT 广告`, MatchType: MatchTypeExpression}, "评论区可以领 福利了")
+	if match.Clause != `"领 福利" AND NOT 广告` {
+		t.Fatalf("Clause = %q", match.Clause)
+	}
+	if got := spanTexts(match.Spans); !reflect.DeepEqual(got, []string{"领 福利"}) {
+		t.Fatalf("spans = %v", got)
+	}
+
+	near := explainOne(t, models.KeywordRule{Pattern: `加 NEAR/3 re:/v[x信]/`, MatchType: MatchTypeExpression}, "私信加个VX")
+	if near.Clause != `加 NEAR/3 re:/v[x信]/` {
+		t.Fatalf("near Clause = %q", near.Clause)
+	}
+	if got := spanTexts(near.Spans); !reflect.DeepEqual(got, []string{"加", "VX"}) {
+		t.Fatalf("near spans = %v", got)
+	}
+	if near.Spans[1].Term != `re:/v[x信]/` {
+		t.Fatalf("near term = %q", near.Spans[1].Term)
+	}
+}
+
+func TestExplainPinyinAndBlocklist(t *testing.T) {
+	match := explainOne(t, models.KeywordRule{Pattern: "加微信", MatchType: MatchTypePinyin}, "大家 jia wei xin 聊")
+	if got := spanTexts(match.Spans); !reflect.DeepEqual(got, []string{"jia wei xin"}) {
+		t.Fatalf("pinyin spans = %v", got)
+	}
+	if match.Normalization != NormalizationPinyin || match.Clause != "加微信" {
+		t.Fatalf("unexpected pinyin result %+v", match)
+	}
+
+	blocked := explainOne(t, models.KeywordRule{Pattern: "qq:*", MatchType: MatchTypeBlocklist}, "有事加QQ：12345678，谢谢")
+	if blocked.Clause != "qq:*" || len(blocked.Spans) != 1 || blocked.Spans[0].Text != "12345678" {
+		t.Fatalf("unexpected blocklist explanation %+v", blocked)
+	}
+}
+
+func TestFormatExplanations(t *testing.T) {
+	compiled, _ := CompileMany([]models.KeywordRule{
+		{ID: 1, Name: "引流", Pattern: "加群", Enabled: true},
+		{ID: 2, Name: "刷屏", Pattern: "comments >= 3 within 10m", MatchType: MatchTypeFlood, Enabled: true},
+	}, "")
+	matches := NewEngine(compiled).MatchAll("快来加群")
+	matches = append(matches, MatchResult{RuleName: "近似重复评论", Matched: "簇 #1", ClusterID: 1})
+	var items []Explanation
+	if err := json.Unmarshal([]byte(FormatExplanations(matches)), &items); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(items) != 1 || items[0].RuleID != 1 || items[0].Spans[0].RuneStart != 2 || items[0].Spans[0].RuneEnd != 4 {
+		t.Fatalf("unexpected explanations %+v", items)
+	}
+	if FormatExplanations(nil) != "" {
+		t.Fatal("expected empty string without matches")
+	}
+}
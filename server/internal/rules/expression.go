@@ -0,0 +1,658 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// MaxNearDistance 是 NEAR/n 允许的最大间隔字符数。
+const MaxNearDistance = 200
+
+// ExpressionError 是表达式解析错误，Column 为出错位置（从 1 开始的字符序号）。
+type ExpressionError struct {
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("表达式第 %d 个字符处%s", e.Column, e.Message)
+}
+
+type exprTokenKind uint8
+
+const (
+	tokenEOF exprTokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenNear
+	tokenTerm
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	pos  int
+	// text 是词语、短语内容或正则源码；near 为 NEAR/n 的距离。
+	text  string
+	regex bool
+	near  int
+}
+
+// exprLexer 按字符切分表达式，位置以字符（rune）计，便于在界面中定位。
+type exprLexer struct {
+	src []rune
+	pos int
+}
+
+func (l *exprLexer) errorf(pos int, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{Column: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *exprLexer) tokens() ([]exprToken, error) {
+	var tokens []exprToken
+	for {
+		token, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		if token.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *exprLexer) next() (exprToken, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return exprToken{kind: tokenEOF, pos: start}, nil
+	}
+	switch c := l.src[l.pos]; c {
+	case '(', '（':
+		l.pos++
+		return exprToken{kind: tokenLParen, pos: start}, nil
+	case ')', '）':
+		l.pos++
+		return exprToken{kind: tokenRParen, pos: start}, nil
+	case '"', '“':
+		return l.phrase(c)
+	}
+	if l.hasPrefix("re:/") {
+		return l.regex()
+	}
+
+	for l.pos < len(l.src) && !isExprDelimiter(l.src[l.pos]) {
+		l.pos++
+	}
+	word := string(l.src[start:l.pos])
+	switch word {
+	case "AND":
+		return exprToken{kind: tokenAnd, pos: start}, nil
+	case "OR":
+		return exprToken{kind: tokenOr, pos: start}, nil
+	case "NOT":
+		return exprToken{kind: tokenNot, pos: start}, nil
+	}
+	if rest, ok := strings.CutPrefix(word, "NEAR/"); ok || word == "NEAR" {
+		distance, err := strconv.Atoi(rest)
+		if err != nil || distance < 0 {
+			return exprToken{}, l.errorf(start, "NEAR 后需要非负整数距离，例如 NEAR/5")
+		}
+		if distance > MaxNearDistance {
+			return exprToken{}, l.errorf(start, "NEAR 距离不能超过 %d", MaxNearDistance)
+		}
+		return exprToken{kind: tokenNear, pos: start, near: distance}, nil
+	}
+	return exprToken{kind: tokenTerm, pos: start, text: word}, nil
+}
+
+func (l *exprLexer) hasPrefix(prefix string) bool {
+	i := l.pos
+	for _, r := range prefix {
+		if i >= len(l.src) || l.src[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// phrase 读取引号短语，支持 \" 和 \\ 转义；中文引号以 ” 结尾。
+func (l *exprLexer) phrase(open rune) (exprToken, error) {
+	start := l.pos
+	closing := '"'
+	if open == '“' {
+		closing = '”'
+	}
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			b.WriteRune(l.src[l.pos+1])
+			l.pos += 2
+			continue
+		case c == closing:
+			l.pos++
+			if strings.TrimSpace(b.String()) == "" {
+				return exprToken{}, l.errorf(start, "引号内容不能为空")
+			}
+			return exprToken{kind: tokenTerm, pos: start, text: b.String()}, nil
+		}
+		b.WriteRune(c)
+		l.pos++
+	}
+	return exprToken{}, l.errorf(start, "引号没有闭合")
+}
+
+// regex 读取 re:/.../ 字面量，正文中的 \/ 表示斜杠本身。
+func (l *exprLexer) regex() (exprToken, error) {
+	start := l.pos
+	l.pos += len("re:/")
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '/':
+			b.WriteRune('/')
+			l.pos += 2
+			continue
+		case c == '/':
+			l.pos++
+			if b.Len() == 0 {
+				return exprToken{}, l.errorf(start, "正则内容不能为空")
+			}
+			return exprToken{kind: tokenTerm, pos: start, text: b.String(), regex: true}, nil
+		}
+		b.WriteRune(c)
+		l.pos++
+	}
+	return exprToken{}, l.errorf(start, "正则字面量缺少结尾的 /")
+}
+
+func isExprDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '（' || r == '）' || r == '"' || r == '“'
+}
+
+// exprParser 是递归下降解析器，优先级从低到高为 OR、AND（可省略）、NOT、NEAR。
+type exprParser struct {
+	tokens        []exprToken
+	pos           int
+	caseSensitive bool
+	normalizer    Normalizer
+}
+
+// parseExpression 把布尔表达式解析为语法树，错误为带位置的 *ExpressionError。
+// 词语和短语在解析时就按规则的归一化和大小写设置预处理好。
+func parseExpression(source string, caseSensitive bool, normalizer Normalizer) (exprNode, error) {
+	lexer := &exprLexer{src: []rune(source)}
+	tokens, err := lexer.tokens()
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, caseSensitive: caseSensitive, normalizer: normalizer}
+	if p.peek().kind == tokenEOF {
+		return nil, &ExpressionError{Column: 1, Message: "表达式不能为空"}
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != tokenEOF {
+		if token.kind == tokenRParen {
+			return nil, p.errorAt(token, "多余的右括号")
+		}
+		return nil, p.errorAt(token, "无法识别的内容")
+	}
+	if !node.positive() {
+		return nil, &ExpressionError{Column: 1, Message: "每个分支都至少需要一个不在 NOT 中的条件"}
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) advance() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *exprParser) errorAt(token exprToken, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{Column: token.pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []exprNode{left}
+	for p.peek().kind == tokenOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, right)
+	}
+	if len(children) == 1 {
+		return left, nil
+	}
+	return &exprOr{children: children}, nil
+}
+
+// parseAnd 解析 AND，相邻两个条件之间省略运算符时同样视为 AND。
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	children := []exprNode{left}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.advance()
+		case tokenTerm, tokenLParen, tokenNot:
+		default:
+			if len(children) == 1 {
+				return left, nil
+			}
+			return &exprAnd{children: children}, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, right)
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.peek().kind != tokenNot {
+		return p.parseNear()
+	}
+	p.advance()
+	child, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &exprNot{child: child}, nil
+}
+
+func (p *exprParser) parseNear() (exprNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenNear {
+		return left, nil
+	}
+	nearToken := p.advance()
+	leftTerm, ok := left.(*exprTerm)
+	if !ok {
+		return nil, p.errorAt(nearToken, "NEAR 左侧只能是词语、短语或正则")
+	}
+	rightToken := p.peek()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	rightTerm, ok := right.(*exprTerm)
+	if !ok {
+		return nil, p.errorAt(rightToken, "NEAR 右侧只能是词语、短语或正则")
+	}
+	if next := p.peek(); next.kind == tokenNear {
+		return nil, p.errorAt(next, "NEAR 不能连续使用，请用 AND 组合多个 NEAR")
+	}
+	return &exprNear{left: leftTerm, right: rightTerm, distance: nearToken.near}, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	token := p.advance()
+	switch token.kind {
+	case tokenTerm:
+		return p.term(token)
+	case tokenLParen:
+		if p.peek().kind == tokenRParen {
+			return nil, p.errorAt(p.peek(), "括号内不能为空")
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenRParen {
+			return nil, p.errorAt(token, "缺少对应的右括号")
+		}
+		p.advance()
+		return node, nil
+	case tokenEOF:
+		return nil, p.errorAt(token, "表达式不完整，缺少条件")
+	case tokenRParen:
+		return nil, p.errorAt(token, "多余的右括号")
+	case tokenNear:
+		return nil, p.errorAt(token, "NEAR 前缺少条件")
+	default:
+		return nil, p.errorAt(token, "运算符前缺少条件")
+	}
+}
+
+func (p *exprParser) term(token exprToken) (exprNode, error) {
+	term := &exprTerm{raw: token.text}
+	if token.regex {
+		re, err := regexp.Compile(regexPattern(token.text, p.caseSensitive))
+		if err != nil {
+			return nil, p.errorAt(token, "正则表达式无效: %v", err)
+		}
+		term.re = re
+		return term, nil
+	}
+	term.needle = width.Fold.String(p.normalizer.Apply(token.text))
+	if !p.caseSensitive {
+		term.needle = strings.ToLower(term.needle)
+	}
+	if term.needle == "" {
+		return nil, p.errorAt(token, "条件在归一化后为空")
+	}
+	return term, nil
+}
+
+// exprNode 是表达式语法树节点。eval 命中时把证据追加到 ctx 中。
+type exprNode interface {
+	eval(ctx *exprContext) bool
+	// positive 表示节点命中时一定能给出证据，纯 NOT 的分支不满足。
+	positive() bool
+	// clause 返回节点命中时实际成立的部分，OR 只保留第一个成立的分支。
+	clause(ctx *exprContext) string
+	// String 按表达式语法输出节点。
+	String() string
+}
+
+// exprContext 保存一次匹配的预处理文本和已收集的证据。
+type exprContext struct {
+	text     string
+	evidence []exprSpan
+}
+
+// exprTerm 是词语、短语或正则条件，raw 为表达式中的原始写法，needle 为预处理后的查找串。
+type exprTerm struct {
+	raw    string
+	needle string
+	re     *regexp.Regexp
+}
+
+// exprSpan 是命中片段在文本中的字符区间（左闭右开），from 和 to 为对应的字节区间，source 为命中的条件。
+type exprSpan struct {
+	start, end int
+	from, to   int
+	text       string
+	source     *exprTerm
+}
+
+// spans 返回条件在文本中出现的全部位置。
+func (t *exprTerm) spans(ctx *exprContext) []exprSpan {
+	var spans []exprSpan
+	if t.re != nil {
+		for _, loc := range t.re.FindAllStringIndex(ctx.text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			spans = append(spans, runeSpan(ctx.text, loc[0], loc[1], ctx.text[loc[0]:loc[1]], t))
+		}
+		return spans
+	}
+	needle := t.needle
+	for offset := 0; offset < len(ctx.text); {
+		idx := strings.Index(ctx.text[offset:], needle)
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		spans = append(spans, runeSpan(ctx.text, start, start+len(needle), t.raw, t))
+		_, size := utf8.DecodeRuneInString(ctx.text[start:])
+		offset = start + size
+	}
+	return spans
+}
+
+func runeSpan(text string, start, end int, evidence string, source *exprTerm) exprSpan {
+	runeStart := utf8.RuneCountInString(text[:start])
+	return exprSpan{start: runeStart, end: runeStart + utf8.RuneCountInString(text[start:end]), from: start, to: end, text: evidence, source: source}
+}
+
+func (t *exprTerm) eval(ctx *exprContext) bool {
+	if t.re != nil {
+		loc := t.re.FindStringIndex(ctx.text)
+		if loc == nil || loc[0] == loc[1] {
+			return false
+		}
+		ctx.evidence = append(ctx.evidence, exprSpan{from: loc[0], to: loc[1], text: ctx.text[loc[0]:loc[1]], source: t})
+		return true
+	}
+	idx := strings.Index(ctx.text, t.needle)
+	if idx < 0 {
+		return false
+	}
+	ctx.evidence = append(ctx.evidence, exprSpan{from: idx, to: idx + len(t.needle), text: t.raw, source: t})
+	return true
+}
+
+func (t *exprTerm) positive() bool { return true }
+
+func (t *exprTerm) clause(*exprContext) string { return t.String() }
+
+// String 输出条件的表达式写法：正则为 re:/.../，含空白、括号或与运算符同名的词语加引号。
+func (t *exprTerm) String() string {
+	if t.re != nil {
+		return "re:/" + strings.ReplaceAll(t.raw, "/", `\/`) + "/"
+	}
+	switch strings.ToUpper(t.raw) {
+	case "AND", "OR", "NOT":
+		return strconv.Quote(t.raw)
+	}
+	if strings.HasPrefix(strings.ToUpper(t.raw), "NEAR/") || strings.IndexFunc(t.raw, isExprDelimiter) >= 0 || strings.ContainsRune(t.raw, '\\') {
+		return strconv.Quote(t.raw)
+	}
+	return t.raw
+}
+
+type exprAnd struct {
+	children []exprNode
+}
+
+func (n *exprAnd) eval(ctx *exprContext) bool {
+	mark := len(ctx.evidence)
+	for _, child := range n.children {
+		if !child.eval(ctx) {
+			ctx.evidence = ctx.evidence[:mark]
+			return false
+		}
+	}
+	return true
+}
+
+func (n *exprAnd) positive() bool {
+	for _, child := range n.children {
+		if child.positive() {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *exprAnd) clause(ctx *exprContext) string {
+	parts := make([]string, 0, len(n.children))
+	for _, child := range n.children {
+		parts = append(parts, groupClause(firedBranch(child, ctx), child.clause(ctx)))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+func (n *exprAnd) String() string {
+	parts := make([]string, 0, len(n.children))
+	for _, child := range n.children {
+		parts = append(parts, groupClause(child, child.String()))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+type exprOr struct {
+	children []exprNode
+}
+
+func (n *exprOr) eval(ctx *exprContext) bool {
+	for _, child := range n.children {
+		if child.eval(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *exprOr) positive() bool {
+	for _, child := range n.children {
+		if !child.positive() {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *exprOr) clause(ctx *exprContext) string {
+	if branch := firedBranch(n, ctx); branch != exprNode(n) {
+		return branch.clause(ctx)
+	}
+	return n.String()
+}
+
+// firedBranch 沿 OR 节点找到第一个成立的分支，其他节点原样返回。
+func firedBranch(node exprNode, ctx *exprContext) exprNode {
+	or, ok := node.(*exprOr)
+	if !ok {
+		return node
+	}
+	for _, child := range or.children {
+		if child.eval(&exprContext{text: ctx.text}) {
+			return firedBranch(child, ctx)
+		}
+	}
+	return node
+}
+
+func (n *exprOr) String() string {
+	parts := make([]string, 0, len(n.children))
+	for _, child := range n.children {
+		parts = append(parts, groupClause(child, child.String()))
+	}
+	return strings.Join(parts, " OR ")
+}
+
+type exprNot struct {
+	child exprNode
+}
+
+func (n *exprNot) eval(ctx *exprContext) bool {
+	mark := len(ctx.evidence)
+	matched := n.child.eval(ctx)
+	ctx.evidence = ctx.evidence[:mark]
+	return !matched
+}
+
+func (n *exprNot) positive() bool { return false }
+
+func (n *exprNot) clause(*exprContext) string { return n.String() }
+
+func (n *exprNot) String() string { return "NOT " + groupClause(n.child, n.child.String()) }
+
+// exprNear 要求两个条件的出现位置之间最多相隔 distance 个字符，先后顺序不限。
+type exprNear struct {
+	left, right *exprTerm
+	distance    int
+}
+
+func (n *exprNear) eval(ctx *exprContext) bool {
+	rights := n.right.spans(ctx)
+	if len(rights) == 0 {
+		return false
+	}
+	for _, left := range n.left.spans(ctx) {
+		for _, right := range rights {
+			if spanGap(left, right) <= n.distance {
+				ctx.evidence = append(ctx.evidence, left, right)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (n *exprNear) positive() bool { return true }
+
+func (n *exprNear) clause(*exprContext) string { return n.String() }
+
+func (n *exprNear) String() string {
+	return fmt.Sprintf("%s NEAR/%d %s", n.left.String(), n.distance, n.right.String())
+}
+
+// groupClause 给 AND、OR 子树加括号，保证输出的子句可以按原优先级重新解析。
+func groupClause(node exprNode, text string) string {
+	switch node.(type) {
+	case *exprAnd, *exprOr:
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func spanGap(a, b exprSpan) int {
+	switch {
+	case a.end <= b.start:
+		return b.start - a.end
+	case b.end <= a.start:
+		return a.start - b.end
+	default:
+		return 0
+	}
+}
+
+// matchExpression 对归一化后的文本求值，命中时返回去重后的证据。
+func (r CompiledRule) matchExpression(text string) string {
+	ctx, ok := r.evalExpression(text)
+	if !ok {
+		return ""
+	}
+	evidence := make([]string, 0, len(ctx.evidence))
+	for _, item := range ctx.evidence {
+		if !containsString(evidence, item.text) {
+			evidence = append(evidence, item.text)
+		}
+	}
+	return strings.Join(evidence, ", ")
+}
+
+// evalExpression 对归一化后的文本求值，返回的上下文中 text 为全角折叠、按需转小写后的文本，证据的字节区间基于它。
+func (r CompiledRule) evalExpression(text string) (*exprContext, bool) {
+	if r.expression == nil {
+		return nil, false
+	}
+	ctx := &exprContext{text: width.Fold.String(text)}
+	if !r.CaseSensitive {
+		ctx.text = strings.ToLower(ctx.text)
+	}
+	return ctx, r.expression.eval(ctx)
+}
@@ -0,0 +1,132 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func compileExpression(t *testing.T, pattern string, caseSensitive bool, normalize string) CompiledRule {
+	t.Helper()
+	rule, err := Compile(models.KeywordRule{
+		Name:          "expr",
+		Pattern:       pattern,
+		MatchType:     MatchTypeExpression,
+		MatchLogic:    MatchLogicAll,
+		CaseSensitive: caseSensitive,
+		Normalize:     normalize,
+		Enabled:       true,
+	})
+	if err != nil {
+		t.Fatalf("Compile(%q) failed: %v", pattern, err)
+	}
+	return rule
+}
+
+func TestExpressionMatching(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		text    string
+		want    string
+	}{
+		{"or group and not", "(代写 OR 代考) AND NOT 举报", "专业代考，价格优惠", "代考"},
+		{"excluded context", "(代写 OR 代考) AND NOT 举报", "举报代写广告", ""},
+		{"implicit and", "加 微信", "加我微信", "加, 微信"},
+		{"implicit and miss", "加 微信", "加我QQ", ""},
+		{"precedence and over or", "a OR b AND c", "only b here", ""},
+		{"precedence or branch", "a OR b AND c", "has a", "a"},
+		{"quoted phrase keeps space", `"free money" NOT scam`, "get FREE MONEY now", "free money"},
+		{"quoted operator word", `"AND"`, "this and that", "AND"},
+		{"chinese quotes and parens", "“领红包”（微信 OR QQ）", "加QQ领红包", "领红包, QQ"},
+		{"regex literal", `re:/v[信x]\d{5,}/ NOT 官方`, "加v信123456", "v信123456"},
+		{"regex with slash", `re:/b23\.tv\/\w+/`, "戳 b23.tv/AbCd", "b23.tv/abcd"},
+		{"near within distance", "加 NEAR/3 微信", "加一下我微信", "加, 微信"},
+		{"near reversed order", "加 NEAR/3 微信", "微信来加", "加, 微信"},
+		{"near too far", "加 NEAR/3 微信", "加油，这期视频真好，微信", ""},
+		{"near with regex", `re:/\d{6,}/ NEAR/2 QQ`, "QQ：123456789", "123456789, QQ"},
+		{"full width folding", "vx", "ＶＸ联系", "vx"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := compileExpression(t, tc.pattern, false, "")
+			if got := rule.Match(tc.text); got != tc.want {
+				t.Fatalf("Match(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExpressionCaseSensitivityAndNormalize(t *testing.T) {
+	rule := compileExpression(t, "VX NOT vx", true, "")
+	if got := rule.Match("加VX"); got != "VX" {
+		t.Fatalf("case sensitive match = %q", got)
+	}
+	if got := rule.Match("加VX vx"); got != "" {
+		t.Fatalf("case sensitive exclusion = %q", got)
+	}
+
+	rule = compileExpression(t, "領取 AND 紅包", false, "t2s,invisible")
+	if got := rule.Match("领\u200B取红包"); got != "領取, 紅包" {
+		t.Fatalf("normalized match = %q", got)
+	}
+	if rule.MatchLogic != MatchLogicSingle {
+		t.Fatalf("expression rules should ignore match logic, got %q", rule.MatchLogic)
+	}
+}
+
+func TestExpressionParseErrors(t *testing.T) {
+	cases := []struct {
+		pattern string
+		column  int
+	}{
+		{"(代写 OR 代考", 1},
+		{"代写 OR 代考)", 9},
+		{"代写 OR", 6},
+		{"AND 代写", 1},
+		{"代写 AND ()", 9},
+		{`"未闭合`, 1},
+		{`a ""`, 3},
+		{"a re:/abc", 3},
+		{"a re:/(/", 3},
+		{"a NEAR/x b", 3},
+		{"a NEAR/999 b", 3},
+		{"(a OR b) NEAR/2 c", 10},
+		{"a NEAR/2 (b OR c)", 10},
+		{"a NEAR/2 b NEAR/2 c", 12},
+		{"NOT 举报", 1},
+		{"代写 OR NOT 举报", 1},
+		{"NOT NOT 广告", 1},
+		{"   ", 1},
+	}
+	for _, tc := range cases {
+		err := Validate(tc.pattern, MatchTypeExpression, false)
+		if tc.pattern == "   " {
+			if err == nil {
+				t.Fatalf("expected error for blank expression")
+			}
+			continue
+		}
+		var exprErr *ExpressionError
+		if !errors.As(err, &exprErr) {
+			t.Fatalf("Validate(%q) error = %v, want *ExpressionError", tc.pattern, err)
+		}
+		if exprErr.Column != tc.column {
+			t.Fatalf("Validate(%q) column = %d (%v), want %d", tc.pattern, exprErr.Column, err, tc.column)
+		}
+	}
+}
+
+func TestExpressionFallsBackInEngine(t *testing.T) {
+	compiled := mustCompileRules(t, []models.KeywordRule{
+		{Pattern: "(代写 OR 代考) NOT 举报", MatchType: MatchTypeExpression},
+		{Pattern: "代写", MatchType: MatchTypePlain},
+	}, "")
+	engine := NewEngine(compiled)
+	for _, text := range []string{"代写论文", "举报代写", "无关"} {
+		if got, want := engine.MatchAll(text), MatchAll(text, compiled); len(got) != len(want) {
+			t.Fatalf("MatchAll(%q) engine=%v linear=%v", text, got, want)
+		}
+	}
+}
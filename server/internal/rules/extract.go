@@ -0,0 +1,198 @@
+package rules
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 评论中可提取的实体类型。
+const (
+	EntityURL    = "url"
+	EntityQQ     = "qq"
+	EntityWeChat = "wechat"
+	EntityPhone  = "phone"
+)
+
+// Entity 是从评论中提取出的链接或联系方式。
+type Entity struct {
+	Kind string `json:"kind"`
+	// Value 是规范化后的实体：链接为小写域名加路径（不含协议），其余为号码或账号本身。
+	Value string `json:"value"`
+	// Domain 是链接的小写域名，其他类型为空。
+	Domain string `json:"domain,omitempty"`
+	// offset 和 end 是实体在预处理后文本中的字节区间。
+	offset, end int
+}
+
+// String 返回写入举报记录的实体文本：链接直接返回 Value，联系方式带类型前缀，如 qq:12345678。
+func (e Entity) String() string {
+	if e.Kind == EntityURL {
+		return e.Value
+	}
+	return e.Kind + ":" + e.Value
+}
+
+// 常见的顶级域名，没有协议头的文本只有以这些后缀结尾才视为域名，避免把 “a.b” 之类的普通文本当成链接。
+var domainSuffixes = []string{
+	"com", "cn", "net", "org", "tv", "cc", "top", "xyz", "io", "me", "info", "vip", "shop", "club",
+	"site", "online", "link", "ly", "co", "gg", "app", "fun", "ink", "work", "live", "pro", "biz",
+	"wang", "store", "icu", "asia", "hk", "tw", "jp", "us", "uk", "ru", "to", "la", "so", "sh", "im",
+}
+
+// urlPath 是链接路径允许的字符，遇到中文、空白或逗号即结束。
+const urlPath = `/[a-z0-9/_\-?=&%.#~+]*`
+
+var (
+	extractNormalizer = NewNormalizer([]string{NormalizeInvisible, NormalizeNFKC, NormalizeConfusables})
+
+	// 点号的常见变体：句号、中点、“点”、dot 及各种括号包裹的写法。
+	dotPattern = regexp.MustCompile(`(?i)([a-z0-9])\s*(?:\(\s*(?:\.|dot|点)\s*\)|\[\s*(?:\.|dot|点)\s*\]|\{\s*(?:\.|dot|点)\s*\}|\s+dot\s+|[.。．·・点])\s*([a-z0-9])`)
+	// 逐字用空格隔开的字母数字，如 “b 2 3 . t v”。
+	spacedPattern = regexp.MustCompile(`(?i)(?:[a-z0-9] ){2,}[a-z0-9]`)
+
+	urlPattern    = regexp.MustCompile(`(?i)https?://([a-z0-9.-]+\.[a-z]{2,})(?::\d+)?(` + urlPath + `)?`)
+	domainPattern = regexp.MustCompile(`(?i)((?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+(?:` + strings.Join(domainSuffixes, "|") + `))\b(` + urlPath + `)?`)
+	qqMailPattern = regexp.MustCompile(`(?i)(?:^|\D)([1-9]\d{4,10})@qq\.com`)
+	// 号码允许数字间有单个空格、横线或下划线，如 “138 0013-8000”。
+	qqPattern     = regexp.MustCompile(`(?i)(?:qq|扣扣|扣|企鹅|q群|群号|群)\s*(?:号码|号)?\s*(?:[:：是为]\s*)?([1-9](?:[ \-_]?\d){4,10})(?:\D|$)`)
+	wechatPattern = regexp.MustCompile(`(?i)(?:微信|威信|薇信|徽信|wechat|weixin|vx|v信|wx|加v|\+v)\s*(?:号)?\s*(?:[:：是为]\s*)?([a-z][a-z0-9_-]{5,19}|1[3-9]\d{9})(?:[^a-z0-9_-]|$)`)
+	phonePattern  = regexp.MustCompile(`1[3-9](?:[ \-_]?\d){9}`)
+)
+
+var chineseDigits = map[rune]rune{
+	'〇': '0', '零': '0', '一': '1', '壹': '1', '幺': '1', '二': '2', '贰': '2', '两': '2',
+	'三': '3', '叁': '3', '四': '4', '肆': '4', '五': '5', '伍': '5', '六': '6', '陆': '6',
+	'七': '7', '柒': '7', '八': '8', '捌': '8', '九': '9', '玖': '9',
+}
+
+// minChineseDigitRun 是连续中文数字被当作号码改写为阿拉伯数字的最短长度，短于它的多半是正常用语。
+const minChineseDigitRun = 5
+
+// Extract 提取评论中的链接（含 b23.tv 短链和用空格、“点”隔开的域名）、QQ 号、微信号和手机号，
+// 按出现顺序返回，同一实体只返回一次。
+func Extract(text string) []Entity {
+	prepared := prepareForExtract(text)
+	var entities []Entity
+	seen := map[string]bool{}
+	add := func(entity Entity) {
+		key := entity.String()
+		if entity.Value == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		entities = append(entities, entity)
+	}
+
+	urlSpans := urlPattern.FindAllStringSubmatchIndex(prepared, -1)
+	for _, m := range urlSpans {
+		add(urlEntity(prepared[m[2]:m[3]], submatch(prepared, m, 4), m[0], m[1]))
+	}
+	for _, m := range domainPattern.FindAllStringSubmatchIndex(prepared, -1) {
+		// 前面紧跟字母数字、点、横线或 @ 的是更长文本或邮箱的一部分，邮箱按 QQ 邮箱单独处理
+		if insideSpans(m[2], urlSpans) || (m[2] > 0 && strings.ContainsRune("@.-_", rune(prepared[m[2]-1]))) {
+			continue
+		}
+		add(urlEntity(prepared[m[2]:m[3]], submatch(prepared, m, 4), m[2], m[1]))
+	}
+	for _, m := range qqMailPattern.FindAllStringSubmatchIndex(prepared, -1) {
+		add(Entity{Kind: EntityQQ, Value: prepared[m[2]:m[3]], offset: m[2], end: m[3]})
+	}
+	for _, m := range qqPattern.FindAllStringSubmatchIndex(prepared, -1) {
+		add(Entity{Kind: EntityQQ, Value: digitsOnly(prepared[m[2]:m[3]]), offset: m[2], end: m[3]})
+	}
+	for _, m := range wechatPattern.FindAllStringSubmatchIndex(prepared, -1) {
+		add(Entity{Kind: EntityWeChat, Value: strings.ToLower(prepared[m[2]:m[3]]), offset: m[2], end: m[3]})
+	}
+	for _, m := range phonePattern.FindAllStringIndex(prepared, -1) {
+		// 更长数字串的一部分不算手机号
+		if isDigitAt(prepared, m[0]-1) || isDigitAt(prepared, m[1]) {
+			continue
+		}
+		add(Entity{Kind: EntityPhone, Value: digitsOnly(prepared[m[0]:m[1]]), offset: m[0], end: m[1]})
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool { return entities[i].offset < entities[j].offset })
+	return entities
+}
+
+// prepareForExtract 还原常见的规避写法：零宽字符、全角和形近字、连续中文数字、点号变体和逐字空格。
+func prepareForExtract(text string) string {
+	text = extractNormalizer.Apply(text)
+	text = replaceChineseDigits(text)
+	text = spacedPattern.ReplaceAllStringFunc(text, func(s string) string {
+		return strings.ReplaceAll(s, " ", "")
+	})
+	return replaceUntilStable(text, dotPattern, "$1.$2")
+}
+
+// replaceUntilStable 反复替换直到文本不再变化，用于处理相邻匹配共用字符的情况（如 a.b.c）。
+func replaceUntilStable(text string, pattern *regexp.Regexp, repl string) string {
+	for i := 0; i < 10; i++ {
+		next := pattern.ReplaceAllString(text, repl)
+		if next == text {
+			break
+		}
+		text = next
+	}
+	return text
+}
+
+func replaceChineseDigits(text string) string {
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		if _, ok := chineseDigits[runes[start]]; !ok {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) {
+			if _, ok := chineseDigits[runes[end]]; !ok {
+				break
+			}
+			end++
+		}
+		if end-start >= minChineseDigitRun {
+			for i := start; i < end; i++ {
+				runes[i] = chineseDigits[runes[i]]
+			}
+		}
+		start = end
+	}
+	return string(runes)
+}
+
+func urlEntity(host, path string, offset, end int) Entity {
+	host = strings.Trim(strings.ToLower(host), ".")
+	trimmed := strings.TrimRight(path, "/.?#&")
+	return Entity{Kind: EntityURL, Value: host + trimmed, Domain: host, offset: offset, end: end - (len(path) - len(trimmed))}
+}
+
+func submatch(text string, m []int, idx int) string {
+	if m[idx] < 0 {
+		return ""
+	}
+	return text[m[idx]:m[idx+1]]
+}
+
+func insideSpans(offset int, spans [][]int) bool {
+	for _, span := range spans {
+		if offset >= span[0] && offset < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigitAt(text string, idx int) bool {
+	return idx >= 0 && idx < len(text) && text[idx] >= '0' && text[idx] <= '9'
+}
+
+func digitsOnly(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
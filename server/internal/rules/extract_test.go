@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestExtractFindsObfuscatedLinksAndContacts(t *testing.T) {
+	cases := map[string][]string{
+		"快来 b23.tv/AbCd12 看看":                    {"b23.tv/AbCd12"},
+		"访问 https://www.Example.com/path?a=1，谢谢": {"www.example.com/path?a=1"},
+		"w w w . e x a m p l e . c o m":          {"www.example.com"},
+		"b23点tv/xyz 和 spam (dot) cn":             {"b23.tv/xyz", "spam.cn"},
+		"加qq：123 456 789":                        {"qq:123456789"},
+		"扣扣一二三四五六七八九":                            {"qq:123456789"},
+		"邮箱 12345678@qq.com":                     {"qq:12345678"},
+		"v信: Abc_12345":                          {"wechat:abc_12345"},
+		"加ⓥ：abcdef1":                             {"wechat:abcdef1"},
+		"看了 3 遍，电话 138-0013-8000 或 13900139000":  {"phone:13800138000", "phone:13900139000"},
+	}
+	for text, want := range cases {
+		got := Extract(text)
+		if len(got) != len(want) {
+			t.Fatalf("Extract(%q) = %+v, want %q", text, got, want)
+		}
+		for i := range want {
+			if got[i].String() != want[i] {
+				t.Fatalf("Extract(%q)[%d] = %q, want %q", text, i, got[i].String(), want[i])
+			}
+		}
+	}
+	for _, text := range []string{"价格是1.5元", "版本v1.2.3", "今天一二三四", "编号 123456789012", "a.b"} {
+		if got := Extract(text); len(got) != 0 {
+			t.Fatalf("Extract(%q) = %+v, want none", text, got)
+		}
+	}
+}
+
+func TestBlocklistRule(t *testing.T) {
+	compiled, err := Compile(models.KeywordRule{
+		Name:       "引流",
+		Pattern:    "b23.tv，https://spam.cn/x\nqq:*; phone:13800138000",
+		MatchType:  MatchTypeBlocklist,
+		MatchLogic: MatchLogicAny,
+		Normalize:  "emoji",
+		Enabled:    true,
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if compiled.MatchLogic != MatchLogicSingle {
+		t.Fatalf("blocklist logic = %s", compiled.MatchLogic)
+	}
+	cases := map[string]string{
+		"戳 b23点tv/abc":           "b23.tv/abc",
+		"去 www.spam.cn 领":        "www.spam.cn",
+		"群号 98765432":            "qq:98765432",
+		"联系 138 0013 8000":       "phone:13800138000",
+		"打 13900139000 或 ok.com": "",
+		"notspam.cn 不算":          "",
+	}
+	for text, want := range cases {
+		if got := compiled.Match(text); got != want {
+			t.Fatalf("Match(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	engine := NewEngine([]CompiledRule{compiled})
+	if match := engine.MatchText("推荐 b23.tv/xyz"); match == nil || match.Matched != "b23.tv/xyz" || match.MatchType != MatchTypeBlocklist {
+		t.Fatalf("engine match = %+v", match)
+	}
+
+	for _, pattern := range []string{"", "qq:abc", "foo:bar", "not a domain", "phone:123"} {
+		if err := Validate(pattern, MatchTypeBlocklist, false); err == nil {
+			t.Fatalf("Validate(%q) should fail", pattern)
+		}
+	}
+	if err := Validate("*", MatchTypeBlocklist, false); err != nil {
+		t.Fatalf("wildcard blocklist rejected: %v", err)
+	}
+}
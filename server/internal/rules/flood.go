@@ -0,0 +1,222 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MatchTypeFlood 按评论者在所有监控目标下的近期活动判断刷屏，匹配内容形如 comments >= 5 within 10m。
+const MatchTypeFlood = "flood"
+
+// 刷屏规则的统计口径。
+const (
+	FloodComments = "comments" // 窗口内评论条数
+	FloodRepeats  = "repeats"  // 窗口内与当前评论内容相同（归一化后）的评论条数
+	FloodVideos   = "videos"   // 窗口内评论过的不同视频数
+)
+
+// 刷屏规则的取值范围。
+const (
+	MinFloodThreshold = 2
+	MaxFloodThreshold = 1000
+	MinFloodWindow    = time.Minute
+	MaxFloodWindow    = 24 * time.Hour
+)
+
+var floodPattern = regexp.MustCompile(`(?i)^(comments|repeats|videos)\s*>=\s*(\d+)\s+within\s+(\S+)$`)
+
+var floodMetricLabels = map[string]string{
+	FloodComments: "条评论",
+	FloodRepeats:  "条相同评论",
+	FloodVideos:   "个视频下评论",
+}
+
+// FloodSpec 是解析后的刷屏条件。
+type FloodSpec struct {
+	Metric    string
+	Threshold int
+	Window    time.Duration
+}
+
+func (s FloodSpec) String() string {
+	return fmt.Sprintf("%s >= %d within %s", s.Metric, s.Threshold, formatFloodWindow(s.Window))
+}
+
+// ParseFloodSpec 解析刷屏规则的匹配内容，窗口支持 s/m/h/d 单位，如 90s、10m、2h、1d。
+func ParseFloodSpec(pattern string) (FloodSpec, error) {
+	m := floodPattern.FindStringSubmatch(strings.TrimSpace(pattern))
+	if m == nil {
+		return FloodSpec{}, fmt.Errorf("刷屏规则格式应为 comments|repeats|videos >= 次数 within 时长，如 comments >= 5 within 10m")
+	}
+	threshold, err := strconv.Atoi(m[2])
+	if err != nil || threshold < MinFloodThreshold || threshold > MaxFloodThreshold {
+		return FloodSpec{}, fmt.Errorf("刷屏规则次数需在 %d-%d 之间", MinFloodThreshold, MaxFloodThreshold)
+	}
+	window, err := parseFloodWindow(m[3])
+	if err != nil {
+		return FloodSpec{}, err
+	}
+	return FloodSpec{Metric: strings.ToLower(m[1]), Threshold: threshold, Window: window}, nil
+}
+
+func parseFloodWindow(raw string) (time.Duration, error) {
+	raw = strings.ToLower(raw)
+	var window time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(raw)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("刷屏规则时长无效: %s", raw)
+	}
+	if window < MinFloodWindow || window > MaxFloodWindow {
+		return 0, fmt.Errorf("刷屏规则时长需在 1m-24h 之间")
+	}
+	return window, nil
+}
+
+func formatFloodWindow(window time.Duration) string {
+	switch {
+	case window%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", window/time.Second)
+	}
+}
+
+func describeFloodWindow(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%d 小时", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%d 分钟", window/time.Minute)
+	default:
+		return fmt.Sprintf("%d 秒", window/time.Second)
+	}
+}
+
+// ActivityComment 是评论者的一条近期评论，也是刷屏命中的证据。
+type ActivityComment struct {
+	RPID      int64     `json:"rpid"`
+	AID       int64     `json:"aid"`
+	BVID      string    `json:"bvid"`
+	TargetUID int64     `json:"target_uid,omitempty"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// Evidence 是一条刷屏命中的证据，写入举报记录。
+type Evidence struct {
+	RuleID   uint              `json:"rule_id"`
+	RuleName string            `json:"rule_name"`
+	Comments []ActivityComment `json:"comments"`
+}
+
+// FormatEvidence 把全部刷屏命中的证据编码为 JSON，没有证据时返回空字符串。
+func FormatEvidence(matches []MatchResult) string {
+	var items []Evidence
+	for _, match := range matches {
+		if len(match.Evidence) > 0 {
+			items = append(items, Evidence{RuleID: match.RuleID, RuleName: match.RuleName, Comments: match.Evidence})
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+var canonicalNormalizer = NewNormalizer(normalizeOrder)
+
+// Canonicalize 返回用于比较评论是否相同的规范化文本：执行全部归一化步骤、转小写，只保留文字和数字。
+func Canonicalize(text string) string {
+	text = strings.ToLower(canonicalNormalizer.Apply(text))
+	var b strings.Builder
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// matchFlood 在评论者的近期评论中寻找包含当前评论、长度为窗口的时间段，
+// 取统计值最大的一段，达到阈值时返回命中描述和该时间段内的相关评论。
+func (r CompiledRule) matchFlood(current ActivityComment, history []ActivityComment) (string, []ActivityComment) {
+	spec := r.flood
+	related := history
+	if spec.Metric == FloodRepeats {
+		key := repeatKey(current.Message)
+		related = make([]ActivityComment, 0, len(history))
+		for _, item := range history {
+			if repeatKey(item.Message) == key {
+				related = append(related, item)
+			}
+		}
+	}
+	related = append([]ActivityComment(nil), related...)
+	sort.Slice(related, func(i, j int) bool { return related[i].At.Before(related[j].At) })
+
+	var best []ActivityComment
+	bestValue := 0
+	for i, start := range related {
+		if start.At.After(current.At) {
+			break
+		}
+		end := start.At.Add(spec.Window)
+		if end.Before(current.At) {
+			continue
+		}
+		var window []ActivityComment
+		for _, item := range related[i:] {
+			if item.At.After(end) {
+				break
+			}
+			window = append(window, item)
+		}
+		if value := floodValue(spec.Metric, window); value > bestValue {
+			best, bestValue = window, value
+		}
+	}
+	if bestValue < spec.Threshold {
+		return "", nil
+	}
+	return fmt.Sprintf("%s内 %d %s", describeFloodWindow(spec.Window), bestValue, floodMetricLabels[spec.Metric]), best
+}
+
+func floodValue(metric string, window []ActivityComment) int {
+	if metric != FloodVideos {
+		return len(window)
+	}
+	videos := map[int64]bool{}
+	for _, item := range window {
+		videos[item.AID] = true
+	}
+	return len(videos)
+}
+
+// repeatKey 按规范化文本比较评论，只含表情或符号的评论按原文比较。
+func repeatKey(message string) string {
+	if key := Canonicalize(message); key != "" {
+		return key
+	}
+	return strings.TrimSpace(message)
+}
@@ -0,0 +1,99 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestParseFloodSpec(t *testing.T) {
+	spec, err := ParseFloodSpec("  Comments >= 5 WITHIN 90m ")
+	if err != nil {
+		t.Fatalf("ParseFloodSpec error: %v", err)
+	}
+	if spec.Metric != FloodComments || spec.Threshold != 5 || spec.Window != 90*time.Minute {
+		t.Fatalf("spec = %+v", spec)
+	}
+	if spec.String() != "comments >= 5 within 90m" {
+		t.Fatalf("String = %q", spec.String())
+	}
+	if spec, err := ParseFloodSpec("videos >= 3 within 1d"); err != nil || spec.Window != 24*time.Hour {
+		t.Fatalf("day window = %+v, %v", spec, err)
+	}
+	for _, pattern := range []string{"comments > 5 within 10m", "likes >= 5 within 10m", "comments >= 1 within 10m", "comments >= 5 within 30s", "comments >= 5 within 2d"} {
+		if _, err := ParseFloodSpec(pattern); err == nil {
+			t.Fatalf("%q should be rejected", pattern)
+		}
+	}
+}
+
+func TestEngineMatchFloodFor(t *testing.T) {
+	compiled, errs := CompileMany([]models.KeywordRule{
+		{ID: 1, Name: "刷屏", Pattern: "comments >= 3 within 10m", MatchType: MatchTypeFlood, MatchLogic: MatchLogicAny, Enabled: true},
+		{ID: 2, Name: "复读", Pattern: "repeats >= 3 within 1h", MatchType: MatchTypeFlood, Weight: 2, Enabled: true},
+		{ID: 3, Name: "串视频", Pattern: "videos >= 3 within 30m", MatchType: MatchTypeFlood, Enabled: true},
+		{ID: 4, Name: "引流", Pattern: "加群", MatchType: MatchTypePlain, Enabled: true},
+	}, "")
+	if len(errs) > 0 {
+		t.Fatalf("CompileMany errors: %v", errs)
+	}
+	engine := NewEngine(compiled)
+	if !engine.HasFlood() || engine.FloodWindow() != time.Hour {
+		t.Fatalf("HasFlood/FloodWindow = %v/%s", engine.HasFlood(), engine.FloodWindow())
+	}
+	if compiled[0].MatchLogic != MatchLogicSingle {
+		t.Fatalf("flood rule logic = %s", compiled[0].MatchLogic)
+	}
+	if matches := engine.MatchAll("comments >= 3 within 10m"); len(matches) != 0 {
+		t.Fatalf("flood rule should not match text: %+v", matches)
+	}
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := []ActivityComment{
+		{RPID: 1, AID: 10, Message: "加群领红包！", At: base},
+		{RPID: 2, AID: 11, Message: "加 群 领 红 包", At: base.Add(4 * time.Minute)},
+		{RPID: 3, AID: 11, Message: "路过", At: base.Add(8 * time.Minute)},
+		{RPID: 4, AID: 12, Message: "加群领红包", At: base.Add(40 * time.Minute)},
+	}
+
+	matches := engine.MatchFloodFor(history[2], history, nil)
+	if len(matches) != 1 || matches[0].RuleID != 1 || len(matches[0].Evidence) != 3 {
+		t.Fatalf("comments match = %+v", matches)
+	}
+	if matches[0].Matched != "10 分钟内 3 条评论" || matches[0].Score != DefaultWeight {
+		t.Fatalf("comments result = %+v", matches[0])
+	}
+
+	matches = engine.MatchFloodFor(history[3], history, nil)
+	if len(matches) != 1 || matches[0].RuleID != 2 || matches[0].Score != 2 {
+		t.Fatalf("repeats match = %+v", matches)
+	}
+	for _, item := range matches[0].Evidence {
+		if item.RPID == 3 {
+			t.Fatalf("repeats evidence should only contain the same text: %+v", matches[0].Evidence)
+		}
+	}
+
+	history[2].AID = 13
+	matches = engine.MatchFloodFor(history[2], history, nil)
+	if len(matches) != 2 || matches[1].RuleID != 3 || matches[1].Matched != "30 分钟内 3 个视频下评论" {
+		t.Fatalf("videos match = %+v", matches)
+	}
+
+	evidence := FormatEvidence(matches)
+	if evidence == "" || FormatEvidence(engine.MatchAll("加群")) != "" {
+		t.Fatalf("FormatEvidence = %q", evidence)
+	}
+}
+
+func TestTestRuleRejectsFloodMustMatch(t *testing.T) {
+	row := models.KeywordRule{Pattern: "comments >= 3 within 10m", MatchType: MatchTypeFlood, MustNotMatch: "正常评论"}
+	if report, err := TestRule(row); err != nil || !report.Passed() {
+		t.Fatalf("TestRule = %+v, %v", report, err)
+	}
+	row.MustMatch = "刷屏"
+	if _, err := TestRule(row); err == nil {
+		t.Fatalf("flood rule with must-match examples should be rejected")
+	}
+}
@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// 模糊间隔模式下可被跳过的干扰字符类别。
+const (
+	GapNoiseSpace     = "space"
+	GapNoisePunct     = "punct"
+	GapNoiseEmoji     = "emoji"
+	GapNoiseInvisible = "invisible"
+
+	// MaxGapLimit 是相邻两个字之间允许跳过的干扰字符数上限。
+	MaxGapLimit = 10
+
+	// NormalizationGap 表示命中时跳过了条件字符之间的干扰字符。
+	NormalizationGap = "gap"
+)
+
+var gapNoiseOrder = []string{GapNoiseSpace, GapNoisePunct, GapNoiseEmoji, GapNoiseInvisible}
+
+// ParseGapNoise 解析逗号分隔的干扰字符类别，留空表示全部类别。
+func ParseGapNoise(raw string) ([]string, error) {
+	requested := map[string]bool{}
+	for _, field := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '，' || unicode.IsSpace(r)
+	}) {
+		class := strings.ToLower(strings.TrimSpace(field))
+		if !containsString(gapNoiseOrder, class) {
+			return nil, fmt.Errorf("未知的干扰字符类别: %s", field)
+		}
+		requested[class] = true
+	}
+	if len(requested) == 0 {
+		return append([]string(nil), gapNoiseOrder...), nil
+	}
+	classes := make([]string, 0, len(requested))
+	for _, class := range gapNoiseOrder {
+		if requested[class] {
+			classes = append(classes, class)
+		}
+	}
+	return classes, nil
+}
+
+// ValidateFuzzyGap 校验最大间隔和干扰字符类别。
+func ValidateFuzzyGap(maxGap int, noise string) error {
+	if maxGap < 0 || maxGap > MaxGapLimit {
+		return fmt.Errorf("最大间隔需在 0-%d 之间", MaxGapLimit)
+	}
+	_, err := ParseGapNoise(noise)
+	return err
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r CompiledRule) isGapNoise(c rune) bool {
+	for _, class := range r.GapNoise {
+		switch class {
+		case GapNoiseSpace:
+			if unicode.IsSpace(c) {
+				return true
+			}
+		case GapNoisePunct:
+			if (unicode.IsPunct(c) || unicode.IsSymbol(c)) && !isEmoji(c) {
+				return true
+			}
+		case GapNoiseEmoji:
+			if isEmoji(c) {
+				return true
+			}
+		case GapNoiseInvisible:
+			if isInvisible(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r CompiledRule) foldRune(c rune) rune {
+	folded := []rune(width.Fold.String(string(c)))
+	if len(folded) == 1 {
+		c = folded[0]
+	}
+	if !r.CaseSensitive {
+		c = unicode.ToLower(c)
+	}
+	return c
+}
+
+// findWithGaps 在 text 中查找 term，相邻两个字之间最多跳过 MaxGap 个干扰字符。
+// 返回命中片段在 text 中的字节区间，以及是否真的跳过了字符。
+func (r CompiledRule) findWithGaps(text string, term []rune) (start, end int, skipped, ok bool) {
+	if len(term) == 0 {
+		return 0, 0, false, false
+	}
+	for i, c := range text {
+		if r.foldRune(c) != term[0] {
+			continue
+		}
+		if end, skipped, ok := r.extendWithGaps(text, i+utf8.RuneLen(c), term[1:]); ok {
+			return i, end, skipped, true
+		}
+	}
+	return 0, 0, false, false
+}
+
+// extendWithGaps 从 pos 开始依次匹配剩余字符，返回命中结束位置。
+func (r CompiledRule) extendWithGaps(text string, pos int, rest []rune) (end int, skipped, ok bool) {
+	gap := 0
+	for len(rest) > 0 {
+		if pos >= len(text) {
+			return 0, false, false
+		}
+		c, size := utf8.DecodeRuneInString(text[pos:])
+		switch {
+		case r.foldRune(c) == rest[0]:
+			rest = rest[1:]
+			gap = 0
+		case gap < r.MaxGap && r.isGapNoise(c):
+			gap++
+			skipped = true
+		default:
+			return 0, false, false
+		}
+		pos += size
+	}
+	return pos, skipped, true
+}
+
+// matchFuzzyGap 是开启最大间隔后的普通匹配，返回评论中的原始片段作为证据。
+func (r CompiledRule) matchFuzzyGap(original, text string) (string, string) {
+	terms := r.terms
+	if len(terms) == 0 {
+		terms = ruleTerms(r.Pattern, r.MatchLogic)
+	}
+	matches := make([]string, 0, len(terms))
+	usedGap := false
+	for _, term := range terms {
+		folded := make([]rune, 0, len(term))
+		for _, c := range r.normalizer.Apply(term) {
+			folded = append(folded, r.foldRune(c))
+		}
+		start, end, skipped, ok := r.findWithGaps(text, folded)
+		if !ok {
+			if r.MatchLogic == MatchLogicAll {
+				return "", ""
+			}
+			continue
+		}
+		start, end = r.normalizer.OriginalSpan(original, start, end)
+		evidence := original[start:end]
+		if r.MatchLogic != MatchLogicAll {
+			return evidence, gapNormalization(skipped)
+		}
+		matches = append(matches, evidence)
+		usedGap = usedGap || skipped
+	}
+	if len(matches) == 0 {
+		return "", ""
+	}
+	return strings.Join(matches, ", "), gapNormalization(usedGap)
+}
+
+func gapNormalization(skipped bool) string {
+	if skipped {
+		return NormalizationGap
+	}
+	return ""
+}
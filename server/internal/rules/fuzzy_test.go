@@ -0,0 +1,99 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func compileGapRule(t *testing.T, rule models.KeywordRule) CompiledRule {
+	t.Helper()
+	if rule.Name == "" {
+		rule.Name = rule.Pattern
+	}
+	if rule.MatchType == "" {
+		rule.MatchType = MatchTypePlain
+	}
+	rule.Enabled = true
+	compiled, err := Compile(rule)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	return compiled
+}
+
+func TestFuzzyGapIgnoresNoiseAndReturnsOriginalEvidence(t *testing.T) {
+	rule := compileGapRule(t, models.KeywordRule{Pattern: "傻逼", MaxGap: 3})
+	cases := []struct {
+		text    string
+		matched string
+		gap     bool
+	}{
+		{"你个傻.逼", "傻.逼", true},
+		{"你个傻 逼啊", "傻 逼", true},
+		{"你个傻😀逼", "傻😀逼", true},
+		{"你个傻\u200B逼", "傻\u200B逼", true},
+		{"傻！？…逼", "傻！？…逼", true},
+		{"就是傻逼", "傻逼", false},
+	}
+	for _, tc := range cases {
+		got := MatchText(tc.text, []CompiledRule{rule})
+		if got == nil {
+			t.Fatalf("expected %q to match", tc.text)
+		}
+		if got.Matched != tc.matched {
+			t.Fatalf("Matched for %q = %q, want %q", tc.text, got.Matched, tc.matched)
+		}
+		if (got.Normalization == NormalizationGap) != tc.gap {
+			t.Fatalf("unexpected normalization %q for %q", got.Normalization, tc.text)
+		}
+	}
+
+	for _, text := range []string{"傻....逼", "傻x逼", "傻", "逼傻"} {
+		if got := rule.Match(text); got != "" {
+			t.Fatalf("expected %q not to match, got %q", text, got)
+		}
+	}
+}
+
+func TestFuzzyGapNoiseClassesAreConfigurable(t *testing.T) {
+	rule := compileGapRule(t, models.KeywordRule{Pattern: "加微信", MaxGap: 2, GapNoise: "space"})
+	if got := rule.Match("加 微  信"); got != "加 微  信" {
+		t.Fatalf("expected spaces to be skipped, got %q", got)
+	}
+	if got := rule.Match("加.微.信"); got != "" {
+		t.Fatalf("punctuation should not be skipped when only space is configured, got %q", got)
+	}
+	if err := ValidateFuzzyGap(MaxGapLimit+1, ""); err == nil {
+		t.Fatal("expected max gap above limit to fail")
+	}
+	if err := ValidateFuzzyGap(2, "space,digits"); err == nil {
+		t.Fatal("expected unknown noise class to fail")
+	}
+}
+
+func TestFuzzyGapEvidenceSurvivesNormalization(t *testing.T) {
+	rule := compileGapRule(t, models.KeywordRule{
+		Pattern:    "VX,领取",
+		MatchLogic: MatchLogicAll,
+		MaxGap:     2,
+		Normalize:  "nfkc,t2s,emoji",
+	})
+	got := MatchText("🎁加ⓥ-ⓧ免費領😀取", []CompiledRule{rule})
+	if got == nil {
+		t.Fatal("expected match")
+	}
+	if got.Matched != "ⓥ-ⓧ, 領😀取" {
+		t.Fatalf("expected original substrings as evidence, got %q", got.Matched)
+	}
+}
+
+func TestFuzzyGapOnlyAppliesToPlainRules(t *testing.T) {
+	rule := compileGapRule(t, models.KeywordRule{Pattern: "傻逼", MatchType: MatchTypeRegex, MaxGap: 3})
+	if rule.MaxGap != 0 {
+		t.Fatalf("regex rules should ignore max gap, got %d", rule.MaxGap)
+	}
+	if got := rule.Match("傻.逼"); got != "" {
+		t.Fatalf("regex rule should not skip noise, got %q", got)
+	}
+}
@@ -14,6 +14,10 @@ import (
 const (
 	MatchTypePlain = "plain"
 	MatchTypeRegex = "regex"
+	// MatchTypePinyin 把文本和条件都转成拼音（全拼和首字母）后匹配，用于识别谐音规避。
+	MatchTypePinyin = "pinyin"
+	// MatchTypeExpression 把匹配内容当作布尔表达式，支持 AND/OR/NOT、括号、引号短语、re:/.../ 和 NEAR/n。
+	MatchTypeExpression = "expression"
 
 	MatchLogicSingle = "single"
 	MatchLogicAny    = "or"
@@ -21,29 +25,73 @@ const (
 )
 
 type CompiledRule struct {
-	ID            uint   `json:"id"`
-	Name          string `json:"name"`
-	Pattern       string `json:"pattern"`
-	MatchType     string `json:"match_type"`
-	MatchLogic    string `json:"match_logic"`
-	CaseSensitive bool   `json:"case_sensitive"`
+	ID            uint     `json:"id"`
+	Name          string   `json:"name"`
+	Pattern       string   `json:"pattern"`
+	MatchType     string   `json:"match_type"`
+	MatchLogic    string   `json:"match_logic"`
+	CaseSensitive bool     `json:"case_sensitive"`
+	Homophone     bool     `json:"homophone"`
+	Normalize     []string `json:"normalize,omitempty"`
+	MaxGap        int      `json:"max_gap,omitempty"`
+	GapNoise      []string `json:"gap_noise,omitempty"`
+	Weight        float64  `json:"weight"`
+	Conditions    string   `json:"conditions,omitempty"`
+	Revision      int      `json:"revision,omitempty"`
+	normalizer    Normalizer
 	terms         []string
+	termWeights   []float64
 	regexes       []*regexp.Regexp
+	pinyinTerms   []pinyinTerm
+	expression    exprNode
+	conditions    []Condition
+	flood         FloodSpec
+	blocklist     []blocklistEntry
 }
 
 type MatchResult struct {
-	RuleID     uint   `json:"rule_id"`
-	RuleName   string `json:"rule_name"`
+	RuleID   uint   `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	// Revision 是命中时规则的修订号，临时关键字为 0。
+	Revision   int    `json:"revision,omitempty"`
 	Pattern    string `json:"pattern"`
 	MatchType  string `json:"match_type"`
 	MatchLogic string `json:"match_logic"`
 	Matched    string `json:"matched"`
+	// Normalization 说明命中经由哪种归一化：拼音规则为 original/pinyin/initials/homophone，
+	// 模糊间隔跳过了干扰字符时为 gap，其余情况为空。
+	Normalization string `json:"normalization,omitempty"`
+	// Score 是规则权重 × 命中条件权重之和，任务按全部命中规则的得分合计判断是否举报。
+	Score float64 `json:"score"`
+	// ClusterID 是近似重复检测产生的合成命中所属的评论簇，关键字规则命中为 0。
+	ClusterID uint `json:"cluster_id,omitempty"`
+	// Evidence 是刷屏规则命中时窗口内评论者的相关评论，文本规则命中为空。
+	Evidence []ActivityComment `json:"evidence,omitempty"`
+	// Clause 是实际成立的条件：单条和任一逻辑为第一个命中的条件，全部逻辑为用 AND 连接的各条件，
+	// 表达式为成立的分支，名单规则为命中的名单项，刷屏规则为规范化后的匹配内容。
+	Clause string `json:"clause,omitempty"`
+	// Steps 是比较前对评论执行的处理步骤，按执行顺序排列，取值为归一化步骤或 Step* 常量。
+	Steps []string `json:"steps,omitempty"`
+	// Spans 是命中片段在评论原文中的位置，刷屏和合成规则为空。
+	Spans []Span `json:"spans,omitempty"`
 }
 
 func Validate(pattern, matchType string, caseSensitive bool, matchLogicValue ...string) error {
 	if strings.TrimSpace(pattern) == "" {
 		return fmt.Errorf("匹配内容不能为空")
 	}
+	if normalizeMatchType(matchType) == MatchTypeExpression {
+		_, err := parseExpression(pattern, caseSensitive, Normalizer{})
+		return err
+	}
+	if normalizeMatchType(matchType) == MatchTypeFlood {
+		_, err := ParseFloodSpec(pattern)
+		return err
+	}
+	if normalizeMatchType(matchType) == MatchTypeBlocklist {
+		_, err := parseBlocklist(pattern)
+		return err
+	}
 	logic := MatchLogicSingle
 	if len(matchLogicValue) > 0 {
 		logic = normalizeMatchLogic(matchLogicValue[0])
@@ -52,7 +100,20 @@ func Validate(pattern, matchType string, caseSensitive bool, matchLogicValue ...
 	if len(terms) == 0 {
 		return fmt.Errorf("匹配内容不能为空")
 	}
-	if normalizeMatchType(matchType) == MatchTypeRegex {
+	if supportsTermWeights(normalizeMatchType(matchType), logic) {
+		var err error
+		if terms, _, err = weightedTerms(terms); err != nil {
+			return err
+		}
+	}
+	switch normalizeMatchType(matchType) {
+	case MatchTypePinyin:
+		for _, term := range terms {
+			if len(pinyinUnits(term)) == 0 {
+				return fmt.Errorf("拼音匹配内容不能只包含空白或分隔符")
+			}
+		}
+	case MatchTypeRegex:
 		for _, term := range terms {
 			_, err := regexp.Compile(regexPattern(term, caseSensitive))
 			if err != nil {
@@ -74,14 +135,90 @@ func Compile(rule models.KeywordRule) (CompiledRule, error) {
 		MatchType:     normalizeMatchType(rule.MatchType),
 		MatchLogic:    normalizeMatchLogic(rule.MatchLogic),
 		CaseSensitive: rule.CaseSensitive,
+		Homophone:     rule.Homophone,
+		Weight:        rule.Weight,
+		Revision:      rule.Revision,
+	}
+	if compiled.Weight <= 0 {
+		compiled.Weight = DefaultWeight
+	}
+	if _, err := ParseSchedule(rule); err != nil {
+		return CompiledRule{}, err
+	}
+	conditions, err := ParseConditions(rule.Conditions)
+	if err != nil {
+		return CompiledRule{}, err
+	}
+	if len(conditions) > 0 {
+		compiled.conditions = conditions
+		compiled.Conditions = FormatConditions(conditions)
+	}
+	if compiled.MatchType == MatchTypePinyin {
+		// 拼音统一按小写比较，大小写敏感对拼音规则没有意义。
+		compiled.CaseSensitive = false
+	} else {
+		compiled.Homophone = false
+	}
+	if compiled.MatchType == MatchTypeExpression || compiled.MatchType == MatchTypeFlood || compiled.MatchType == MatchTypeBlocklist {
+		// 表达式自带运算符、刷屏规则不按文本匹配、名单本身就是任一匹配，都不再按逗号拆分条件。
+		compiled.MatchLogic = MatchLogicSingle
 	}
 	if compiled.Name == "" {
 		compiled.Name = compiled.Pattern
 	}
+	steps, err := ParseNormalizeSteps(rule.Normalize)
+	if err != nil {
+		return CompiledRule{}, err
+	}
+	if len(steps) > 0 {
+		compiled.Normalize = steps
+		compiled.normalizer = NewNormalizer(steps)
+	}
+	if compiled.MatchType == MatchTypePlain && rule.MaxGap > 0 {
+		if err := ValidateFuzzyGap(rule.MaxGap, rule.GapNoise); err != nil {
+			return CompiledRule{}, err
+		}
+		compiled.MaxGap = rule.MaxGap
+		compiled.GapNoise, _ = ParseGapNoise(rule.GapNoise)
+	}
 	if err := Validate(compiled.Pattern, compiled.MatchType, compiled.CaseSensitive, compiled.MatchLogic); err != nil {
 		return CompiledRule{}, err
 	}
+	if compiled.MatchType == MatchTypeExpression {
+		compiled.expression, err = parseExpression(compiled.Pattern, compiled.CaseSensitive, compiled.normalizer)
+		if err != nil {
+			return CompiledRule{}, err
+		}
+		return compiled, nil
+	}
+	if compiled.MatchType == MatchTypeFlood {
+		compiled.flood, err = ParseFloodSpec(compiled.Pattern)
+		if err != nil {
+			return CompiledRule{}, err
+		}
+		compiled.Pattern = compiled.flood.String()
+		return compiled, nil
+	}
+	if compiled.MatchType == MatchTypeBlocklist {
+		compiled.blocklist, err = parseBlocklist(compiled.Pattern)
+		if err != nil {
+			return CompiledRule{}, err
+		}
+		return compiled, nil
+	}
 	compiled.terms = ruleTerms(compiled.Pattern, compiled.MatchLogic)
+	if supportsTermWeights(compiled.MatchType, compiled.MatchLogic) {
+		compiled.terms, compiled.termWeights, err = weightedTerms(compiled.terms)
+		if err != nil {
+			return CompiledRule{}, err
+		}
+	}
+	if compiled.MatchType == MatchTypePinyin {
+		compiled.pinyinTerms = make([]pinyinTerm, 0, len(compiled.terms))
+		for _, term := range compiled.terms {
+			compiled.pinyinTerms = append(compiled.pinyinTerms, compilePinyinTerm(compiled.normalizer.Apply(term)))
+		}
+	}
 	if compiled.MatchType == MatchTypeRegex {
 		compiled.regexes = make([]*regexp.Regexp, 0, len(compiled.terms))
 		for _, term := range compiled.terms {
@@ -95,7 +232,13 @@ func Compile(rule models.KeywordRule) (CompiledRule, error) {
 	return compiled, nil
 }
 
+// CompileMany 编译启用且当前处于生效时间内的规则和临时关键字。
 func CompileMany(rows []models.KeywordRule, adHocKeywords string) ([]CompiledRule, []error) {
+	return CompileManyAt(rows, adHocKeywords, time.Now())
+}
+
+// CompileManyAt 同 CompileMany，按 now 判断规则的生效时间。
+func CompileManyAt(rows []models.KeywordRule, adHocKeywords string, now time.Time) ([]CompiledRule, []error) {
 	compiled := make([]CompiledRule, 0, len(rows))
 	var errs []error
 
@@ -103,6 +246,14 @@ func CompileMany(rows []models.KeywordRule, adHocKeywords string) ([]CompiledRul
 		if !row.Enabled {
 			continue
 		}
+		schedule, err := ParseSchedule(row)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", row.Name, err))
+			continue
+		}
+		if !schedule.ActiveAt(now) {
+			continue
+		}
 		rule, err := Compile(row)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("%s: %w", row.Name, err))
@@ -117,6 +268,7 @@ func CompileMany(rows []models.KeywordRule, adHocKeywords string) ([]CompiledRul
 			Pattern:    keyword,
 			MatchType:  MatchTypePlain,
 			MatchLogic: MatchLogicSingle,
+			Weight:     DefaultWeight,
 			terms:      []string{keyword},
 		})
 	}
@@ -124,45 +276,90 @@ func CompileMany(rows []models.KeywordRule, adHocKeywords string) ([]CompiledRul
 	return compiled, errs
 }
 
+// MatchText 按顺序逐条匹配并返回第一条命中的规则，适合单次调用；
+// 同一组规则要匹配大量评论时应先用 NewEngine 构建引擎。
 func MatchText(text string, compiled []CompiledRule) *MatchResult {
+	return MatchTextFor(text, nil, compiled)
+}
+
+// MatchTextFor 同 MatchText，并检查规则对评论者的附加条件。
+func MatchTextFor(text string, commenter *Commenter, compiled []CompiledRule) *MatchResult {
 	for _, rule := range compiled {
-		if matched := rule.Match(text); matched != "" {
-			return &MatchResult{
-				RuleID:     rule.ID,
-				RuleName:   rule.Name,
-				Pattern:    rule.Pattern,
-				MatchType:  rule.MatchType,
-				MatchLogic: rule.MatchLogic,
-				Matched:    matched,
-			}
+		if !rule.conditionsHold(commenter) {
+			continue
+		}
+		if matched, normalization := rule.match(text); matched != "" {
+			result := rule.result(text, matched, normalization, rule.score(text))
+			return &result
 		}
 	}
 	return nil
 }
 
+// MatchAll 逐条匹配并返回全部命中的规则，批量场景同样建议使用 Engine。
 func MatchAll(text string, compiled []CompiledRule) []MatchResult {
+	return MatchAllFor(text, nil, compiled)
+}
+
+// MatchAllFor 同 MatchAll，并检查规则对评论者的附加条件。
+func MatchAllFor(text string, commenter *Commenter, compiled []CompiledRule) []MatchResult {
 	matches := make([]MatchResult, 0)
 	for _, rule := range compiled {
-		if matched := rule.Match(text); matched != "" {
-			matches = append(matches, MatchResult{
-				RuleID:     rule.ID,
-				RuleName:   rule.Name,
-				Pattern:    rule.Pattern,
-				MatchType:  rule.MatchType,
-				MatchLogic: rule.MatchLogic,
-				Matched:    matched,
-			})
+		if !rule.conditionsHold(commenter) {
+			continue
+		}
+		if matched, normalization := rule.match(text); matched != "" {
+			matches = append(matches, rule.result(text, matched, normalization, rule.score(text)))
 		}
 	}
 	return matches
 }
 
 func (r CompiledRule) Match(text string) string {
-	if r.MatchType == MatchTypeRegex {
-		return r.matchRegex(text)
+	matched, _ := r.match(text)
+	return matched
+}
+
+func (r CompiledRule) match(text string) (string, string) {
+	// 规则配置的归一化步骤先作用于评论文本，普通和拼音条件在比较时做同样处理。
+	original := text
+	text = r.normalizer.Apply(text)
+	switch r.MatchType {
+	case MatchTypeRegex:
+		return r.matchRegex(text), ""
+	case MatchTypePinyin:
+		return r.matchPinyin(text)
+	case MatchTypeExpression:
+		return r.matchExpression(text), ""
+	case MatchTypeFlood:
+		// 刷屏规则只看评论者的近期活动，由 Engine.MatchFloodFor 判断。
+		return "", ""
+	case MatchTypeBlocklist:
+		// 提取器自带去零宽、全角和形近字处理，直接使用原文以免归一化步骤删掉链接中的字符。
+		return r.matchBlocklist(original), ""
+	default:
+		if r.MaxGap > 0 {
+			return r.matchFuzzyGap(original, text)
+		}
+		return r.matchPlain(text), ""
 	}
+}
 
-	return r.matchPlain(text)
+// result 构造命中结果，并按评论原文 text 补充命中说明。
+func (r CompiledRule) result(text, matched, normalization string, score float64) MatchResult {
+	result := MatchResult{
+		RuleID:        r.ID,
+		RuleName:      r.Name,
+		Revision:      r.Revision,
+		Pattern:       r.Pattern,
+		MatchType:     r.MatchType,
+		MatchLogic:    r.MatchLogic,
+		Matched:       matched,
+		Normalization: normalization,
+		Score:         score,
+	}
+	r.explain(text, &result)
+	return result
 }
 
 func (r CompiledRule) matchRegex(text string) string {
@@ -208,7 +405,7 @@ func (r CompiledRule) matchPlain(text string) string {
 	}
 	matches := make([]string, 0, len(terms))
 	for _, term := range terms {
-		pattern := normalize(term)
+		pattern := normalize(r.normalizer.Apply(term))
 		matched := pattern != "" && strings.Contains(source, pattern)
 		if !matched && r.MatchLogic == MatchLogicAll {
 			return ""
@@ -281,10 +478,20 @@ func MarkMatchedAt(rule *models.KeywordRule, now time.Time) {
 }
 
 func normalizeMatchType(matchType string) string {
-	if strings.EqualFold(matchType, MatchTypeRegex) {
+	switch strings.ToLower(strings.TrimSpace(matchType)) {
+	case MatchTypeRegex:
 		return MatchTypeRegex
+	case MatchTypePinyin:
+		return MatchTypePinyin
+	case MatchTypeExpression:
+		return MatchTypeExpression
+	case MatchTypeFlood:
+		return MatchTypeFlood
+	case MatchTypeBlocklist:
+		return MatchTypeBlocklist
+	default:
+		return MatchTypePlain
 	}
-	return MatchTypePlain
 }
 
 func normalizeMatchLogic(matchLogic string) string {
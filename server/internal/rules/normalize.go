@@ -0,0 +1,245 @@
+package rules
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 匹配前可选的文本归一化步骤，按 normalizeOrder 的固定顺序执行。
+const (
+	NormalizeInvisible   = "invisible"
+	NormalizeNFKC        = "nfkc"
+	NormalizeConfusables = "confusables"
+	NormalizeT2S         = "t2s"
+	NormalizeEmoji       = "emoji"
+)
+
+var normalizeOrder = []string{
+	NormalizeInvisible,
+	NormalizeNFKC,
+	NormalizeConfusables,
+	NormalizeT2S,
+	NormalizeEmoji,
+}
+
+//go:embed t2s.txt
+var t2sData string
+
+//go:embed confusables.txt
+var confusablesData string
+
+var (
+	t2sOnce  sync.Once
+	t2sTable map[rune]rune
+
+	confusablesOnce     sync.Once
+	confusableRunes     map[rune]rune
+	confusableSplitting *strings.Replacer
+)
+
+// Normalizer 按规则配置的步骤归一化文本，零值表示不做任何处理。
+type Normalizer struct {
+	steps []string
+}
+
+// ParseNormalizeSteps 解析逗号分隔的步骤列表，去重后按执行顺序返回。
+func ParseNormalizeSteps(raw string) ([]string, error) {
+	requested := map[string]bool{}
+	for _, field := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '，' || unicode.IsSpace(r)
+	}) {
+		step := strings.ToLower(strings.TrimSpace(field))
+		if !isNormalizeStep(step) {
+			return nil, fmt.Errorf("未知的归一化步骤: %s", field)
+		}
+		requested[step] = true
+	}
+	steps := make([]string, 0, len(requested))
+	for _, step := range normalizeOrder {
+		if requested[step] {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// FormatNormalizeSteps 把步骤列表格式化为存储用的逗号分隔字符串。
+func FormatNormalizeSteps(steps []string) string {
+	return strings.Join(steps, ",")
+}
+
+func isNormalizeStep(step string) bool {
+	return containsString(normalizeOrder, step)
+}
+
+func NewNormalizer(steps []string) Normalizer {
+	return Normalizer{steps: steps}
+}
+
+func (n Normalizer) Apply(text string) string {
+	for _, step := range n.steps {
+		switch step {
+		case NormalizeInvisible:
+			text = removeInvisible(text)
+		case NormalizeNFKC:
+			text = norm.NFKC.String(text)
+		case NormalizeConfusables:
+			text = foldConfusables(text)
+		case NormalizeT2S:
+			text = traditionalToSimplified(text)
+		case NormalizeEmoji:
+			text = stripEmoji(text)
+		}
+	}
+	return text
+}
+
+// OriginalSpan 把归一化后文本中的字节区间映射回原文。各步骤基本按字符独立处理，
+// 归一化结果长度随原文前缀单调增长，因此可以对前缀长度做二分查找。
+func (n Normalizer) OriginalSpan(original string, start, end int) (int, int) {
+	if len(n.steps) == 0 {
+		return start, end
+	}
+	return originalSpan(original, start, end, n.Apply)
+}
+
+// originalSpan 是 OriginalSpan 的通用实现，apply 可以是归一化再叠加全角折叠、转小写等同样逐字处理的变换。
+func originalSpan(original string, start, end int, apply func(string) string) (int, int) {
+	boundaries := make([]int, 0, len(original)+1)
+	for i := range original {
+		boundaries = append(boundaries, i)
+	}
+	boundaries = append(boundaries, len(original))
+	prefixLen := func(idx int) int {
+		return len(apply(original[:boundaries[idx]]))
+	}
+	// 起点取归一化长度不超过 start 的最长前缀，终点取长度不小于 end 的最短前缀。
+	first := sort.Search(len(boundaries), func(idx int) bool { return prefixLen(idx) > start }) - 1
+	if first < 0 {
+		first = 0
+	}
+	last := sort.Search(len(boundaries), func(idx int) bool { return prefixLen(idx) >= end })
+	if last >= len(boundaries) {
+		last = len(boundaries) - 1
+	}
+	if boundaries[last] < boundaries[first] {
+		last = first
+	}
+	return boundaries[first], boundaries[last]
+}
+
+// removeInvisible 去掉零宽字符、格式控制符和组合附加符号（含重音、删除线等）。
+func removeInvisible(text string) string {
+	decomposed := norm.NFD.String(text)
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if isInvisible(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+func isInvisible(r rune) bool {
+	switch r {
+	case '\u115F', '\u1160', '\u3164', '\uFFA0', '\u2800':
+		// 韩文填充符和盲文空白常被当作“看不见的字”插入。
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+func foldConfusables(text string) string {
+	confusablesOnce.Do(loadConfusables)
+	text = confusableSplitting.Replace(text)
+	return strings.Map(func(r rune) rune {
+		if mapped, ok := confusableRunes[r]; ok {
+			return mapped
+		}
+		return r
+	}, text)
+}
+
+func loadConfusables() {
+	confusableRunes = map[rune]rune{}
+	var splits []string
+	for _, line := range strings.Split(confusablesData, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		from, to := []rune(fields[1]), []rune(fields[2])
+		switch fields[0] {
+		case "char":
+			if len(from) != 1 || len(to) != 1 {
+				continue
+			}
+			confusableRunes[from[0]] = to[0]
+			if upper := unicode.ToUpper(from[0]); upper != from[0] {
+				confusableRunes[upper] = unicode.ToUpper(to[0])
+			}
+		case "split":
+			splits = append(splits, fields[1], fields[2])
+		}
+	}
+	confusableSplitting = strings.NewReplacer(splits...)
+}
+
+func traditionalToSimplified(text string) string {
+	t2sOnce.Do(loadT2S)
+	return strings.Map(func(r rune) rune {
+		if mapped, ok := t2sTable[r]; ok {
+			return mapped
+		}
+		return r
+	}, text)
+}
+
+func loadT2S() {
+	t2sTable = make(map[rune]rune, 4200)
+	for _, line := range strings.Split(t2sData, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		from, to := []rune(fields[0]), []rune(fields[1])
+		if len(from) == 1 && len(to) == 1 {
+			t2sTable[from[0]] = to[0]
+		}
+	}
+}
+
+func stripEmoji(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 表情、符号、旗帜、肤色修饰
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号和装饰符号
+		return true
+	case r >= 0x2300 && r <= 0x23FF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F: // 变体选择符
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // 标签字符
+		return true
+	case r == 0x200D || r == 0x20E3:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestNormalizerSteps(t *testing.T) {
+	cases := []struct {
+		name  string
+		steps string
+		in    string
+		want  string
+	}{
+		{"zero width joiner and space", "invisible", "加\u200B微\u200D信", "加微信"},
+		{"bom and word joiner", "invisible", "\uFEFF代\u2060刷", "代刷"},
+		{"combining strikethrough", "invisible", "s\u0336b\u0336", "sb"},
+		{"accents", "invisible", "vípér", "viper"},
+		{"hangul filler", "invisible", "傻\u3164逼", "傻逼"},
+		{"circled letters", "nfkc", "ⓋⓍ", "VX"},
+		{"mathematical bold", "nfkc", "𝐰𝐱", "wx"},
+		{"full width digits", "nfkc", "ＱＱ１２３", "QQ123"},
+		{"kangxi radicals", "nfkc", "⼈", "人"},
+		{"cyrillic look-alikes", "confusables", "vх сом", "vx com"},
+		{"greek upper look-alikes", "confusables", "ΒΙΤ", "BIT"},
+		{"radical split", "confusables", "你女马的", "你妈的"},
+		{"split name", "confusables", "弓长三", "张三"},
+		{"traditional chinese", "t2s", "加微信領取優惠", "加微信领取优惠"},
+		{"traditional swear", "t2s", "傻逼東西", "傻逼东西"},
+		{"emoji padding", "emoji", "加🙏微❤\uFE0F信", "加微信"},
+		{"flag and zwj sequence", "emoji", "代👨\u200D👩\u200D👧刷🇨🇳", "代刷"},
+		{"keycap", "emoji", "1\uFE0F\u20E3号", "1号"},
+		{"pipeline order", "t2s,emoji,nfkc,invisible,confusables", "ⓋⓍ\u200B：а😀領", "VX:a领"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			steps, err := ParseNormalizeSteps(tc.steps)
+			if err != nil {
+				t.Fatalf("ParseNormalizeSteps failed: %v", err)
+			}
+			if got := NewNormalizer(steps).Apply(tc.in); got != tc.want {
+				t.Fatalf("Apply(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseNormalizeStepsOrdersAndRejectsUnknown(t *testing.T) {
+	steps, err := ParseNormalizeSteps(" EMOJI，t2s, nfkc t2s ")
+	if err != nil {
+		t.Fatalf("ParseNormalizeSteps failed: %v", err)
+	}
+	if got := FormatNormalizeSteps(steps); got != "nfkc,t2s,emoji" {
+		t.Fatalf("unexpected canonical order: %q", got)
+	}
+	if _, err := ParseNormalizeSteps("nfkc,leet"); err == nil {
+		t.Fatal("expected unknown step to fail")
+	}
+}
+
+func TestRuleNormalizationAppliesToPlainAndRegex(t *testing.T) {
+	all := "invisible,nfkc,confusables,t2s,emoji"
+	cases := []struct {
+		name      string
+		rule      models.KeywordRule
+		text      string
+		matched   string
+		unchanged bool
+	}{
+		{"plain traditional", models.KeywordRule{Pattern: "加微信", MatchType: MatchTypePlain}, "加\u200B微😊信領優惠", "加微信", true},
+		{"plain term in traditional", models.KeywordRule{Pattern: "領取", MatchType: MatchTypePlain}, "点击领取", "領取", true},
+		{"plain cyrillic", models.KeywordRule{Pattern: "vx", MatchType: MatchTypePlain}, "加vх", "vx", true},
+		{"plain circled", models.KeywordRule{Pattern: "vx", MatchType: MatchTypePlain}, "加ⓥⓧ", "vx", true},
+		{"plain radical split", models.KeywordRule{Pattern: "你妈", MatchType: MatchTypePlain}, "亻尔女马", "你妈", true},
+		{"regex math digits", models.KeywordRule{Pattern: `qq\d{5,}`, MatchType: MatchTypeRegex}, "ｑｑ𝟏𝟐𝟑𝟒𝟓", "qq12345", true},
+		{"regex zero width", models.KeywordRule{Pattern: `v信\d+`, MatchType: MatchTypeRegex}, "V\u200B信\u200C888", "V信888", true},
+		{"pinyin strikethrough", models.KeywordRule{Pattern: "傻逼", MatchType: MatchTypePinyin}, "sh\u0336a\u0336 b\u0336i\u0336", "傻逼", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.rule.Name = tc.name
+			tc.rule.Enabled = true
+			bare, err := Compile(tc.rule)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if tc.unchanged && bare.Match(tc.text) != "" {
+				t.Fatalf("rule without normalization should not match %q", tc.text)
+			}
+			tc.rule.Normalize = all
+			normalized, err := Compile(tc.rule)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if got := normalized.Match(tc.text); got != tc.matched {
+				t.Fatalf("Match(%q) = %q, want %q", tc.text, got, tc.matched)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsUnknownNormalizeStep(t *testing.T) {
+	if _, err := Compile(models.KeywordRule{Name: "bad", Pattern: "x", Normalize: "nfkc,rot13"}); err == nil {
+		t.Fatal("expected unknown normalization step to fail compile")
+	}
+}
@@ -0,0 +1,336 @@
+package rules
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// 拼音匹配命中时使用的归一化方式，供预览说明命中来源。
+const (
+	NormalizationOriginal  = "original"
+	NormalizationPinyin    = "pinyin"
+	NormalizationInitials  = "initials"
+	NormalizationHomophone = "homophone"
+)
+
+//go:embed pinyin_dict.txt
+var pinyinDictData string
+
+//go:embed homophone.txt
+var homophoneData string
+
+var (
+	pinyinOnce  sync.Once
+	pinyinTable map[rune]string
+
+	homophoneOnce     sync.Once
+	homophoneInitials [][2]string
+	homophoneFinals   [][2]string
+)
+
+type pinyinUnitKind uint8
+
+const (
+	unitHan pinyinUnitKind = iota
+	unitLetter
+	unitOther
+)
+
+// pinyinUnit 是拼音匹配的最小单位：一个汉字（带音节）、一个拉丁字母或其他字符。
+// start 和 end 是该字符在原文本中的字节区间，用于标出命中位置。
+type pinyinUnit struct {
+	kind       pinyinUnitKind
+	r          rune
+	syllable   string
+	start, end int
+}
+
+// pinyinTerm 是编译后的拼音条件，同时保留精确音节和近音音节两份。
+type pinyinTerm struct {
+	units []pinyinUnit
+	fuzzy []pinyinUnit
+}
+
+// PinyinOf 返回汉字不带声调的拼音，非汉字或字典未收录时返回空串。
+func PinyinOf(r rune) string {
+	pinyinOnce.Do(loadPinyinTable)
+	return pinyinTable[r]
+}
+
+func loadPinyinTable() {
+	pinyinTable = make(map[rune]string, 21000)
+	for _, line := range strings.Split(pinyinDictData, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		syllable, chars, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		for _, r := range chars {
+			if _, exists := pinyinTable[r]; !exists {
+				pinyinTable[r] = syllable
+			}
+		}
+	}
+}
+
+func loadHomophoneTable() {
+	for _, line := range strings.Split(homophoneData, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		pair := [2]string{fields[1], fields[2]}
+		switch fields[0] {
+		case "initial":
+			homophoneInitials = append(homophoneInitials, pair)
+		case "final":
+			homophoneFinals = append(homophoneFinals, pair)
+		}
+	}
+}
+
+// homophoneSyllable 按近音表把音节折叠成代表音，如 shi→si、zhang→zan。
+func homophoneSyllable(syllable string) string {
+	homophoneOnce.Do(loadHomophoneTable)
+	for _, pair := range homophoneInitials {
+		if strings.HasPrefix(syllable, pair[0]) {
+			syllable = pair[1] + syllable[len(pair[0]):]
+			break
+		}
+	}
+	for _, pair := range homophoneFinals {
+		if strings.HasSuffix(syllable, pair[0]) {
+			syllable = syllable[:len(syllable)-len(pair[0])] + pair[1]
+			break
+		}
+	}
+	return syllable
+}
+
+// pinyinUnits 把文本拆成拼音单位。空白和常见的拼音分隔符被当作噪声跳过，
+// 这样 "sha bi"、"sha'bi" 与 "shabi" 等价。
+func pinyinUnits(text string) []pinyinUnit {
+	units := make([]pinyinUnit, 0, len(text))
+	for i, r := range text {
+		start, end := i, i+utf8.RuneLen(r)
+		folded := []rune(strings.ToLower(width.Fold.String(string(r))))
+		if len(folded) != 1 {
+			units = append(units, pinyinUnit{kind: unitOther, r: r, start: start, end: end})
+			continue
+		}
+		r2 := folded[0]
+		switch {
+		case unicode.IsSpace(r2) || r2 == '\'' || r2 == '-' || r2 == '·':
+			continue
+		case r2 >= 'a' && r2 <= 'z':
+			units = append(units, pinyinUnit{kind: unitLetter, r: r2, start: start, end: end})
+		default:
+			if syllable := PinyinOf(r2); syllable != "" {
+				units = append(units, pinyinUnit{kind: unitHan, r: r2, syllable: syllable, start: start, end: end})
+				continue
+			}
+			units = append(units, pinyinUnit{kind: unitOther, r: r2, start: start, end: end})
+		}
+	}
+	return units
+}
+
+func withHomophones(units []pinyinUnit) []pinyinUnit {
+	fuzzy := make([]pinyinUnit, len(units))
+	copy(fuzzy, units)
+	for i := range fuzzy {
+		if fuzzy[i].kind == unitHan {
+			fuzzy[i].syllable = homophoneSyllable(fuzzy[i].syllable)
+		}
+	}
+	return fuzzy
+}
+
+func compilePinyinTerm(term string) pinyinTerm {
+	units := pinyinUnits(term)
+	return pinyinTerm{units: units, fuzzy: withHomophones(units)}
+}
+
+// pinyinMatcher 在文本单位序列上做带回溯的对齐：
+// 条件中的汉字可以匹配同音汉字、拼写出该音节的连续字母或该音节的首字母；
+// 条件中的字母可以匹配相同字母、首字母相同的汉字，或与后续字母拼成其音节的汉字。
+type pinyinMatcher struct {
+	term []pinyinUnit
+	text []pinyinUnit
+	memo map[[2]int]pinyinAlignment
+}
+
+// pinyinAlignment 记录从某个位置开始的对齐结果，initials 表示是否用到了首字母。
+type pinyinAlignment struct {
+	end      int
+	ok       bool
+	initials bool
+}
+
+func (m *pinyinMatcher) match(j, i int) pinyinAlignment {
+	if j == len(m.term) {
+		return pinyinAlignment{end: i, ok: true}
+	}
+	if i >= len(m.text) {
+		return pinyinAlignment{}
+	}
+	key := [2]int{j, i}
+	if cached, seen := m.memo[key]; seen {
+		return cached
+	}
+
+	result := pinyinAlignment{}
+	for _, step := range m.steps(j, i) {
+		if next := m.match(step.term, step.text); next.ok {
+			result = pinyinAlignment{end: next.end, ok: true, initials: step.initials || next.initials}
+			break
+		}
+	}
+	m.memo[key] = result
+	return result
+}
+
+type pinyinStep struct {
+	term     int
+	text     int
+	initials bool
+}
+
+func (m *pinyinMatcher) steps(j, i int) []pinyinStep {
+	want := m.term[j]
+	got := m.text[i]
+	steps := make([]pinyinStep, 0, 3)
+	switch want.kind {
+	case unitHan:
+		if got.kind == unitHan && got.syllable == want.syllable {
+			steps = append(steps, pinyinStep{term: j + 1, text: i + 1})
+		}
+		if got.kind == unitLetter {
+			if n := spells(m.text, i, want.syllable); n > 0 {
+				steps = append(steps, pinyinStep{term: j + 1, text: i + n})
+			}
+			if got.r == rune(want.syllable[0]) {
+				steps = append(steps, pinyinStep{term: j + 1, text: i + 1, initials: true})
+			}
+		}
+	case unitLetter:
+		if got.kind == unitLetter && got.r == want.r {
+			steps = append(steps, pinyinStep{term: j + 1, text: i + 1})
+		}
+		if got.kind == unitHan {
+			if n := spells(m.term, j, got.syllable); n > 0 {
+				steps = append(steps, pinyinStep{term: j + n, text: i + 1})
+			}
+			if rune(got.syllable[0]) == want.r {
+				steps = append(steps, pinyinStep{term: j + 1, text: i + 1, initials: true})
+			}
+		}
+	default:
+		if got.r == want.r {
+			steps = append(steps, pinyinStep{term: j + 1, text: i + 1})
+		}
+	}
+	return steps
+}
+
+// spells 判断 units 从 from 开始的连续字母是否恰好拼出 syllable，返回消耗的单位数。
+func spells(units []pinyinUnit, from int, syllable string) int {
+	if from+len(syllable) > len(units) {
+		return 0
+	}
+	for k := 0; k < len(syllable); k++ {
+		unit := units[from+k]
+		if unit.kind != unitLetter || unit.r != rune(syllable[k]) {
+			return 0
+		}
+	}
+	return len(syllable)
+}
+
+// findPinyin 在文本任意位置尝试对齐条件，返回是否命中以及是否用到了首字母。
+func findPinyin(term, text []pinyinUnit) (ok, initials bool) {
+	_, _, initials, ok = alignPinyin(term, text)
+	return ok, initials
+}
+
+// alignPinyin 同 findPinyin，并返回命中片段对应的文本单位区间 [from, to)。
+func alignPinyin(term, text []pinyinUnit) (from, to int, initials, ok bool) {
+	if len(term) == 0 {
+		return 0, 0, false, false
+	}
+	m := &pinyinMatcher{term: term, text: text, memo: map[[2]int]pinyinAlignment{}}
+	for i := range text {
+		if result := m.match(0, i); result.ok {
+			return i, result.end, result.initials, true
+		}
+	}
+	return 0, 0, false, false
+}
+
+func (r CompiledRule) matchPinyin(text string) (string, string) {
+	if len(r.pinyinTerms) == 0 {
+		return "", ""
+	}
+	folded := strings.ToLower(width.Fold.String(text))
+	units := pinyinUnits(text)
+	var fuzzyUnits []pinyinUnit
+	if r.Homophone {
+		fuzzyUnits = withHomophones(units)
+	}
+
+	matches := make([]string, 0, len(r.pinyinTerms))
+	normalization := ""
+	for idx, term := range r.pinyinTerms {
+		kind := ""
+		if strings.Contains(folded, strings.ToLower(width.Fold.String(r.normalizer.Apply(r.terms[idx])))) {
+			kind = NormalizationOriginal
+		} else if ok, initials := findPinyin(term.units, units); ok {
+			kind = NormalizationPinyin
+			if initials {
+				kind = NormalizationInitials
+			}
+		} else if r.Homophone {
+			if ok, _ := findPinyin(term.fuzzy, fuzzyUnits); ok {
+				kind = NormalizationHomophone
+			}
+		}
+		if kind == "" {
+			if r.MatchLogic == MatchLogicAll {
+				return "", ""
+			}
+			continue
+		}
+		if r.MatchLogic != MatchLogicAll {
+			return r.terms[idx], kind
+		}
+		matches = append(matches, r.terms[idx])
+		normalization = strongerNormalization(normalization, kind)
+	}
+	if len(matches) == 0 {
+		return "", ""
+	}
+	return strings.Join(matches, ", "), normalization
+}
+
+// strongerNormalization 在“全部”逻辑下取最宽松的那种归一化作为整体说明。
+func strongerNormalization(current, next string) string {
+	rank := map[string]int{
+		"":                     0,
+		NormalizationOriginal:  1,
+		NormalizationPinyin:    2,
+		NormalizationInitials:  3,
+		NormalizationHomophone: 4,
+	}
+	if rank[next] > rank[current] {
+		return next
+	}
+	return current
+}
@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func compilePinyinRule(t *testing.T, pattern string, homophone bool, logic string) CompiledRule {
+	t.Helper()
+	compiled, err := Compile(models.KeywordRule{
+		Name:       pattern,
+		Pattern:    pattern,
+		MatchType:  MatchTypePinyin,
+		MatchLogic: logic,
+		Homophone:  homophone,
+		Enabled:    true,
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	return compiled
+}
+
+func TestPinyinMatchReportsNormalization(t *testing.T) {
+	hanRule := compilePinyinRule(t, "傻逼", false, MatchLogicSingle)
+	latinRule := compilePinyinRule(t, "sb", false, MatchLogicSingle)
+
+	cases := []struct {
+		name          string
+		rule          CompiledRule
+		text          string
+		normalization string
+	}{
+		{"original text", hanRule, "你个傻逼", NormalizationOriginal},
+		{"same syllables", hanRule, "你个沙比", NormalizationPinyin},
+		{"full pinyin", hanRule, "真是SHA BI啊", NormalizationPinyin},
+		{"mixed han and pinyin", hanRule, "傻bi一个", NormalizationPinyin},
+		{"initials typed by commenter", hanRule, "纯纯sb", NormalizationInitials},
+		{"initials rule against han text", latinRule, "你是煞笔吧", NormalizationInitials},
+		{"full-width letters", latinRule, "ＳＢ", NormalizationOriginal},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := MatchText(tc.text, []CompiledRule{tc.rule})
+			if got == nil {
+				t.Fatalf("expected %q to match", tc.text)
+			}
+			if got.Normalization != tc.normalization {
+				t.Fatalf("expected normalization %q, got %q", tc.normalization, got.Normalization)
+			}
+			if got.Matched != tc.rule.Pattern {
+				t.Fatalf("expected matched term %q, got %q", tc.rule.Pattern, got.Matched)
+			}
+		})
+	}
+}
+
+func TestPinyinMatchRespectsSyllableBoundaries(t *testing.T) {
+	rule := compilePinyinRule(t, "傻逼", false, MatchLogicSingle)
+	for _, text := range []string{"随便看看", "说不定吧", "是不是", "方便面"} {
+		if got := rule.Match(text); got != "" {
+			t.Fatalf("expected %q not to match, got %q", text, got)
+		}
+	}
+}
+
+func TestPinyinHomophoneTableIsOptIn(t *testing.T) {
+	strict := compilePinyinRule(t, "死了", false, MatchLogicSingle)
+	fuzzy := compilePinyinRule(t, "死了", true, MatchLogicSingle)
+
+	if got := strict.Match("你妈四呢"); got != "" {
+		t.Fatalf("strict pinyin rule should ignore near homophones, got %q", got)
+	}
+	got := MatchText("你妈诗呢", []CompiledRule{fuzzy})
+	if got == nil || got.Normalization != NormalizationHomophone {
+		t.Fatalf("expected homophone match, got %#v", got)
+	}
+}
+
+func TestPinyinAllLogicCombinesNormalizations(t *testing.T) {
+	rule := compilePinyinRule(t, "傻逼,废物", false, MatchLogicAll)
+	got := MatchText("你个fw真sb", []CompiledRule{rule})
+	if got == nil {
+		t.Fatal("expected all terms to match")
+	}
+	if got.Matched != "傻逼, 废物" || got.Normalization != NormalizationInitials {
+		t.Fatalf("unexpected result: %#v", got)
+	}
+	if rule.Match("你个sb") != "" {
+		t.Fatal("all logic should require every term")
+	}
+}
+
+func TestPinyinValidationAndPlainRulesUnaffected(t *testing.T) {
+	if err := Validate(" ' - ", MatchTypePinyin, false); err == nil {
+		t.Fatal("expected separator-only pinyin pattern to fail validation")
+	}
+	plain, err := Compile(models.KeywordRule{Name: "plain", Pattern: "傻逼", MatchType: MatchTypePlain, Homophone: true})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if plain.Homophone {
+		t.Fatal("homophone flag should only apply to pinyin rules")
+	}
+	if got := MatchText("沙比", []CompiledRule{plain}); got != nil {
+		t.Fatalf("plain rule should not use pinyin, got %#v", got)
+	}
+	if got := MatchText("傻逼", []CompiledRule{plain}); got == nil || got.Normalization != "" {
+		t.Fatalf("plain rule should match without normalization label, got %#v", got)
+	}
+}
@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+// TimeWindow 是一天中的生效时段，以距 0 点的分钟数表示，区间左闭右开。
+// End 不大于 Start 时跨越午夜，如 22:00-02:00。
+type TimeWindow struct {
+	Start int
+	End   int
+}
+
+func (w TimeWindow) String() string {
+	return formatClock(w.Start) + "-" + formatClock(w.End)
+}
+
+// crossesMidnight 判断时段是否跨越午夜。
+func (w TimeWindow) crossesMidnight() bool {
+	return w.End <= w.Start
+}
+
+// Schedule 是规则的生效时间：在有效期 [From, Until) 内，且星期和时段都符合时规则才参与匹配。
+// Days 为空表示每天，Windows 为空表示全天；跨午夜的时段按开始那天的星期判断。
+type Schedule struct {
+	From    *time.Time
+	Until   *time.Time
+	Days    []time.Weekday
+	Windows []TimeWindow
+}
+
+// ParseSchedule 解析并校验规则的生效时间。
+func ParseSchedule(rule models.KeywordRule) (Schedule, error) {
+	if rule.ActiveFrom != nil && rule.ActiveUntil != nil && !rule.ActiveUntil.After(*rule.ActiveFrom) {
+		return Schedule{}, fmt.Errorf("失效时间必须晚于生效时间")
+	}
+	days, err := ParseActiveDays(rule.ActiveDays)
+	if err != nil {
+		return Schedule{}, err
+	}
+	windows, err := ParseActiveHours(rule.ActiveHours)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return Schedule{From: rule.ActiveFrom, Until: rule.ActiveUntil, Days: days, Windows: windows}, nil
+}
+
+// ActiveAt 判断规则在 t 时刻是否生效，星期和时段按 t 所在时区计算。
+func (s Schedule) ActiveAt(t time.Time) bool {
+	if s.From != nil && t.Before(*s.From) {
+		return false
+	}
+	if s.Expired(t) {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if len(s.Windows) == 0 {
+		return s.onDay(t.Weekday())
+	}
+	for _, window := range s.Windows {
+		switch {
+		case !window.crossesMidnight():
+			if minute >= window.Start && minute < window.End && s.onDay(t.Weekday()) {
+				return true
+			}
+		case minute >= window.Start:
+			if s.onDay(t.Weekday()) {
+				return true
+			}
+		case minute < window.End:
+			// 午夜之后的部分属于前一天开始的时段
+			if s.onDay((t.Weekday() + 6) % 7) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Expired 判断规则在 t 时刻是否已过失效时间，过期后不会再次生效。
+func (s Schedule) Expired(t time.Time) bool {
+	return s.Until != nil && !t.Before(*s.Until)
+}
+
+func (s Schedule) onDay(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, candidate := range s.Days {
+		if candidate == day {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseActiveDays 解析生效星期，逗号分隔的 1-7（周一至周日），可用 1-5 表示范围，留空为每天。
+func ParseActiveDays(value string) ([]time.Weekday, error) {
+	seen := map[time.Weekday]bool{}
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := parseISODay(from)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = parseISODay(to); err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, fmt.Errorf("星期范围无效: %s", part)
+			}
+		}
+		for day := start; day <= end; day++ {
+			seen[time.Weekday(day%7)] = true
+		}
+	}
+	if len(seen) == 7 {
+		// 选中全部星期等同于不限制
+		return nil, nil
+	}
+	days := make([]time.Weekday, 0, len(seen))
+	for day := range seen {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return isoDay(days[i]) < isoDay(days[j]) })
+	return days, nil
+}
+
+// FormatActiveDays 把生效星期格式化为 1-7 的逗号分隔列表。
+func FormatActiveDays(days []time.Weekday) string {
+	parts := make([]string, 0, len(days))
+	for _, day := range days {
+		parts = append(parts, strconv.Itoa(isoDay(day)))
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseISODay(value string) (int, error) {
+	day, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || day < 1 || day > 7 {
+		return 0, fmt.Errorf("星期无效: %s，请填写 1-7（周一至周日）", strings.TrimSpace(value))
+	}
+	return day, nil
+}
+
+func isoDay(day time.Weekday) int {
+	if day == time.Sunday {
+		return 7
+	}
+	return int(day)
+}
+
+// ParseActiveHours 解析生效时段，逗号分隔的 HH:MM-HH:MM，结束时间不晚于开始时间时跨越午夜，留空为全天。
+func ParseActiveHours(value string) ([]TimeWindow, error) {
+	var windows []TimeWindow
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' || r == ';' || r == '；' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("时段格式无效: %s，应为 HH:MM-HH:MM", part)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("时段的开始和结束时间不能相同: %s", part)
+		}
+		windows = append(windows, TimeWindow{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+// FormatActiveHours 把生效时段格式化为逗号分隔的 HH:MM-HH:MM。
+func FormatActiveHours(windows []TimeWindow) string {
+	parts := make([]string, 0, len(windows))
+	for _, window := range windows {
+		parts = append(parts, window.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseClock 解析 HH:MM，24:00 表示当天结束。
+func parseClock(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	hour, minute, ok := strings.Cut(value, ":")
+	h, errH := strconv.Atoi(hour)
+	m, errM := strconv.Atoi(minute)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("时间无效: %s，应为 HH:MM", value)
+	}
+	return h*60 + m, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
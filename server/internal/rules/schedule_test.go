@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestParseActiveDaysAndHours(t *testing.T) {
+	days, err := ParseActiveDays("6-7, 1")
+	if err != nil || FormatActiveDays(days) != "1,6,7" {
+		t.Fatalf("ParseActiveDays = %v (%v)", FormatActiveDays(days), err)
+	}
+	if days, _ := ParseActiveDays("1-7"); days != nil {
+		t.Fatalf("expected every day to mean no restriction, got %v", days)
+	}
+	for _, bad := range []string{"0", "8", "5-2", "周一"} {
+		if _, err := ParseActiveDays(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+
+	windows, err := ParseActiveHours("9:00-18:30；22:00-02:00")
+	if err != nil || FormatActiveHours(windows) != "09:00-18:30,22:00-02:00" {
+		t.Fatalf("ParseActiveHours = %v (%v)", FormatActiveHours(windows), err)
+	}
+	for _, bad := range []string{"09:00", "25:00-26:00", "08:60-09:00", "10:00-10:00"} {
+		if _, err := ParseActiveHours(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestScheduleActiveAt(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	from, until := at("2026-03-01 00:00"), at("2026-04-01 00:00")
+	// 周五、周六晚间 22:00 至次日 02:00
+	schedule, err := ParseSchedule(models.KeywordRule{ActiveFrom: &from, ActiveUntil: &until, ActiveDays: "5,6", ActiveHours: "22:00-02:00"})
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+	cases := map[string]bool{
+		"2026-02-27 23:00": false, // 有效期之前
+		"2026-03-06 23:00": true,  // 周五晚上
+		"2026-03-07 01:30": true,  // 周五开始的时段延续到周六凌晨
+		"2026-03-08 01:30": true,  // 周六开始的时段延续到周日凌晨
+		"2026-03-09 01:30": false, // 周日开始的时段不生效
+		"2026-03-06 12:00": false, // 不在时段内
+		"2026-04-03 23:00": false, // 已过失效时间
+	}
+	for value, want := range cases {
+		if got := schedule.ActiveAt(at(value)); got != want {
+			t.Errorf("ActiveAt(%s) = %v, want %v", value, got, want)
+		}
+	}
+	if !schedule.Expired(until) || schedule.Expired(at("2026-03-31 23:59")) {
+		t.Fatal("expected the rule to expire exactly at active_until")
+	}
+
+	if _, err := ParseSchedule(models.KeywordRule{ActiveFrom: &until, ActiveUntil: &from}); err == nil {
+		t.Fatal("expected active_until before active_from to be rejected")
+	}
+}
+
+func TestCompileManySkipsInactiveRules(t *testing.T) {
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.Local) // 周五
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	rows := []models.KeywordRule{
+		{ID: 1, Name: "常驻", Pattern: "常驻", Enabled: true},
+		{ID: 2, Name: "已过期", Pattern: "过期", Enabled: true, ActiveUntil: &past},
+		{ID: 3, Name: "未开始", Pattern: "未开始", Enabled: true, ActiveFrom: &future},
+		{ID: 4, Name: "工作日白天", Pattern: "白天", Enabled: true, ActiveDays: "1-5", ActiveHours: "09:00-18:00"},
+		{ID: 5, Name: "周末", Pattern: "周末", Enabled: true, ActiveDays: "6,7"},
+		{ID: 6, Name: "时段无效", Pattern: "无效", Enabled: true, ActiveHours: "9点"},
+	}
+	compiled, errs := CompileManyAt(rows, "", now)
+	var ids []uint
+	for _, rule := range compiled {
+		ids = append(ids, rule.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 4 {
+		t.Fatalf("expected rules 1 and 4 to be active, got %v", ids)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected the invalid schedule to be reported, got %v", errs)
+	}
+}
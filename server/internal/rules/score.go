@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 规则和条件权重的取值范围，未设置时均为 1。
+const (
+	DefaultWeight = 1.0
+	MaxWeight     = 100.0
+	// MaxScoreThreshold 是任务得分阈值的上限，0 表示任意命中即举报。
+	MaxScoreThreshold = 1000.0
+)
+
+// termWeightPattern 匹配条件末尾的 ^权重，如 “代写^2.5”。
+var termWeightPattern = regexp.MustCompile(`^(.*?)\s*\^\s*(\d+(?:\.\d+)?)$`)
+
+// Contribution 是一条规则对评论得分的贡献，写入举报记录。
+type Contribution struct {
+	RuleID    uint    `json:"rule_id"`
+	RuleName  string  `json:"rule_name"`
+	MatchType string  `json:"match_type,omitempty"`
+	Revision  int     `json:"revision,omitempty"`
+	Matched   string  `json:"matched"`
+	Score     float64 `json:"score"`
+}
+
+// ValidateWeight 校验规则权重，0 视为未设置。
+func ValidateWeight(weight float64) error {
+	if weight < 0 || weight > MaxWeight {
+		return fmt.Errorf("规则权重需在 0-%g 之间", MaxWeight)
+	}
+	return nil
+}
+
+// ValidateScoreThreshold 校验任务得分阈值。
+func ValidateScoreThreshold(threshold float64) error {
+	if threshold < 0 || threshold > MaxScoreThreshold {
+		return fmt.Errorf("得分阈值需在 0-%g 之间", MaxScoreThreshold)
+	}
+	return nil
+}
+
+// supportsTermWeights 表示该类型的“任一/全部”条件可以用 ^权重 后缀。
+// 正则中的 ^ 有特殊含义，表达式自带运算符，二者都不解析条件权重。
+func supportsTermWeights(matchType, matchLogic string) bool {
+	if matchLogic == MatchLogicSingle {
+		return false
+	}
+	return matchType == MatchTypePlain || matchType == MatchTypePinyin
+}
+
+// weightedTerms 拆出条件末尾的权重，返回去重后的条件和对应权重。
+func weightedTerms(terms []string) ([]string, []float64, error) {
+	texts := make([]string, 0, len(terms))
+	weights := make([]float64, 0, len(terms))
+	custom := false
+	for _, term := range terms {
+		text, weight := term, DefaultWeight
+		if parts := termWeightPattern.FindStringSubmatch(term); parts != nil {
+			value, err := strconv.ParseFloat(parts[2], 64)
+			if err != nil || value <= 0 || value > MaxWeight {
+				return nil, nil, fmt.Errorf("条件 %s 的权重需大于 0 且不超过 %g", term, MaxWeight)
+			}
+			text, weight = strings.TrimSpace(parts[1]), value
+			if text == "" {
+				return nil, nil, fmt.Errorf("条件 %s 缺少内容", term)
+			}
+			custom = true
+		}
+		if containsString(texts, text) {
+			continue
+		}
+		texts = append(texts, text)
+		weights = append(weights, weight)
+	}
+	if !custom {
+		weights = nil
+	}
+	return texts, weights, nil
+}
+
+func (r CompiledRule) termWeight(idx int) float64 {
+	if idx < len(r.termWeights) {
+		return r.termWeights[idx]
+	}
+	return DefaultWeight
+}
+
+func (r CompiledRule) weight() float64 {
+	if r.Weight > 0 {
+		return r.Weight
+	}
+	return DefaultWeight
+}
+
+// score 计算命中规则的得分：规则权重 × 命中条件的权重之和。
+// “任一”规则会统计全部出现的条件，多个弱条件同时出现时得分叠加。
+func (r CompiledRule) score(text string) float64 {
+	return r.scoreWith(func(idx int) bool { return r.termMatches(text, idx) })
+}
+
+func (r CompiledRule) scoreWith(matched func(idx int) bool) float64 {
+	terms := r.plainTerms()
+	switch {
+	case r.MatchType == MatchTypeExpression || r.MatchLogic == MatchLogicSingle:
+		return r.weight()
+	case r.MatchLogic == MatchLogicAll:
+		total := 0.0
+		for idx := range terms {
+			total += r.termWeight(idx)
+		}
+		return r.weight() * total
+	default:
+		total := 0.0
+		for idx := range terms {
+			if matched(idx) {
+				total += r.termWeight(idx)
+			}
+		}
+		return r.weight() * total
+	}
+}
+
+// termMatches 用只含第 idx 个条件的子规则判断该条件是否出现，复用各类型的匹配逻辑。
+func (r CompiledRule) termMatches(text string, idx int) bool {
+	sub := r
+	sub.MatchLogic = MatchLogicSingle
+	sub.terms = r.plainTerms()[idx : idx+1]
+	if len(r.regexes) > idx {
+		sub.regexes = r.regexes[idx : idx+1]
+	}
+	if len(r.pinyinTerms) > idx {
+		sub.pinyinTerms = r.pinyinTerms[idx : idx+1]
+	}
+	matched, _ := sub.match(text)
+	return matched != ""
+}
+
+// TotalScore 汇总多条命中规则的得分。
+func TotalScore(matches []MatchResult) float64 {
+	total := 0.0
+	for _, match := range matches {
+		total += match.Score
+	}
+	return total
+}
+
+// ReachesThreshold 判断得分是否达到阈值，阈值为 0 时任意命中即达到。
+func ReachesThreshold(score, threshold float64) bool {
+	if threshold <= 0 {
+		return score > 0
+	}
+	// 权重可以是小数，留一点浮点误差余量。
+	return score >= threshold-1e-9
+}
+
+// Contributions 返回各命中规则的得分明细。
+func Contributions(matches []MatchResult) []Contribution {
+	items := make([]Contribution, 0, len(matches))
+	for _, match := range matches {
+		items = append(items, Contribution{
+			RuleID:    match.RuleID,
+			RuleName:  match.RuleName,
+			MatchType: match.MatchType,
+			Revision:  match.Revision,
+			Matched:   match.Matched,
+			Score:     match.Score,
+		})
+	}
+	return items
+}
+
+// FormatContributions 把得分明细编码为 JSON，存入举报记录。
+func FormatContributions(matches []MatchResult) string {
+	data, err := json.Marshal(Contributions(matches))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
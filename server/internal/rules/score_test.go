@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestRuleScores(t *testing.T) {
+	compiled := mustCompileRules(t, []models.KeywordRule{
+		{Pattern: "兼职", MatchType: MatchTypePlain, Weight: 0.5},
+		{Pattern: "日结^2, 手机^0.5, 宝妈", MatchType: MatchTypePlain, MatchLogic: MatchLogicAny},
+		{Pattern: "加, 微信", MatchType: MatchTypePlain, MatchLogic: MatchLogicAll, Weight: 1.5},
+		{Pattern: `re:/\d{6,}/ NOT 官方`, MatchType: MatchTypeExpression, Weight: 3},
+		{Pattern: "jianzhi^4;rijie", MatchType: MatchTypePinyin, MatchLogic: MatchLogicAny},
+	}, "")
+
+	cases := []struct {
+		text  string
+		want  map[uint]float64
+		total float64
+	}{
+		{"招兼职", map[uint]float64{1: 0.5, 5: 4}, 4.5},
+		{"宝妈手机兼职日结", map[uint]float64{1: 0.5, 2: 3.5, 5: 5}, 9},
+		{"加我微信", map[uint]float64{3: 3}, 3},
+		{"qq 12345678", map[uint]float64{4: 3}, 3},
+		{"官方群 12345678", map[uint]float64{}, 0},
+	}
+	engine := NewEngine(compiled)
+	for _, tc := range cases {
+		for name, matches := range map[string][]MatchResult{
+			"linear": MatchAll(tc.text, compiled),
+			"engine": engine.MatchAll(tc.text),
+		} {
+			got := map[uint]float64{}
+			for _, match := range matches {
+				got[match.RuleID] = match.Score
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("%s %q scores = %v, want %v", name, tc.text, got, tc.want)
+			}
+			for id, score := range tc.want {
+				if got[id] != score {
+					t.Fatalf("%s %q rule %d score = %v, want %v", name, tc.text, id, got[id], score)
+				}
+			}
+			if total := TotalScore(matches); total != tc.total {
+				t.Fatalf("%s %q total = %v, want %v", name, tc.text, total, tc.total)
+			}
+		}
+	}
+}
+
+func TestTermWeightsKeepEvidenceClean(t *testing.T) {
+	compiled := mustCompileRules(t, []models.KeywordRule{
+		{Pattern: "代写^3, 代考", MatchType: MatchTypePlain, MatchLogic: MatchLogicAny},
+	}, "")
+	match := MatchText("专业代写", compiled)
+	if match == nil || match.Matched != "代写" || match.Score != 3 {
+		t.Fatalf("unexpected match: %#v", match)
+	}
+	if MatchText("^3", compiled) != nil {
+		t.Fatalf("weight suffix should not be matched as text")
+	}
+
+	// 单条规则保持原样，^ 不被当作权重。
+	compiled = mustCompileRules(t, []models.KeywordRule{{Pattern: "a^2", MatchType: MatchTypePlain}}, "")
+	if match := MatchText("x a^2", compiled); match == nil || match.Score != 1 {
+		t.Fatalf("single rule should keep ^ literally: %#v", match)
+	}
+}
+
+func TestTermWeightValidation(t *testing.T) {
+	for _, pattern := range []string{"代写^0, 代考", "代写^101, 代考", "^2, 代考"} {
+		if err := Validate(pattern, MatchTypePlain, false, MatchLogicAny); err == nil {
+			t.Fatalf("Validate(%q) should fail", pattern)
+		}
+	}
+	if err := Validate(`\d+^2, x`, MatchTypeRegex, false, MatchLogicAny); err != nil {
+		t.Fatalf("regex rules should not parse term weights: %v", err)
+	}
+	if err := ValidateWeight(-1); err == nil {
+		t.Fatalf("negative rule weight should fail")
+	}
+	if err := ValidateScoreThreshold(MaxScoreThreshold + 1); err == nil {
+		t.Fatalf("threshold above limit should fail")
+	}
+}
+
+func TestReachesThreshold(t *testing.T) {
+	if !ReachesThreshold(0.5, 0) || ReachesThreshold(0, 0) {
+		t.Fatalf("zero threshold should accept any positive score")
+	}
+	if ReachesThreshold(2.9, 3) || !ReachesThreshold(0.1+0.2+2.7, 3) {
+		t.Fatalf("threshold comparison should tolerate float rounding")
+	}
+}
+
+func TestFormatContributions(t *testing.T) {
+	raw := FormatContributions([]MatchResult{{RuleID: 2, RuleName: "兼职", Matched: "兼职", Score: 1.5}})
+	var items []Contribution
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		t.Fatalf("invalid json %q: %v", raw, err)
+	}
+	if len(items) != 1 || items[0].RuleID != 2 || items[0].Score != 1.5 {
+		t.Fatalf("unexpected contributions: %#v", items)
+	}
+}
@@ -0,0 +1,70 @@
+package ruleset
+
+import (
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"gorm.io/gorm"
+)
+
+// 规则集关联表，由 models 中的 many2many 标签生成。
+const (
+	taskSetsTable = "task_rule_sets"
+	setRulesTable = "rule_set_rules"
+)
+
+// SetIDsForTask 返回任务关联的规则集ID。
+func SetIDsForTask(db *gorm.DB, taskID uint) ([]uint, error) {
+	ids := make([]uint, 0)
+	err := db.Table(taskSetsTable).Where("monitor_task_id = ?", taskID).Order("rule_set_id ASC").Pluck("rule_set_id", &ids).Error
+	return ids, err
+}
+
+// RulesForSets 返回规则集内启用的规则，多个规则集共有的规则只出现一次，按创建时间排序。
+// setIDs 为空时不返回任何规则：未关联规则集的任务只使用临时关键字，新增规则不会悄悄影响它。
+func RulesForSets(db *gorm.DB, setIDs []uint) ([]models.KeywordRule, error) {
+	rows := make([]models.KeywordRule, 0)
+	if len(setIDs) == 0 {
+		return rows, nil
+	}
+	query := MembersOf(db.Where("enabled = ?", true), setIDs...)
+	if err := query.Order("created_at ASC").Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// MembersOf 把规则查询限制为属于任一给定规则集的规则。
+func MembersOf(query *gorm.DB, setIDs ...uint) *gorm.DB {
+	members := query.Session(&gorm.Session{NewDB: true}).Table(setRulesTable).Select("keyword_rule_id").Where("rule_set_id IN ?", setIDs)
+	return query.Where("id IN (?)", members)
+}
+
+// EffectiveRules 返回任务实际生效的规则。
+func EffectiveRules(db *gorm.DB, task models.MonitorTask) ([]models.KeywordRule, error) {
+	setIDs, err := SetIDsForTask(db, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	return RulesForSets(db, setIDs)
+}
+
+// CompileForTask 编译任务生效的规则和临时关键字，监控执行和测试接口共用。
+func CompileForTask(db *gorm.DB, task models.MonitorTask) ([]rules.CompiledRule, []error) {
+	rows, err := EffectiveRules(db, task)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return rules.CompileMany(rows, task.Keywords)
+}
+
+// TaskCount 返回引用该规则集的任务数量。
+func TaskCount(db *gorm.DB, setID uint) (int64, error) {
+	var count int64
+	err := db.Table(taskSetsTable).Where("rule_set_id = ?", setID).Count(&count).Error
+	return count, err
+}
+
+// DetachRule 把规则从所有规则集中移除，删除规则时调用。
+func DetachRule(db *gorm.DB, ruleID uint) error {
+	return db.Exec("DELETE FROM "+setRulesTable+" WHERE keyword_rule_id = ?", ruleID).Error
+}
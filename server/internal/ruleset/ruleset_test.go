@@ -0,0 +1,80 @@
+package ruleset
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestEffectiveRulesUnionsSetsAndSkipsDisabled(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	rows := []models.KeywordRule{
+		{Name: "广告", Pattern: "广告", Enabled: true},
+		{Name: "引流", Pattern: "加群", Enabled: true},
+		{Name: "停用", Pattern: "停用", Enabled: true},
+		{Name: "未入集", Pattern: "其他", Enabled: true},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create rules: %v", err)
+	}
+	if err := db.Model(&rows[2]).Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable rule: %v", err)
+	}
+	spam := models.RuleSet{Name: "spam", Rules: []models.KeywordRule{rows[0], rows[1]}}
+	lure := models.RuleSet{Name: "lure", Rules: []models.KeywordRule{rows[1], rows[2]}}
+	if err := db.Create(&spam).Error; err != nil {
+		t.Fatalf("create set: %v", err)
+	}
+	if err := db.Create(&lure).Error; err != nil {
+		t.Fatalf("create set: %v", err)
+	}
+	task := models.MonitorTask{Name: "task", Keywords: "临时词", RuleSets: []models.RuleSet{spam, lure}}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	effective, err := EffectiveRules(db, task)
+	if err != nil {
+		t.Fatalf("EffectiveRules: %v", err)
+	}
+	if len(effective) != 2 || effective[0].ID != rows[0].ID || effective[1].ID != rows[1].ID {
+		t.Fatalf("effective rules = %+v, want the two enabled members once each", effective)
+	}
+	compiled, errs := CompileForTask(db, task)
+	if len(errs) != 0 || len(compiled) != 3 {
+		t.Fatalf("CompileForTask = %d rules, errors %v; want 2 rules and one ad-hoc keyword", len(compiled), errs)
+	}
+
+	bare := models.MonitorTask{Name: "bare", Keywords: "临时词"}
+	if err := db.Create(&bare).Error; err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	none, err := EffectiveRules(db, bare)
+	if err != nil || len(none) != 0 {
+		t.Fatalf("task without sets should not pick up any rule, got %d (%v)", len(none), err)
+	}
+	if compiled, errs := CompileForTask(db, bare); len(errs) != 0 || len(compiled) != 1 {
+		t.Fatalf("task without sets should only use its ad-hoc keywords, got %d rules (%v)", len(compiled), errs)
+	}
+
+	if err := DetachRule(db, rows[1].ID); err != nil {
+		t.Fatalf("DetachRule: %v", err)
+	}
+	effective, err = EffectiveRules(db, task)
+	if err != nil || len(effective) != 1 || effective[0].ID != rows[0].ID {
+		t.Fatalf("detached rule should leave every set, got %+v (%v)", effective, err)
+	}
+	if count, err := TaskCount(db, spam.ID); err != nil || count != 1 {
+		t.Fatalf("TaskCount = %d (%v), want 1", count, err)
+	}
+}
@@ -0,0 +1,294 @@
+// Package rulestats 按天汇总每条关键字规则的命中、举报、复查和白名单跳过次数，
+// 用于查看规则的效果并找出误报较多的规则。
+package rulestats
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DayLayout 是统计表中日期的格式，按服务器本地时区划分。
+const DayLayout = "2006-01-02"
+
+// Counters 是一条规则在一段时间内的计数。
+type Counters struct {
+	Matches          int64 `json:"matches"`
+	ReportsAttempted int64 `json:"reports_attempted"`
+	ReportsSucceeded int64 `json:"reports_succeeded"`
+	ConfirmedRemoved int64 `json:"confirmed_removed"`
+	ReviewRejected   int64 `json:"review_rejected"`
+	WhitelistSkips   int64 `json:"whitelist_skips"`
+}
+
+// ReportsFailed 返回举报接口未成功的次数。
+func (c Counters) ReportsFailed() int64 {
+	return c.ReportsAttempted - c.ReportsSucceeded
+}
+
+// FalsePositives 是误报的近似值：审核未通过的举报加上失败的举报。
+func (c Counters) FalsePositives() int64 {
+	return c.ReviewRejected + c.ReportsFailed()
+}
+
+func (c *Counters) add(other Counters) {
+	c.Matches += other.Matches
+	c.ReportsAttempted += other.ReportsAttempted
+	c.ReportsSucceeded += other.ReportsSucceeded
+	c.ConfirmedRemoved += other.ConfirmedRemoved
+	c.ReviewRejected += other.ReviewRejected
+	c.WhitelistSkips += other.WhitelistSkips
+}
+
+// Day 是某一天的计数。
+type Day struct {
+	Day string `json:"day"`
+	Counters
+}
+
+// Series 是一条规则在查询范围内每天的计数，没有记录的日期补零。
+type Series struct {
+	RuleID   uint     `json:"rule_id"`
+	RuleName string   `json:"rule_name"`
+	Totals   Counters `json:"totals"`
+	Daily    []Day    `json:"daily"`
+}
+
+// Noisy 是按误报近似值排序的规则。
+type Noisy struct {
+	RuleID            uint    `json:"rule_id"`
+	RuleName          string  `json:"rule_name"`
+	ReportsAttempted  int64   `json:"reports_attempted"`
+	ReportsFailed     int64   `json:"reports_failed"`
+	ReviewRejected    int64   `json:"review_rejected"`
+	ConfirmedRemoved  int64   `json:"confirmed_removed"`
+	WhitelistSkips    int64   `json:"whitelist_skips"`
+	FalsePositives    int64   `json:"false_positives"`
+	FalsePositiveRate float64 `json:"false_positive_rate"` // false_positives / reports_attempted
+}
+
+// Add 把 delta 累加到各规则在 at 当天的计数上，ruleIDs 中的 0 和重复值会被忽略。
+func Add(db *gorm.DB, at time.Time, ruleIDs []uint, delta Counters) error {
+	day := at.Local().Format(DayLayout)
+	seen := make(map[uint]bool, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		if ruleID == 0 || seen[ruleID] {
+			continue
+		}
+		seen[ruleID] = true
+		row := models.RuleDailyStat{
+			RuleID:           ruleID,
+			Day:              day,
+			Matches:          delta.Matches,
+			ReportsAttempted: delta.ReportsAttempted,
+			ReportsSucceeded: delta.ReportsSucceeded,
+			ConfirmedRemoved: delta.ConfirmedRemoved,
+			ReviewRejected:   delta.ReviewRejected,
+			WhitelistSkips:   delta.WhitelistSkips,
+		}
+		err := db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "rule_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"matches":           gorm.Expr("matches + ?", delta.Matches),
+				"reports_attempted": gorm.Expr("reports_attempted + ?", delta.ReportsAttempted),
+				"reports_succeeded": gorm.Expr("reports_succeeded + ?", delta.ReportsSucceeded),
+				"confirmed_removed": gorm.Expr("confirmed_removed + ?", delta.ConfirmedRemoved),
+				"review_rejected":   gorm.Expr("review_rejected + ?", delta.ReviewRejected),
+				"whitelist_skips":   gorm.Expr("whitelist_skips + ?", delta.WhitelistSkips),
+				"updated_at":        time.Now(),
+			}),
+		}).Create(&row).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 按评论去重的计数类型，见 AddOnce。
+const (
+	KindMatch         = "match"
+	KindWhitelistSkip = "whitelist_skip"
+)
+
+// AddOnce 与 Add 相同，但同一条评论（rpid）在同一条规则上的 kind 计数只累加一次。
+// 热门评论在每次运行时都会被重新拉取，命中和白名单跳过次数需要按评论去重；
+// 举报次数已由已举报检查去重，不经过这里。
+func AddOnce(db *gorm.DB, at time.Time, rpid int64, kind string, ruleIDs []uint, delta Counters) error {
+	fresh := make([]uint, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		if ruleID == 0 {
+			continue
+		}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RuleStatComment{RuleID: ruleID, RPID: rpid, Kind: kind})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			fresh = append(fresh, ruleID)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	return Add(db, at, fresh, delta)
+}
+
+// PruneComments 删除 before 之前记录的按评论去重数据。评论超出监控和复查的范围后
+// 不会再被拉取，去重记录无需长期保留。
+func PruneComments(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("created_at < ?", before).Delete(&models.RuleStatComment{})
+	return result.RowsAffected, result.Error
+}
+
+// ReportRuleIDs 返回举报记录涉及的全部规则：优先读取得分明细，旧记录退回到主规则。
+func ReportRuleIDs(record models.ReportRecord) []uint {
+	var contributions []rules.Contribution
+	if record.MatchedRules != "" && json.Unmarshal([]byte(record.MatchedRules), &contributions) == nil && len(contributions) > 0 {
+		ids := make([]uint, 0, len(contributions))
+		for _, item := range contributions {
+			ids = append(ids, item.RuleID)
+		}
+		return ids
+	}
+	if record.KeywordRuleID != nil {
+		return []uint{*record.KeywordRuleID}
+	}
+	return nil
+}
+
+// DeleteRule 删除规则的全部统计，在删除规则时调用。
+func DeleteRule(db *gorm.DB, ruleID uint) error {
+	if err := db.Where("rule_id = ?", ruleID).Delete(&models.RuleStatComment{}).Error; err != nil {
+		return err
+	}
+	return db.Where("rule_id = ?", ruleID).Delete(&models.RuleDailyStat{}).Error
+}
+
+// Load 读取 from 到 to（含）之间的统计并按规则汇总为每日序列。ruleID 为 0 时返回所有有记录的规则。
+func Load(db *gorm.DB, from, to time.Time, ruleID uint) ([]Series, error) {
+	query := db.Model(&models.RuleDailyStat{}).
+		Where("day >= ? AND day <= ?", from.Local().Format(DayLayout), to.Local().Format(DayLayout))
+	if ruleID > 0 {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+	var rows []models.RuleDailyStat
+	if err := query.Order("rule_id ASC, day ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	days := dayRange(from, to)
+	byRule := map[uint]map[string]Counters{}
+	order := make([]uint, 0)
+	if ruleID > 0 {
+		byRule[ruleID] = map[string]Counters{}
+		order = append(order, ruleID)
+	}
+	for _, row := range rows {
+		if byRule[row.RuleID] == nil {
+			byRule[row.RuleID] = map[string]Counters{}
+			order = append(order, row.RuleID)
+		}
+		byRule[row.RuleID][row.Day] = countersOf(row)
+	}
+
+	names, err := ruleNames(db, order)
+	if err != nil {
+		return nil, err
+	}
+	series := make([]Series, 0, len(order))
+	for _, id := range order {
+		item := Series{RuleID: id, RuleName: names[id], Daily: make([]Day, 0, len(days))}
+		for _, day := range days {
+			counters := byRule[id][day]
+			item.Totals.add(counters)
+			item.Daily = append(item.Daily, Day{Day: day, Counters: counters})
+		}
+		series = append(series, item)
+	}
+	return series, nil
+}
+
+// TopNoisy 按误报率从高到低返回最多 limit 条规则，误报率相同时误报次数多的在前。
+// 没有误报的规则不会出现在结果中。
+func TopNoisy(series []Series, limit int) []Noisy {
+	items := make([]Noisy, 0)
+	for _, item := range series {
+		totals := item.Totals
+		falsePositives := totals.FalsePositives()
+		if falsePositives <= 0 || totals.ReportsAttempted <= 0 {
+			continue
+		}
+		items = append(items, Noisy{
+			RuleID:            item.RuleID,
+			RuleName:          item.RuleName,
+			ReportsAttempted:  totals.ReportsAttempted,
+			ReportsFailed:     totals.ReportsFailed(),
+			ReviewRejected:    totals.ReviewRejected,
+			ConfirmedRemoved:  totals.ConfirmedRemoved,
+			WhitelistSkips:    totals.WhitelistSkips,
+			FalsePositives:    falsePositives,
+			FalsePositiveRate: float64(falsePositives) / float64(totals.ReportsAttempted),
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].FalsePositiveRate != items[j].FalsePositiveRate {
+			return items[i].FalsePositiveRate > items[j].FalsePositiveRate
+		}
+		if items[i].FalsePositives != items[j].FalsePositives {
+			return items[i].FalsePositives > items[j].FalsePositives
+		}
+		return items[i].RuleID < items[j].RuleID
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func countersOf(row models.RuleDailyStat) Counters {
+	return Counters{
+		Matches:          row.Matches,
+		ReportsAttempted: row.ReportsAttempted,
+		ReportsSucceeded: row.ReportsSucceeded,
+		ConfirmedRemoved: row.ConfirmedRemoved,
+		ReviewRejected:   row.ReviewRejected,
+		WhitelistSkips:   row.WhitelistSkips,
+	}
+}
+
+func ruleNames(db *gorm.DB, ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var rows []models.KeywordRule
+	if err := db.Select("id", "name").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, nil
+}
+
+func dayRange(from, to time.Time) []string {
+	start := startOfDay(from)
+	end := startOfDay(to)
+	days := make([]string, 0)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(DayLayout))
+	}
+	return days
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,177 @@
+package rulestats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestAddAccumulatesAndLoadFillsDays(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	rows := []models.KeywordRule{
+		{Name: "广告", Pattern: "广告", Enabled: true},
+		{Name: "引流", Pattern: "加群", Enabled: true},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create rules: %v", err)
+	}
+	ads, lure := rows[0].ID, rows[1].ID
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+
+	steps := []struct {
+		at    time.Time
+		ids   []uint
+		delta Counters
+	}{
+		{today, []uint{ads, lure, ads, 0}, Counters{Matches: 1}},
+		{today, []uint{ads}, Counters{Matches: 1}},
+		{today, []uint{ads, lure}, Counters{ReportsAttempted: 1, ReportsSucceeded: 1}},
+		{today, []uint{lure}, Counters{ReportsAttempted: 1}},
+		{yesterday, []uint{ads}, Counters{ReportsAttempted: 1, ReportsSucceeded: 1}},
+		{yesterday, []uint{ads}, Counters{ConfirmedRemoved: 1}},
+		{yesterday, []uint{lure}, Counters{WhitelistSkips: 2}},
+		{today, []uint{lure}, Counters{ReviewRejected: 1}},
+	}
+	for _, step := range steps {
+		if err := Add(db, step.at, step.ids, step.delta); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	series, err := Load(db, today.AddDate(0, 0, -6), today, 0)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected 2 series, got %+v", series)
+	}
+	first := series[0]
+	if first.RuleID != ads || first.RuleName != "广告" || len(first.Daily) != 7 {
+		t.Fatalf("unexpected series: %+v", first)
+	}
+	want := Counters{Matches: 2, ReportsAttempted: 2, ReportsSucceeded: 2, ConfirmedRemoved: 1}
+	if first.Totals != want {
+		t.Fatalf("expected totals %+v, got %+v", want, first.Totals)
+	}
+	if last := first.Daily[6]; last.Day != today.Format(DayLayout) || last.Matches != 2 || last.ReportsAttempted != 1 {
+		t.Fatalf("unexpected today counters: %+v", last)
+	}
+	if first.Daily[0].Counters != (Counters{}) {
+		t.Fatalf("expected empty days to be zero filled, got %+v", first.Daily[0])
+	}
+
+	noisy := TopNoisy(series, 10)
+	if len(noisy) != 1 || noisy[0].RuleID != lure {
+		t.Fatalf("expected only the lure rule to be noisy, got %+v", noisy)
+	}
+	// 2 次举报中 1 次失败、1 次复查时评论仍在
+	if noisy[0].FalsePositives != 2 || noisy[0].FalsePositiveRate != 1 || noisy[0].WhitelistSkips != 2 {
+		t.Fatalf("unexpected noisy entry: %+v", noisy[0])
+	}
+
+	only, err := Load(db, today, today, lure)
+	if err != nil {
+		t.Fatalf("Load single rule failed: %v", err)
+	}
+	if len(only) != 1 || only[0].Totals.WhitelistSkips != 0 || only[0].Totals.ReportsAttempted != 2 {
+		t.Fatalf("unexpected single rule series: %+v", only)
+	}
+
+	if err := DeleteRule(db, ads); err != nil {
+		t.Fatalf("DeleteRule failed: %v", err)
+	}
+	var remaining int64
+	db.Model(&models.RuleDailyStat{}).Where("rule_id = ?", ads).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected stats of deleted rule to be removed, got %d rows", remaining)
+	}
+}
+
+func TestReportRuleIDsFallsBackToPrimaryRule(t *testing.T) {
+	primary := uint(7)
+	withDetails := models.ReportRecord{KeywordRuleID: &primary, MatchedRules: `[{"rule_id":3,"score":1},{"rule_id":5,"score":2}]`}
+	if ids := ReportRuleIDs(withDetails); len(ids) != 2 || ids[0] != 3 || ids[1] != 5 {
+		t.Fatalf("unexpected ids from contributions: %v", ids)
+	}
+	legacy := models.ReportRecord{KeywordRuleID: &primary}
+	if ids := ReportRuleIDs(legacy); len(ids) != 1 || ids[0] != primary {
+		t.Fatalf("unexpected ids from legacy record: %v", ids)
+	}
+	if ids := ReportRuleIDs(models.ReportRecord{}); len(ids) != 0 {
+		t.Fatalf("expected no ids, got %v", ids)
+	}
+}
+
+func TestAddOnceCountsEachCommentOncePerRule(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+	now := time.Now()
+
+	// 同一条热门评论在三次运行中被重新拉取，第三次又命中了新规则 9
+	for _, ids := range [][]uint{{3}, {3}, {3, 9}} {
+		if err := AddOnce(db, now, 1001, KindMatch, ids, Counters{Matches: 1}); err != nil {
+			t.Fatalf("AddOnce failed: %v", err)
+		}
+	}
+	if err := AddOnce(db, now, 1001, KindWhitelistSkip, []uint{3}, Counters{WhitelistSkips: 1}); err != nil {
+		t.Fatalf("AddOnce failed: %v", err)
+	}
+	if err := AddOnce(db, now, 1002, KindMatch, []uint{3}, Counters{Matches: 1}); err != nil {
+		t.Fatalf("AddOnce failed: %v", err)
+	}
+
+	series, err := Load(db, now, now, 0)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	totals := map[uint]Counters{}
+	for _, item := range series {
+		totals[item.RuleID] = item.Totals
+	}
+	if totals[3].Matches != 2 || totals[3].WhitelistSkips != 1 || totals[9].Matches != 1 {
+		t.Fatalf("unexpected totals: %+v", totals)
+	}
+
+	if err := DeleteRule(db, 3); err != nil {
+		t.Fatalf("DeleteRule failed: %v", err)
+	}
+	var seen int64
+	db.Model(&models.RuleStatComment{}).Where("rule_id = ?", 3).Count(&seen)
+	if seen != 0 {
+		t.Fatalf("expected seen comments of deleted rule to be removed, got %d", seen)
+	}
+
+	// 超出保留期的去重记录被清理，较新的保留
+	if err := db.Model(&models.RuleStatComment{}).Where(&models.RuleStatComment{RuleID: 9, RPID: 1001}).Update("created_at", now.Add(-31*24*time.Hour)).Error; err != nil {
+		t.Fatalf("age seen comment: %v", err)
+	}
+	if err := AddOnce(db, now, 1003, KindMatch, []uint{9}, Counters{Matches: 1}); err != nil {
+		t.Fatalf("AddOnce failed: %v", err)
+	}
+	pruned, err := PruneComments(db, now.Add(-30*24*time.Hour))
+	if err != nil || pruned != 1 {
+		t.Fatalf("PruneComments = %d (%v), want 1", pruned, err)
+	}
+	db.Model(&models.RuleStatComment{}).Count(&seen)
+	if seen != 1 {
+		t.Fatalf("expected only the recent seen comment to remain, got %d", seen)
+	}
+}
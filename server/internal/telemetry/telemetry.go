@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiritlhl/goban/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/spiritlhl/goban"
+	serviceName         = "goban"
+)
+
+// Init 根据配置安装全局 TracerProvider。未配置 OTLP 地址时保持 OpenTelemetry
+// 默认的 no-op 实现，返回的关闭函数可安全调用。
+func Init(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	if cfg == nil || cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// 导出器自行读取 OTEL_EXPORTER_OTLP_* 环境变量（地址、请求头、是否启用 TLS 等）。
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("创建 OTLP 导出器失败: %w", err)
+	}
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+	fromEnv, err := resource.New(ctx, resource.WithFromEnv())
+	if err == nil {
+		if merged, mergeErr := resource.Merge(res, fromEnv); mergeErr == nil {
+			res = merged
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Tracer 返回 goban 统一使用的 Tracer，始终从当前全局 Provider 获取。
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartSpan 在 ctx 下创建子 span；ctx 为空时使用 Background。
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError 将错误记录到 span 并把状态置为 Error，err 为空时不做任何事。
+func RecordError(span trace.Span, err error) {
+	if span == nil || err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/spiritlhl/goban/internal/config"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+)
+
+func installTestProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestInitWithoutEndpointIsNoop(t *testing.T) {
+	shutdown, err := Init(context.Background(), &config.Config{})
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("no-op shutdown failed: %v", err)
+	}
+}
+
+func TestGormPluginRecordsStatementSpansUnderParent(t *testing.T) {
+	exporter := installTestProvider(t)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.Use(NewGormPlugin()); err != nil {
+		t.Fatalf("register plugin: %v", err)
+	}
+	type sample struct {
+		ID   uint
+		Name string
+	}
+	if err := db.AutoMigrate(&sample{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	exporter.Reset()
+
+	ctx, parent := StartSpan(context.Background(), "monitor.run")
+	tx := db.WithContext(ctx)
+	if err := tx.Create(&sample{Name: "demo"}).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var count int64
+	if err := tx.Model(&sample{}).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	names := map[string]bool{}
+	for _, span := range spans {
+		names[span.Name] = true
+		if span.Name == "monitor.run" {
+			continue
+		}
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("span %s should be a child of the run span", span.Name)
+		}
+	}
+	for _, want := range []string{"gorm.create", "gorm.query"} {
+		if !names[want] {
+			t.Fatalf("expected span %s, got %v", want, names)
+		}
+	}
+}
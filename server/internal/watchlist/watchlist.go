@@ -0,0 +1,163 @@
+package watchlist
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spiritlhl/goban/internal/models"
+	"github.com/spiritlhl/goban/internal/rules"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 重点关注用户评论的处理方式。
+const (
+	ModeAlwaysReport = "always_report" // 每条评论都举报，不要求命中规则
+	ModeExtraRules   = "extra_rules"   // 在任务规则之外额外匹配指定规则集
+	ModeNotifyOnly   = "notify_only"   // 照常匹配，发现新评论时发送通知
+)
+
+// 条目来源。
+const (
+	SourceManual = "manual"
+	SourceAuto   = "auto"
+)
+
+// always_report 产生的合成命中的规则名和匹配类型，写入举报记录。
+const (
+	RuleName  = "重点关注用户"
+	MatchType = "watchlist"
+)
+
+// ValidateMode 校验处理方式，extra_rules 必须指定规则集。
+func ValidateMode(mode string, ruleSetID uint) error {
+	switch mode {
+	case ModeAlwaysReport, ModeNotifyOnly:
+		return nil
+	case ModeExtraRules:
+		if ruleSetID == 0 {
+			return fmt.Errorf("额外规则集模式必须选择规则集")
+		}
+		return nil
+	default:
+		return fmt.Errorf("处理方式不支持")
+	}
+}
+
+// ValidateAutoMode 校验自动加入时使用的处理方式，自动加入无法指定规则集，不支持 extra_rules。
+func ValidateAutoMode(mode string) error {
+	switch mode {
+	case ModeAlwaysReport, ModeNotifyOnly:
+		return nil
+	default:
+		return fmt.Errorf("自动加入的处理方式只能是 always_report 或 notify_only")
+	}
+}
+
+// Matcher 按 UID 查找启用的重点关注用户，只在一次任务执行内使用，不并发访问。
+type Matcher struct {
+	users map[int64]*models.WatchlistUser
+}
+
+// NewMatcher 构建重点关注用户匹配器，停用的条目不包含在内。
+func NewMatcher(rows []models.WatchlistUser) Matcher {
+	m := Matcher{users: map[int64]*models.WatchlistUser{}}
+	for i := range rows {
+		row := rows[i]
+		if !row.Enabled || row.UID <= 0 {
+			continue
+		}
+		m.users[row.UID] = &row
+	}
+	return m
+}
+
+// Lookup 返回评论者对应的重点关注条目。
+func (m Matcher) Lookup(uid int64) (models.WatchlistUser, bool) {
+	if uid <= 0 {
+		return models.WatchlistUser{}, false
+	}
+	user, ok := m.users[uid]
+	if !ok {
+		return models.WatchlistUser{}, false
+	}
+	return *user, true
+}
+
+// RuleSetIDs 返回 extra_rules 条目引用的规则集，按 ID 排序。
+func (m Matcher) RuleSetIDs() []uint {
+	seen := map[uint]bool{}
+	ids := make([]uint, 0)
+	for _, user := range m.users {
+		if user.Mode == ModeExtraRules && user.RuleSetID > 0 && !seen[user.RuleSetID] {
+			seen[user.RuleSetID] = true
+			ids = append(ids, user.RuleSetID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// MarkNotified 记录将要为评论 rpid 发送 notify_only 通知，返回是否首次记录。
+// 评论按热度排序拉取，无法按发布时间判断是否新评论，因此按 rpid 去重；
+// 记录保存在数据库中，多个任务覆盖同一视频或并发执行时也只有一个能记录成功。
+func MarkNotified(db *gorm.DB, uid, rpid int64) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.WatchlistNotice{UID: uid, RPID: rpid})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ForgetNotices 删除评论者的通知记录，删除重点关注条目时调用。
+func ForgetNotices(db *gorm.DB, uid int64) error {
+	return db.Where("uid = ?", uid).Delete(&models.WatchlistNotice{}).Error
+}
+
+// Match 返回 always_report 条目的合成命中，权重为默认权重，调用方不再按得分阈值判断。
+func Match(user models.WatchlistUser) rules.MatchResult {
+	matched := user.Reason
+	if matched == "" {
+		matched = fmt.Sprintf("UID %d", user.UID)
+	}
+	return rules.MatchResult{
+		RuleName:  RuleName,
+		MatchType: MatchType,
+		Matched:   matched,
+		Score:     rules.DefaultWeight,
+	}
+}
+
+// Promote 在评论者的成功举报次数达到 threshold 时把其加入重点关注，threshold 不大于 0 时不自动加入。
+// 已有条目（包括停用的）保持不变，返回新建的条目和是否新建。
+func Promote(db *gorm.DB, uid int64, uname string, threshold int, mode string) (models.WatchlistUser, bool, error) {
+	if threshold <= 0 || uid <= 0 {
+		return models.WatchlistUser{}, false, nil
+	}
+	var existing int64
+	if err := db.Model(&models.WatchlistUser{}).Where("uid = ?", uid).Count(&existing).Error; err != nil || existing > 0 {
+		return models.WatchlistUser{}, false, err
+	}
+	var count int64
+	if err := db.Model(&models.ReportRecord{}).Where("comment_user_id = ? AND success = ?", uid, true).Count(&count).Error; err != nil {
+		return models.WatchlistUser{}, false, err
+	}
+	if count < int64(threshold) {
+		return models.WatchlistUser{}, false, nil
+	}
+	row := models.WatchlistUser{
+		UID:         uid,
+		Uname:       uname,
+		Reason:      fmt.Sprintf("成功举报 %d 次，自动加入", count),
+		Mode:        mode,
+		Source:      SourceAuto,
+		Enabled:     true,
+		ReportCount: count,
+	}
+	// 并发的任务可能同时达到阈值，UID 唯一索引冲突时视为已加入
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return models.WatchlistUser{}, false, result.Error
+	}
+	return row, true, nil
+}
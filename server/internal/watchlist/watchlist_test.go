@@ -0,0 +1,103 @@
+package watchlist
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestMatcherLookup(t *testing.T) {
+	matcher := NewMatcher([]models.WatchlistUser{
+		{UID: 1, Mode: ModeAlwaysReport, Enabled: true},
+		{UID: 2, Mode: ModeExtraRules, RuleSetID: 9, Enabled: true},
+		{UID: 3, Mode: ModeExtraRules, RuleSetID: 4, Enabled: true},
+		{UID: 4, Mode: ModeNotifyOnly, Enabled: false},
+	})
+	if _, ok := matcher.Lookup(1); !ok {
+		t.Fatal("expected uid 1 to be watched")
+	}
+	if _, ok := matcher.Lookup(4); ok {
+		t.Fatal("expected disabled entry to be skipped")
+	}
+	if ids := matcher.RuleSetIDs(); len(ids) != 2 || ids[0] != 4 || ids[1] != 9 {
+		t.Fatalf("RuleSetIDs = %v", ids)
+	}
+
+	if match := Match(models.WatchlistUser{UID: 7}); match.RuleName != RuleName || match.Matched != "UID 7" {
+		t.Fatalf("unexpected synthetic match %+v", match)
+	}
+	if err := ValidateMode(ModeExtraRules, 0); err == nil {
+		t.Fatal("expected extra_rules without rule set to be rejected")
+	}
+	if err := ValidateAutoMode(ModeExtraRules); err == nil {
+		t.Fatal("expected extra_rules to be rejected as auto mode")
+	}
+}
+
+func TestPromoteAfterThreshold(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	reports := []models.ReportRecord{
+		{TaskID: 1, CommentID: 1, CommentUserID: 42, Success: true},
+		{TaskID: 1, CommentID: 2, CommentUserID: 42, Success: false},
+		{TaskID: 2, CommentID: 3, CommentUserID: 42, Success: true},
+	}
+	if err := db.Create(&reports).Error; err != nil {
+		t.Fatalf("create reports: %v", err)
+	}
+
+	if _, created, err := Promote(db, 42, "广告号", 3, ModeNotifyOnly); err != nil || created {
+		t.Fatalf("expected 2 successful reports to stay below threshold 3, created=%v err=%v", created, err)
+	}
+	row, created, err := Promote(db, 42, "广告号", 2, ModeAlwaysReport)
+	if err != nil || !created {
+		t.Fatalf("expected promotion, created=%v err=%v", created, err)
+	}
+	if row.Source != SourceAuto || row.Mode != ModeAlwaysReport || row.ReportCount != 2 || !row.Enabled {
+		t.Fatalf("unexpected promoted row %+v", row)
+	}
+	if _, created, err := Promote(db, 42, "广告号", 2, ModeAlwaysReport); err != nil || created {
+		t.Fatalf("expected existing entry to be kept, created=%v err=%v", created, err)
+	}
+	if _, created, _ := Promote(db, 42, "广告号", 0, ModeAlwaysReport); created {
+		t.Fatal("expected threshold 0 to disable promotion")
+	}
+}
+
+func TestMarkNotifiedOncePerComment(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DB_PATH", filepath.Join(tmp, "goban.db"))
+	t.Setenv("PASSWORD", "test-password")
+	t.Setenv("GOBAN_SECRET_KEY", "test-secret")
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	db := database.GetDB()
+
+	// 热门排序下较新的评论可能先被处理，之后读到的较早评论仍应通知
+	for _, rpid := range []int64{300, 100} {
+		if fresh, err := MarkNotified(db, 42, rpid); err != nil || !fresh {
+			t.Fatalf("expected rpid %d to be notified, fresh=%v err=%v", rpid, fresh, err)
+		}
+	}
+	// 再次拉取或另一个任务读到同一条评论时不再通知
+	if fresh, err := MarkNotified(db, 42, 300); err != nil || fresh {
+		t.Fatalf("expected repeated rpid to be skipped, fresh=%v err=%v", fresh, err)
+	}
+
+	if err := ForgetNotices(db, 42); err != nil {
+		t.Fatalf("ForgetNotices failed: %v", err)
+	}
+	if fresh, err := MarkNotified(db, 42, 300); err != nil || !fresh {
+		t.Fatalf("expected notices to be cleared, fresh=%v err=%v", fresh, err)
+	}
+}
@@ -0,0 +1,232 @@
+package whitelist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+// 支持的导入导出格式。
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// csvHeader 是导出 CSV 的列，导入时按表头识别列，顺序不限，缺少的列取默认值。
+var csvHeader = []string{"uid", "uname", "uname_match", "remark", "enabled", "task_id", "target_uid", "expires_at"}
+
+// Entry 是导入导出使用的白名单条目，包含 WhitelistUser 的全部可编辑字段。
+type Entry struct {
+	UID        int64      `json:"uid,omitempty"`
+	Uname      string     `json:"uname,omitempty"`
+	UnameMatch string     `json:"uname_match,omitempty"`
+	Remark     string     `json:"remark,omitempty"`
+	Enabled    *bool      `json:"enabled,omitempty"`
+	TaskID     uint       `json:"task_id,omitempty"`
+	TargetUID  int64      `json:"target_uid,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// document 是导出 JSON 的顶层结构；导入时也接受直接的条目数组。
+type document struct {
+	Users []Entry `json:"users"`
+}
+
+// ParseFormat 规范化格式名，默认 CSV。
+func ParseFormat(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("不支持的格式: %s，可选 csv、json", value)
+	}
+}
+
+// Decode 解析导入内容。CSV 第一行必须是表头，至少包含 uid 或 uname 列。
+func Decode(data []byte, format string) ([]Entry, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if format == FormatJSON {
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var entries []Entry
+			if err := json.Unmarshal(trimmed, &entries); err != nil {
+				return nil, fmt.Errorf("JSON 解析失败: %v", err)
+			}
+			return entries, nil
+		}
+		var doc document
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, fmt.Errorf("JSON 解析失败: %v", err)
+		}
+		return doc.Users, nil
+	}
+	return decodeCSV(data)
+}
+
+func decodeCSV(data []byte) ([]Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CSV 解析失败: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasUID := columns["uid"]
+	_, hasUname := columns["uname"]
+	if !hasUID && !hasUname {
+		return nil, errors.New("CSV 表头至少需要 uid 或 uname 列")
+	}
+
+	entries := make([]Entry, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV 解析失败: %v", err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		entry := Entry{
+			Uname:      field("uname"),
+			UnameMatch: field("uname_match"),
+			Remark:     field("remark"),
+		}
+		if entry.UID, err = parseInt(field("uid")); err != nil {
+			return nil, fmt.Errorf("第 %d 行 uid 无效", line)
+		}
+		if entry.TargetUID, err = parseInt(field("target_uid")); err != nil {
+			return nil, fmt.Errorf("第 %d 行 target_uid 无效", line)
+		}
+		taskID, err := parseInt(field("task_id"))
+		if err != nil || taskID < 0 {
+			return nil, fmt.Errorf("第 %d 行 task_id 无效", line)
+		}
+		entry.TaskID = uint(taskID)
+		if raw := field("enabled"); raw != "" {
+			enabled, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行 enabled 必须是 true 或 false", line)
+			}
+			entry.Enabled = &enabled
+		}
+		if raw := field("expires_at"); raw != "" {
+			expiresAt, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行 expires_at 必须是 RFC3339 时间", line)
+			}
+			entry.ExpiresAt = &expiresAt
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func parseInt(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+// Encode 按格式导出白名单。
+func Encode(rows []models.WhitelistUser, format string) ([]byte, error) {
+	entries := FromModels(rows)
+	if format == FormatJSON {
+		data, err := json.MarshalIndent(document{Users: entries}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		expiresAt := ""
+		if entry.ExpiresAt != nil {
+			expiresAt = entry.ExpiresAt.Format(time.RFC3339)
+		}
+		record := []string{
+			formatInt(entry.UID),
+			entry.Uname,
+			entry.UnameMatch,
+			entry.Remark,
+			strconv.FormatBool(entry.Enabled == nil || *entry.Enabled),
+			formatInt(int64(entry.TaskID)),
+			formatInt(entry.TargetUID),
+			expiresAt,
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func formatInt(value int64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+// FromModels 把白名单转换为导出条目，精确匹配的 uname_match 省略。
+func FromModels(rows []models.WhitelistUser) []Entry {
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		enabled := row.Enabled
+		entry := Entry{
+			UID:       row.UID,
+			Uname:     row.Uname,
+			Remark:    row.Remark,
+			Enabled:   &enabled,
+			TaskID:    row.TaskID,
+			TargetUID: row.TargetUID,
+			ExpiresAt: row.ExpiresAt,
+		}
+		if row.UnameMatch != UnameExact {
+			entry.UnameMatch = row.UnameMatch
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Key 是判断导入条目与现有条目是否为同一条的依据：UID、用户名、匹配方式和适用范围都相同。
+func Key(row models.WhitelistUser) string {
+	match := row.UnameMatch
+	if match == "" {
+		match = UnameExact
+	}
+	return fmt.Sprintf("%d|%s|%s|%d|%d", row.UID, normalizeUname(row.Uname), match, row.TaskID, row.TargetUID)
+}
@@ -0,0 +1,71 @@
+package whitelist
+
+import (
+	"fmt"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+// 评论被自动跳过的原因。
+const (
+	TrustOwner     = "owner"      // UP 主本人的评论
+	TrustUpLiked   = "up_liked"   // UP 主点赞过的评论
+	TrustUpReplied = "up_replied" // UP 主回复过的评论
+	TrustFansMedal = "fans_medal" // 佩戴该 UP 主粉丝勋章且达到等级的评论者
+)
+
+// MaxFansMedalLevel 是粉丝勋章的最高等级。
+const MaxFansMedalLevel = 40
+
+// TrustOptions 是任务自动跳过可信评论的设置，判断只用评论接口已返回的数据。
+type TrustOptions struct {
+	SkipOwner        bool
+	SkipUpInteracted bool
+	// FansMedalLevel 大于 0 时跳过佩戴该 UP 主粉丝勋章且等级不低于该值的评论者。
+	FansMedalLevel int
+}
+
+// TrustOptionsFor 读取任务的自动跳过设置。
+func TrustOptionsFor(task models.MonitorTask) TrustOptions {
+	return TrustOptions{
+		SkipOwner:        task.SkipOwnerComments,
+		SkipUpInteracted: task.SkipUpInteracted,
+		FansMedalLevel:   task.SkipFansMedalLevel,
+	}
+}
+
+// Trusted 返回评论被自动跳过的原因，不跳过时返回空字符串。targetUID 是视频所属的 UP 主。
+func (o TrustOptions) Trusted(targetUID int64, comment bili.CommentInfo) string {
+	mid := comment.Member.Mid
+	if mid == 0 {
+		mid = comment.Mid
+	}
+	switch {
+	case o.SkipOwner && targetUID > 0 && mid == targetUID:
+		return TrustOwner
+	case o.SkipUpInteracted && comment.UpAction.Like:
+		return TrustUpLiked
+	case o.SkipUpInteracted && comment.UpAction.Reply:
+		return TrustUpReplied
+	case o.FansMedalLevel > 0 && comment.Member.FansMedalLevel() >= o.FansMedalLevel && medalOf(comment.Member.FansDetail, targetUID):
+		return TrustFansMedal
+	}
+	return ""
+}
+
+// medalOf 判断粉丝勋章是否属于 UP 主 targetUID，任一方 UID 未知时不作判断。
+func medalOf(detail *bili.FansDetail, targetUID int64) bool {
+	if detail == nil {
+		return false
+	}
+	return detail.UID == 0 || targetUID == 0 || detail.UID == targetUID
+}
+
+// ValidateFansMedalLevel 校验粉丝勋章等级，0 表示不按粉丝勋章跳过。
+func ValidateFansMedalLevel(level int) error {
+	if level < 0 || level > MaxFansMedalLevel {
+		return fmt.Errorf("粉丝勋章等级必须在 0-%d 之间", MaxFansMedalLevel)
+	}
+	return nil
+}
@@ -1,45 +1,226 @@
 package whitelist
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spiritlhl/goban/internal/models"
 )
 
+// 用户名的匹配方式。
+const (
+	UnameExact = "exact" // 忽略大小写和首尾空白的精确匹配
+	UnameGlob  = "glob"  // * 匹配任意个字符，? 匹配一个字符，忽略大小写
+	UnameRegex = "regex" // 正则表达式，在用户名任意位置匹配即可，忽略大小写
+)
+
+// maxUnamePattern 是用户名模式的最大长度。
+const maxUnamePattern = 200
+
+// Scope 是评论所属的任务和 UP 主，决定哪些白名单条目适用。
+type Scope struct {
+	TaskID    uint
+	TargetUID int64
+}
+
+// entry 是一条白名单的适用范围，TaskID 或 TargetUID 为 0 表示不限。
+type entry struct {
+	taskID    uint
+	targetUID int64
+}
+
+func (e entry) appliesTo(scope Scope) bool {
+	return (e.taskID == 0 || e.taskID == scope.TaskID) && (e.targetUID == 0 || e.targetUID == scope.TargetUID)
+}
+
+// pattern 是一条通配符或正则用户名条目。
+type pattern struct {
+	re    *regexp.Regexp
+	scope entry
+}
+
 type Matcher struct {
-	uids   map[int64]struct{}
-	unames map[string]struct{}
+	uids     map[int64][]entry
+	unames   map[string][]entry
+	patterns []pattern
 }
 
-func NewMatcher(rows []models.WhitelistUser) Matcher {
+// NewMatcher 构建白名单匹配器，停用和在 now 时已过期的条目不包含在内。
+// 通配符和正则条目在这里编译一次；保存时已校验，无法编译的条目直接跳过。
+func NewMatcher(rows []models.WhitelistUser, now time.Time) Matcher {
 	m := Matcher{
-		uids:   map[int64]struct{}{},
-		unames: map[string]struct{}{},
+		uids:   map[int64][]entry{},
+		unames: map[string][]entry{},
 	}
 	for _, row := range rows {
-		if !row.Enabled {
+		if !row.Enabled || Expired(row, now) {
 			continue
 		}
+		scope := entry{taskID: row.TaskID, targetUID: row.TargetUID}
 		if row.UID > 0 {
-			m.uids[row.UID] = struct{}{}
+			m.uids[row.UID] = append(m.uids[row.UID], scope)
+		}
+		uname := normalizeUname(row.Uname)
+		if uname == "" {
+			continue
 		}
-		if strings.TrimSpace(row.Uname) != "" {
-			m.unames[strings.ToLower(strings.TrimSpace(row.Uname))] = struct{}{}
+		switch row.UnameMatch {
+		case UnameGlob, UnameRegex:
+			if re, err := compileUname(row.Uname, row.UnameMatch); err == nil {
+				m.patterns = append(m.patterns, pattern{re: re, scope: scope})
+			}
+		default:
+			m.unames[uname] = append(m.unames[uname], scope)
 		}
 	}
 	return m
 }
 
-func (m Matcher) Contains(uid int64, uname string) bool {
-	if uid > 0 {
-		if _, ok := m.uids[uid]; ok {
-			return true
-		}
+// Contains 判断评论者是否在适用于 scope 的白名单中。
+func (m Matcher) Contains(scope Scope, uid int64, uname string) bool {
+	if uid > 0 && anyApplies(m.uids[uid], scope) {
+		return true
 	}
-	uname = strings.ToLower(strings.TrimSpace(uname))
+	uname = normalizeUname(uname)
 	if uname == "" {
 		return false
 	}
-	_, ok := m.unames[uname]
-	return ok
+	if anyApplies(m.unames[uname], scope) {
+		return true
+	}
+	for _, p := range m.patterns {
+		if p.scope.appliesTo(scope) && p.re.MatchString(uname) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateUname 校验用户名条目。通配符和正则必须能编译，且不能匹配空用户名，
+// 避免 * 或 .* 这类模式把所有评论者都加入白名单。
+func ValidateUname(uname, match string) error {
+	switch match {
+	case "", UnameExact:
+		return nil
+	case UnameGlob, UnameRegex:
+	default:
+		return errors.New("用户名匹配方式不支持")
+	}
+	if strings.TrimSpace(uname) == "" {
+		return errors.New("通配符和正则条目必须填写用户名")
+	}
+	if len([]rune(uname)) > maxUnamePattern {
+		return fmt.Errorf("用户名模式不能超过 %d 个字符", maxUnamePattern)
+	}
+	re, err := compileUname(uname, match)
+	if err != nil {
+		return fmt.Errorf("用户名正则无效: %v", err)
+	}
+	if re.MatchString("") {
+		return errors.New("用户名模式不能匹配所有用户")
+	}
+	return nil
+}
+
+// compileUname 把通配符或正则用户名编译为忽略大小写的正则，通配符需匹配整个用户名。
+func compileUname(uname, match string) (*regexp.Regexp, error) {
+	uname = strings.TrimSpace(uname)
+	if match == UnameRegex {
+		return regexp.Compile("(?i)" + uname)
+	}
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, r := range uname {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// Index 按评论者查找指向它的白名单条目，不考虑启用状态、过期时间和适用范围，
+// 用于评论者档案和排行。与 Matcher 一样，通配符和正则条目只编译一次。
+type Index struct {
+	rows   []models.WhitelistUser
+	unames []string         // 精确条目规范化后的用户名
+	res    []*regexp.Regexp // 通配符和正则条目编译后的模式，无法编译时为 nil
+}
+
+// NewIndex 构建白名单索引，Lookup 按 rows 的顺序返回条目。
+func NewIndex(rows []models.WhitelistUser) Index {
+	x := Index{
+		rows:   rows,
+		unames: make([]string, len(rows)),
+		res:    make([]*regexp.Regexp, len(rows)),
+	}
+	for i, row := range rows {
+		if normalizeUname(row.Uname) == "" {
+			continue
+		}
+		switch row.UnameMatch {
+		case UnameGlob, UnameRegex:
+			if re, err := compileUname(row.Uname, row.UnameMatch); err == nil {
+				x.res[i] = re
+			}
+		default:
+			x.unames[i] = normalizeUname(row.Uname)
+		}
+	}
+	return x
+}
+
+// Lookup 返回 UID 或任一用户名指向该评论者的条目。
+func (x Index) Lookup(uid int64, unames ...string) []models.WhitelistUser {
+	normalized := make([]string, 0, len(unames))
+	for _, uname := range unames {
+		if uname = normalizeUname(uname); uname != "" {
+			normalized = append(normalized, uname)
+		}
+	}
+	found := make([]models.WhitelistUser, 0)
+	for i, row := range x.rows {
+		if (row.UID > 0 && row.UID == uid) || x.matchesUname(i, normalized) {
+			found = append(found, row)
+		}
+	}
+	return found
+}
+
+func (x Index) matchesUname(i int, unames []string) bool {
+	for _, uname := range unames {
+		if x.res[i] != nil && x.res[i].MatchString(uname) {
+			return true
+		}
+		if x.unames[i] != "" && x.unames[i] == uname {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired 判断白名单条目在 now 时是否已过期，未设置过期时间的条目长期有效。
+func Expired(row models.WhitelistUser, now time.Time) bool {
+	return row.ExpiresAt != nil && !now.Before(*row.ExpiresAt)
+}
+
+func anyApplies(entries []entry, scope Scope) bool {
+	for _, e := range entries {
+		if e.appliesTo(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeUname(uname string) string {
+	return strings.ToLower(strings.TrimSpace(uname))
 }
@@ -0,0 +1,179 @@
+package whitelist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiritlhl/goban/internal/bili"
+	"github.com/spiritlhl/goban/internal/models"
+)
+
+func TestMatcherScopesAndExpiry(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	matcher := NewMatcher([]models.WhitelistUser{
+		{UID: 1, Enabled: true},
+		{UID: 2, TaskID: 7, Enabled: true},
+		{UID: 3, TargetUID: 100, Enabled: true},
+		{Uname: " 房管小号 ", TaskID: 7, TargetUID: 100, Enabled: true},
+		{UID: 4, ExpiresAt: &past, Enabled: true},
+		{UID: 5, ExpiresAt: &future, Enabled: true},
+		{UID: 6, Enabled: false},
+	}, now)
+
+	cases := []struct {
+		name  string
+		scope Scope
+		uid   int64
+		uname string
+		want  bool
+	}{
+		{"全局条目", Scope{TaskID: 9, TargetUID: 200}, 1, "", true},
+		{"任务范围内", Scope{TaskID: 7, TargetUID: 200}, 2, "", true},
+		{"其他任务", Scope{TaskID: 8, TargetUID: 200}, 2, "", false},
+		{"UP 主范围内", Scope{TaskID: 8, TargetUID: 100}, 3, "", true},
+		{"其他 UP 主", Scope{TaskID: 8, TargetUID: 200}, 3, "", false},
+		{"任务和 UP 主都符合", Scope{TaskID: 7, TargetUID: 100}, 0, "房管小号", true},
+		{"只符合任务", Scope{TaskID: 7, TargetUID: 200}, 0, "房管小号", false},
+		{"已过期", Scope{TaskID: 7, TargetUID: 100}, 4, "", false},
+		{"未过期", Scope{TaskID: 7, TargetUID: 100}, 5, "", true},
+		{"已停用", Scope{TaskID: 7, TargetUID: 100}, 6, "", false},
+	}
+	for _, tc := range cases {
+		if got := matcher.Contains(tc.scope, tc.uid, tc.uname); got != tc.want {
+			t.Errorf("%s: Contains = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTrustOptions(t *testing.T) {
+	comment := func(mid int64, medal int, liked, replied bool) bili.CommentInfo {
+		var c bili.CommentInfo
+		c.Member.Mid = mid
+		if medal > 0 {
+			c.Member.FansDetail = &bili.FansDetail{Level: medal}
+		}
+		c.UpAction.Like = liked
+		c.UpAction.Reply = replied
+		return c
+	}
+	medalFrom := func(owner int64, level int) bili.CommentInfo {
+		c := comment(1, level, false, false)
+		c.Member.FansDetail.UID = owner
+		return c
+	}
+	options := TrustOptionsFor(models.MonitorTask{SkipOwnerComments: true, SkipUpInteracted: true, SkipFansMedalLevel: 20})
+	cases := []struct {
+		name    string
+		comment bili.CommentInfo
+		want    string
+	}{
+		{"UP 主本人", comment(100, 0, false, false), TrustOwner},
+		{"UP 主点赞", comment(1, 0, true, false), TrustUpLiked},
+		{"UP 主回复", comment(1, 0, false, true), TrustUpReplied},
+		{"高等级粉丝", comment(1, 21, false, false), TrustFansMedal},
+		{"低等级粉丝", comment(1, 19, false, false), ""},
+		{"普通评论", comment(1, 0, false, false), ""},
+		{"本 UP 主的高等级勋章", medalFrom(100, 30), TrustFansMedal},
+		{"其他 UP 主的高等级勋章", medalFrom(200, 30), ""},
+	}
+	for _, tc := range cases {
+		if got := options.Trusted(100, tc.comment); got != tc.want {
+			t.Errorf("%s: Trusted = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := (TrustOptions{}).Trusted(100, comment(100, 30, true, true)); got != "" {
+		t.Fatalf("expected nothing to be skipped without options, got %q", got)
+	}
+	if ValidateFansMedalLevel(MaxFansMedalLevel+1) == nil || ValidateFansMedalLevel(-1) == nil || ValidateFansMedalLevel(0) != nil {
+		t.Fatal("unexpected fans medal level validation")
+	}
+}
+
+func TestMatcherUnamePatterns(t *testing.T) {
+	matcher := NewMatcher([]models.WhitelistUser{
+		{Uname: "*官方*", UnameMatch: UnameGlob, Enabled: true},
+		{Uname: "bili_??", UnameMatch: UnameGlob, TaskID: 7, Enabled: true},
+		{Uname: `^房管\d+号$`, UnameMatch: UnameRegex, Enabled: true},
+		{Uname: "(", UnameMatch: UnameRegex, Enabled: true},
+		{Uname: "a.b", Enabled: true},
+	}, time.Now())
+	cases := []struct {
+		name  string
+		scope Scope
+		uname string
+		want  bool
+	}{
+		{"通配符包含", Scope{TaskID: 1}, "哔哩哔哩官方账号", true},
+		{"通配符不含", Scope{TaskID: 1}, "普通用户", false},
+		{"问号匹配单个字符且忽略大小写", Scope{TaskID: 7}, "BILI_01", true},
+		{"问号长度不符", Scope{TaskID: 7}, "bili_001", false},
+		{"通配符条目的范围", Scope{TaskID: 8}, "bili_01", false},
+		{"正则", Scope{TaskID: 1}, "房管12号", true},
+		{"正则不符", Scope{TaskID: 1}, "房管十二号", false},
+		{"精确条目中的点不是通配符", Scope{TaskID: 1}, "axb", false},
+		{"精确条目", Scope{TaskID: 1}, "A.B", true},
+	}
+	for _, tc := range cases {
+		if got := matcher.Contains(tc.scope, 0, tc.uname); got != tc.want {
+			t.Errorf("%s: Contains(%q) = %v, want %v", tc.name, tc.uname, got, tc.want)
+		}
+	}
+
+	index := NewIndex([]models.WhitelistUser{
+		{ID: 1, Uname: "*官方*", UnameMatch: UnameGlob, TaskID: 3},
+		{ID: 2, UID: 42, Enabled: true},
+		{ID: 3, Uname: "(", UnameMatch: UnameRegex},
+		{ID: 4, Uname: "A.B"},
+	})
+	found := index.Lookup(42, "旧名字", "哔哩哔哩官方", "a.b")
+	if len(found) != 3 || found[0].ID != 1 || found[1].ID != 2 || found[2].ID != 4 {
+		t.Errorf("Lookup should ignore scope and enabled state and keep row order, got %+v", found)
+	}
+	if found := index.Lookup(7, "普通用户"); len(found) != 0 {
+		t.Errorf("expected no entries, got %+v", found)
+	}
+
+	for _, bad := range []struct{ uname, match string }{{"*", UnameGlob}, {".*", UnameRegex}, {"(", UnameRegex}, {"", UnameGlob}, {"x", "like"}} {
+		if err := ValidateUname(bad.uname, bad.match); err == nil {
+			t.Errorf("expected ValidateUname(%q, %q) to fail", bad.uname, bad.match)
+		}
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	expiresAt := time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC)
+	rows := []models.WhitelistUser{
+		{UID: 1, Uname: "房管", UnameMatch: UnameExact, Remark: "备注, 含逗号", Enabled: true, TaskID: 3, TargetUID: 100, ExpiresAt: &expiresAt},
+		{Uname: "*官方*", UnameMatch: UnameGlob, Enabled: false},
+	}
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		data, err := Encode(rows, format)
+		if err != nil {
+			t.Fatalf("Encode(%s) failed: %v", format, err)
+		}
+		entries, err := Decode(data, format)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("Decode(%s) = %d entries (%v)", format, len(entries), err)
+		}
+		first, second := entries[0], entries[1]
+		if first.UID != 1 || first.Remark != "备注, 含逗号" || first.TaskID != 3 || first.TargetUID != 100 || first.ExpiresAt == nil || !first.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("%s: unexpected first entry %+v", format, first)
+		}
+		if second.UnameMatch != UnameGlob || second.Enabled == nil || *second.Enabled {
+			t.Errorf("%s: unexpected second entry %+v", format, second)
+		}
+	}
+
+	// 手写的 CSV：带 BOM、列顺序不同、缺少可选列
+	entries, err := Decode([]byte("\ufeffuname,uid\n张三,\n,42\n"), FormatCSV)
+	if err != nil || len(entries) != 2 || entries[0].Uname != "张三" || entries[1].UID != 42 || entries[0].Enabled != nil {
+		t.Fatalf("Decode(handwritten csv) = %+v (%v)", entries, err)
+	}
+	if _, err := Decode([]byte("remark\nx\n"), FormatCSV); err == nil {
+		t.Fatal("expected csv without uid or uname column to be rejected")
+	}
+	if entries, err := Decode([]byte(`[{"uid": 7}]`), FormatJSON); err != nil || len(entries) != 1 || entries[0].UID != 7 {
+		t.Fatalf("Decode(json array) = %+v (%v)", entries, err)
+	}
+}
@@ -2,8 +2,9 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -11,9 +12,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/spiritlhl/goban/internal/config"
 	"github.com/spiritlhl/goban/internal/database"
+	"github.com/spiritlhl/goban/internal/logging"
 	"github.com/spiritlhl/goban/internal/middleware"
 	"github.com/spiritlhl/goban/internal/monitor"
 	"github.com/spiritlhl/goban/internal/routes"
+	"github.com/spiritlhl/goban/internal/telemetry"
 )
 
 // @title Goban API
@@ -25,12 +28,19 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// 加载配置
+	// 加载配置，同时按 LOG_LEVEL / LOG_FORMAT 安装结构化日志
 	cfg := config.LoadConfig()
+	logger := logging.For("server")
+
+	// 初始化链路追踪，未配置 OTLP 地址时为 no-op
+	shutdownTracing, err := telemetry.Init(ctx, cfg)
+	if err != nil {
+		fatal(logger, "初始化链路追踪失败", err)
+	}
 
 	// 初始化数据库
 	if err := database.InitDB(); err != nil {
-		log.Fatalf("初始化数据库失败: %v", err)
+		fatal(logger, "初始化数据库失败", err)
 	}
 
 	// 初始化监控服务
@@ -45,14 +55,15 @@ func main() {
 	}
 
 	// 创建路由
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.Recovery(), middleware.RequestLogger())
 	router.Use(middleware.CORS(cfg))
 
 	// 设置路由
 	routes.SetupRoutes(router)
 
 	// 启动服务器
-	log.Printf("服务器启动在端口 %s", cfg.Port)
+	logger.Info("服务器启动", "port", cfg.Port)
 	server := &http.Server{
 		Addr:              ":" + cfg.Port,
 		Handler:           router,
@@ -68,10 +79,10 @@ func main() {
 
 	select {
 	case <-ctx.Done():
-		log.Println("收到退出信号，正在关闭服务")
+		logger.Info("收到退出信号，正在关闭服务")
 	case err := <-serverErr:
 		if err != nil {
-			log.Fatalf("启动服务器失败: %v", err)
+			fatal(logger, "启动服务器失败", err)
 		}
 		return
 	}
@@ -79,7 +90,15 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("关闭 HTTP 服务失败: %v", err)
+		logger.Error("关闭 HTTP 服务失败", slog.Any(logging.KeyError, err))
 	}
 	monitorService.Stop()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("关闭链路追踪失败", slog.Any(logging.KeyError, err))
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, slog.Any(logging.KeyError, err))
+	os.Exit(1)
 }
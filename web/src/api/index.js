@@ -114,7 +114,8 @@ export const taskAPI = {
   create: (data) => request.post('/tasks/create', data),
   update: (id, data) => request.put(`/tasks/${id}`, data),
   delete: (id, params) => request.delete(`/tasks/${id}`, { params }),
-  test: (id) => request.get(`/tasks/${id}/test`)
+  test: (id) => request.get(`/tasks/${id}/test`),
+  rules: (id) => request.get(`/tasks/${id}/rules`)
 }
 
 export const logAPI = {
@@ -128,14 +129,60 @@ export const keywordAPI = {
   create: (data) => request.post('/keywords/create', data),
   update: (id, data) => request.put(`/keywords/${id}`, data),
   delete: (id, params) => request.delete(`/keywords/${id}`, { params }),
-  preview: (data) => request.post('/keywords/preview', data)
+  preview: (data) => request.post('/keywords/preview', data),
+  tests: () => request.get('/keywords/tests'),
+  stats: (params) => request.get('/keywords/stats', { params }),
+  revisions: (id) => request.get(`/keywords/${id}/revisions`),
+  diff: (id, params) => request.get(`/keywords/${id}/diff`, { params }),
+  rollback: (id, data) => request.post(`/keywords/${id}/rollback`, data),
+  import: (data) => request.post('/keywords/import', data),
+  export: (params) => request.get('/keywords/export', { params, responseType: 'blob' })
+}
+
+export const ruleSetAPI = {
+  list: () => request.get('/rule-sets/list'),
+  create: (data) => request.post('/rule-sets/create', data),
+  update: (id, data) => request.put(`/rule-sets/${id}`, data),
+  delete: (id, params) => request.delete(`/rule-sets/${id}`, { params })
+}
+
+export const clusterAPI = {
+  list: (params) => request.get('/clusters/list', { params }),
+  get: (id) => request.get(`/clusters/${id}`),
+  report: (id) => request.post(`/clusters/${id}/report`),
+  dismiss: (id) => request.post(`/clusters/${id}/dismiss`)
+}
+
+export const classifierAPI = {
+  models: () => request.get('/classifier/models'),
+  train: (data) => request.post('/classifier/train', data),
+  evaluate: (data) => request.post('/classifier/evaluate', data),
+  activate: (id) => request.post(`/classifier/models/${id}/activate`),
+  deleteModel: (id, params) => request.delete(`/classifier/models/${id}`, { params }),
+  samples: (params) => request.get('/classifier/samples', { params }),
+  createSample: (data) => request.post('/classifier/samples', data),
+  deleteSample: (id, params) => request.delete(`/classifier/samples/${id}`, { params })
 }
 
 export const whitelistAPI = {
   list: () => request.get('/whitelist/list'),
   create: (data) => request.post('/whitelist/create', data),
   update: (id, data) => request.put(`/whitelist/${id}`, data),
-  delete: (id, params) => request.delete(`/whitelist/${id}`, { params })
+  delete: (id, params) => request.delete(`/whitelist/${id}`, { params }),
+  import: (data) => request.post('/whitelist/import', data),
+  export: (params) => request.get('/whitelist/export', { params, responseType: 'blob' })
+}
+
+export const watchlistAPI = {
+  list: (params) => request.get('/watchlist/list', { params }),
+  create: (data) => request.post('/watchlist/create', data),
+  update: (id, data) => request.put(`/watchlist/${id}`, data),
+  delete: (id, params) => request.delete(`/watchlist/${id}`, { params })
+}
+
+export const commenterAPI = {
+  top: (params) => request.get('/commenters/top', { params }),
+  profile: (uid) => request.get(`/commenters/${uid}`)
 }
 
 export const settingsAPI = {
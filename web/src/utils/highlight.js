@@ -0,0 +1,51 @@
+// 按命中片段的字符偏移（rune_start/rune_end）把文本切分为普通段和高亮段。
+// 偏移按 Unicode 码点计算，与 Array.from 的切分一致；重叠的片段合并后一起高亮。
+export function highlightSegments(text, spans) {
+  const chars = Array.from(text || '')
+  const ranges = (spans || [])
+    .filter((span) => span.group === undefined || span.group === 0)
+    .map((span) => ({ start: Math.max(0, span.rune_start), end: Math.min(chars.length, span.rune_end), terms: [span.term] }))
+    .filter((range) => range.start < range.end)
+    .sort((a, b) => a.start - b.start)
+
+  const merged = []
+  for (const range of ranges) {
+    const last = merged[merged.length - 1]
+    if (last && range.start <= last.end) {
+      last.end = Math.max(last.end, range.end)
+      if (!last.terms.includes(range.terms[0])) last.terms.push(range.terms[0])
+    } else {
+      merged.push({ ...range })
+    }
+  }
+
+  const segments = []
+  let cursor = 0
+  for (const range of merged) {
+    if (range.start > cursor) segments.push({ text: chars.slice(cursor, range.start).join(''), hit: false })
+    segments.push({ text: chars.slice(range.start, range.end).join(''), hit: true, title: range.terms.filter(Boolean).join('、') })
+    cursor = range.end
+  }
+  if (cursor < chars.length) segments.push({ text: chars.slice(cursor).join(''), hit: false })
+  return segments
+}
+
+// 把各命中规则的片段汇总到一起。
+export function collectSpans(explanations) {
+  return (explanations || []).flatMap((item) => item.spans || [])
+}
+
+const stepLabels = {
+  invisible: '去零宽/组合字符',
+  nfkc: 'NFKC',
+  confusables: '形近字/拆字',
+  t2s: '繁转简',
+  emoji: '去表情',
+  width: '全角转半角',
+  lowercase: '转小写',
+  extract: '提取链接和联系方式'
+}
+
+export function stepLabel(step) {
+  return stepLabels[step] || step
+}